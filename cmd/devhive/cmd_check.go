@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iguchi/devhive/internal/checks"
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// checkCmd runs a worker's checks in its worktree
+func checkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [worker]",
+		Short: "Run a worker's checks in its worktree",
+		Long: `Run the worker's checks (defaults.checks followed by the worker's own
+checks) in its worktree and store the results.
+
+The same checks gate completion: with defaults.auto_complete, progress 100
+('devhive progress', the HTTP API and the MCP report_progress tool) marks
+the worker completed only when every check passes. Otherwise the worker is
+set to error with the failing checks as last_error.
+
+Checks run with the shell, in the worker's environment (DEVHIVE_WORKER and
+its env / env_file). The command fails when a check fails.
+
+Examples:
+  devhive check frontend          # Run frontend's checks
+  devhive check                   # Run $DEVHIVE_WORKER's checks (from a worktree)
+  devhive check --all             # Every worker of the active sprint
+  devhive check frontend --last   # Show the latest results without running
+  devhive check --all --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			last, _ := cmd.Flags().GetBool("last")
+
+			configFile, err := FindProjectComposeFile()
+			if err != nil {
+				return err
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+
+			var names []string
+			if all {
+				if len(args) > 0 {
+					return fmt.Errorf("--all cannot be used with a worker name")
+				}
+				if names, err = database.GetAllWorkerNames(); err != nil {
+					return err
+				}
+			} else {
+				name, err := getWorkerName(args, 0)
+				if err != nil {
+					return err
+				}
+				w, err := database.GetWorker(name)
+				if err != nil {
+					return err
+				}
+				if w == nil {
+					return fmt.Errorf("worker not found: %s", name)
+				}
+				names = []string{name}
+			}
+
+			formatter, err := getOutputFormatter(cmd)
+			if err != nil {
+				return err
+			}
+			var log io.Writer = os.Stdout
+			if formatter != nil {
+				log = nil
+			}
+
+			results := []db.CheckResult{}
+			failed := 0
+			for _, name := range names {
+				var workerResults []db.CheckResult
+				if last {
+					workerResults, err = database.GetCheckResults(name)
+					if err == nil && log != nil {
+						printCheckResults(name, workerResults)
+					}
+				} else {
+					if len(config.GetWorkerChecks(name)) == 0 {
+						if log != nil {
+							fmt.Printf("%s: no checks configured\n", name)
+						}
+						continue
+					}
+					if log != nil {
+						fmt.Printf("▶ %s\n", name)
+					}
+					workerResults, err = runWorkerChecks(config, filepath.Dir(configFile), name, log)
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				if checkFailure(name, workerResults) != "" {
+					failed++
+				}
+				results = append(results, workerResults...)
+			}
+
+			if formatter != nil {
+				if err := formatter.Write(os.Stdout, results); err != nil {
+					return err
+				}
+			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("checks failed for %d of %d worker(s)", failed, len(names))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Check every worker of the active sprint")
+	cmd.Flags().Bool("last", false, "Show the latest stored results instead of running the checks")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// workerWorktreeDir returns the absolute worktree path of a worker
+func workerWorktreeDir(config *ComposeConfig, projectRoot, name string) string {
+	dir := getWorktreePath(name, config.Workers[name].Worktree)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(projectRoot, dir)
+	}
+	return dir
+}
+
+// runWorkerChecks runs a worker's checks in its worktree, stores each result
+// and logs a worker_checked event. Progress is written to log (nil: silent).
+func runWorkerChecks(config *ComposeConfig, projectRoot, name string, log io.Writer) ([]db.CheckResult, error) {
+	if log == nil {
+		log = io.Discard
+	}
+	dir := workerWorktreeDir(config, projectRoot, name)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("worktree not found: %s", dir)
+	}
+
+	env, err := config.GetWorkerEnv(name, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []db.CheckResult
+	var failed []string
+	for _, c := range config.GetWorkerChecks(name) {
+		fmt.Fprintf(log, "  check %s: %s\n", c.Name, c.Run)
+		r := checks.Run(dir, checks.Check{Name: c.Name, Run: c.Run}, env)
+		result := db.CheckResult{
+			Worker:     name,
+			Name:       r.Name,
+			Command:    r.Command,
+			Passed:     r.Passed,
+			ExitCode:   r.ExitCode,
+			Output:     r.Output,
+			DurationMs: r.Duration.Milliseconds(),
+			CreatedAt:  time.Now(),
+		}
+		id, err := database.SaveCheckResult(result)
+		if err != nil {
+			return results, err
+		}
+		result.ID = int(id)
+		results = append(results, result)
+
+		if r.Passed {
+			fmt.Fprintf(log, "  ✓ %s (%s)\n", c.Name, r.Duration)
+			continue
+		}
+		failed = append(failed, c.Name)
+		fmt.Fprintf(log, "  ✗ %s failed (exit %d)\n", c.Name, r.ExitCode)
+		if output := checks.Tail(r.Output, 20); output != "" {
+			fmt.Fprintln(log, indentLines(output))
+		}
+	}
+
+	return results, database.ReportWorkerChecked(name, len(failed) == 0, failed)
+}
+
+// checkFailure describes the failed checks of a worker ("" if all passed)
+func checkFailure(name string, results []db.CheckResult) string {
+	var failed []db.CheckResult
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, r)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("check %s failed (exit %d): see 'devhive check %s --last'", failed[0].Name, failed[0].ExitCode, name)
+	}
+	var names []string
+	for _, r := range failed {
+		names = append(names, r.Name)
+	}
+	return fmt.Sprintf("checks %s failed: see 'devhive check %s --last'", strings.Join(names, ", "), name)
+}
+
+// completeWorker marks a worker completed if its checks pass. Otherwise the
+// worker is set to error with the failed checks as last_error. Returns
+// whether the worker was completed.
+func completeWorker(config *ComposeConfig, projectRoot, name string, log io.Writer) (bool, error) {
+	if len(config.GetWorkerChecks(name)) > 0 {
+		results, err := runWorkerChecks(config, projectRoot, name, log)
+		if err != nil {
+			// "Done" is not taken on faith when the checks cannot run
+			return false, database.ReportWorkerError(name, fmt.Sprintf("checks could not run: %v", err))
+		}
+		if msg := checkFailure(name, results); msg != "" {
+			return false, database.ReportWorkerError(name, msg)
+		}
+	}
+	return true, database.UpdateWorkerStatus(name, "completed", nil)
+}
+
+// printCheckResults prints stored check results of a worker
+func printCheckResults(name string, results []db.CheckResult) {
+	if len(results) == 0 {
+		fmt.Printf("%s: no check results\n", name)
+		return
+	}
+	fmt.Printf("%s (%s)\n", name, results[len(results)-1].CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	for _, r := range results {
+		if r.Passed {
+			fmt.Printf("  ✓ %s (%s)\n", r.Name, time.Duration(r.DurationMs)*time.Millisecond)
+			continue
+		}
+		fmt.Printf("  ✗ %s failed (exit %d)\n", r.Name, r.ExitCode)
+		if output := checks.Tail(r.Output, 20); output != "" {
+			fmt.Println(indentLines(output))
+		}
+	}
+}
+
+// indentLines indents check output below its result line
+func indentLines(s string) string {
+	return "      " + strings.ReplaceAll(s, "\n", "\n      ")
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/iguchi/devhive/internal/db"
 	"github.com/spf13/cobra"
@@ -122,21 +124,18 @@ Examples:
   devhive inbox           # Show unread messages
   devhive inbox --all     # Show all messages`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			messages, err := database.GetUnreadMessages("pm")
+			all, _ := cmd.Flags().GetBool("all")
+			messages, err := database.GetMessages("pm", all)
 			if err != nil {
 				return err
 			}
+			markRead, _ := cmd.Flags().GetBool("mark-read")
 
-			all, _ := cmd.Flags().GetBool("all")
-			if !all {
-				// Filter to unread only
-				var unread []db.Message
-				for _, m := range messages {
-					if m.ReadAt == nil {
-						unread = append(unread, m)
-					}
+			if ok, err := writeOutput(cmd, messages); err != nil || ok {
+				if err == nil && markRead && len(messages) > 0 {
+					database.MarkAllRead("pm")
 				}
-				messages = unread
+				return err
 			}
 
 			if len(messages) == 0 {
@@ -146,21 +145,21 @@ Examples:
 
 			fmt.Printf("=== Inbox (%d messages) ===\n\n", len(messages))
 
-			for _, m := range messages {
-				icon := getMessageIcon(m.MessageType)
-				readStatus := ""
-				if m.ReadAt == nil {
-					readStatus = " [NEW]"
+			// Group by thread so replies appear next to the request they answer
+			for _, thread := range groupByThread(messages) {
+				if len(thread) == 1 && thread[0].ReplyTo == 0 {
+					printInboxMessage(thread[0], "")
+					continue
 				}
-				fmt.Printf("%s %s from %s%s\n", icon, m.Subject, m.FromWorker, readStatus)
-				if m.Content != "" {
-					fmt.Printf("   %s\n", m.Content)
+				fmt.Printf("🧵 Thread #%d (%d message(s), 'devhive thread %d' for full history)\n", thread[0].ThreadID, len(thread), thread[0].ThreadID)
+				for _, m := range thread {
+					printInboxMessage(m, "  ")
 				}
-				fmt.Printf("   (%s)\n\n", m.CreatedAt.Format("01/02 15:04"))
 			}
+			fmt.Println("Reply with: devhive reply <id> \"message\"")
+			fmt.Println()
 
 			// Mark as read option
-			markRead, _ := cmd.Flags().GetBool("mark-read")
 			if markRead && len(messages) > 0 {
 				database.MarkAllRead("pm")
 				fmt.Println("Marked all as read.")
@@ -172,10 +171,47 @@ Examples:
 
 	cmd.Flags().BoolP("all", "a", false, "Show all messages including read")
 	cmd.Flags().BoolP("mark-read", "r", false, "Mark all messages as read")
+	addOutputFlags(cmd)
 
 	return cmd
 }
 
+// printInboxMessage prints a message with its ID as shown in the inbox
+func printInboxMessage(m db.Message, indent string) {
+	icon := getMessageIcon(m.MessageType)
+	readStatus := ""
+	if m.ReadAt == nil {
+		readStatus = " [NEW]"
+	}
+	replyTo := ""
+	if m.ReplyTo > 0 {
+		replyTo = fmt.Sprintf(" (re #%d)", m.ReplyTo)
+	}
+	fmt.Printf("%s#%d %s %s from %s%s%s\n", indent, m.ID, icon, m.Subject, m.FromWorker, replyTo, readStatus)
+	if m.Content != "" {
+		fmt.Printf("%s   %s\n", indent, m.Content)
+	}
+	fmt.Printf("%s   (%s)\n\n", indent, m.CreatedAt.Format("01/02 15:04"))
+}
+
+// groupByThread groups messages by thread, keeping the order of first appearance
+func groupByThread(messages []db.Message) [][]db.Message {
+	var order []int
+	threads := make(map[int][]db.Message)
+	for _, m := range messages {
+		if _, ok := threads[m.ThreadID]; !ok {
+			order = append(order, m.ThreadID)
+		}
+		threads[m.ThreadID] = append(threads[m.ThreadID], m)
+	}
+
+	groups := make([][]db.Message, 0, len(order))
+	for _, id := range order {
+		groups = append(groups, threads[id])
+	}
+	return groups
+}
+
 func getMessageIcon(msgType string) string {
 	icons := map[string]string{
 		"help":    "🆘",
@@ -183,6 +219,7 @@ func getMessageIcon(msgType string) string {
 		"unblock": "🚫",
 		"clarify": "❓",
 		"report":  "📋",
+		"reply":   "💬",
 		"info":    "ℹ️",
 	}
 	if icon, ok := icons[msgType]; ok {
@@ -191,29 +228,62 @@ func getMessageIcon(msgType string) string {
 	return "📨"
 }
 
-// replyCmd allows PM to reply to workers
+// replyCmd allows PM to reply to workers or to a specific message
 func replyCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "reply <worker> <message>",
-		Short: "Reply to a worker",
+		Use:   "reply <worker|message-id> <message>",
+		Short: "Reply to a worker or a message",
 		Long: `Send a reply message to a worker.
 
+With a message ID (as shown by 'devhive inbox' / 'devhive msgs'), the
+reply is sent to that message's sender and kept in the same thread.
+The original message is marked as read. Only its recipient can reply:
+$DEVHIVE_WORKER, or pm when unset.
+
 Examples:
   devhive reply frontend "Approved, proceed with tests"
-  devhive reply backend "Use ISO 8601 format for dates"`,
+  devhive reply backend "Use ISO 8601 format for dates"
+  devhive reply 12 "Use the session API"   # Answer message #12`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			toWorker := args[0]
+			target := args[0]
 			message := args[1]
 
+			// Reply to a message ID
+			if id, ok := parseMessageID(target); ok {
+				parent, err := database.GetMessage(id)
+				if err != nil {
+					return err
+				}
+				if parent == nil {
+					return fmt.Errorf("message not found: #%d", id)
+				}
+
+				from := os.Getenv("DEVHIVE_WORKER")
+				if from == "" {
+					from = "pm"
+				}
+				reply, err := database.ReplyToMessage(id, from, "reply", parent.ReplySubject(), message)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("✅ Reply #%d sent to %s (thread #%d)\n", reply.ID, reply.ToWorker, reply.ThreadID)
+				return nil
+			}
+
 			// Verify worker exists
-			if _, err := database.GetWorker(toWorker); err != nil {
+			toWorker := target
+			w, err := database.GetWorker(toWorker)
+			if err != nil {
+				return err
+			}
+			if w == nil {
 				return fmt.Errorf("worker not found: %s", toWorker)
 			}
 
 			// Send message from PM
-			_, err := database.SendMessage("pm", toWorker, "reply", "💬 PM Reply", message)
-			if err != nil {
+			if _, err := database.SendMessage("pm", toWorker, "reply", "💬 PM Reply", message); err != nil {
 				return err
 			}
 
@@ -224,6 +294,66 @@ Examples:
 	}
 }
 
+// threadCmd shows a whole conversation
+func threadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "thread <message-id>",
+		Short: "Show the conversation a message belongs to",
+		Long: `Show every message in the thread of the given message, oldest first.
+
+Examples:
+  devhive thread 12`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, ok := parseMessageID(args[0])
+			if !ok {
+				return fmt.Errorf("invalid message ID: %s", args[0])
+			}
+			m, err := database.GetMessage(id)
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				return fmt.Errorf("message not found: #%d", id)
+			}
+
+			thread, err := database.GetThread(m.ThreadID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("=== Thread #%d (%d messages) ===\n\n", m.ThreadID, len(thread))
+
+			// Indent replies by their depth in the conversation
+			depth := make(map[int]int)
+			for _, t := range thread {
+				indent := ""
+				if t.ReplyTo > 0 {
+					depth[t.ID] = depth[t.ReplyTo] + 1
+					indent = strings.Repeat("  ", depth[t.ID])
+				}
+				fmt.Printf("%s#%d %s %s → %s  %s\n", indent, t.ID, getMessageIcon(t.MessageType),
+					t.FromWorker, t.ToWorker, t.CreatedAt.Format("01/02 15:04"))
+				if t.Subject != "" {
+					fmt.Printf("%s   %s\n", indent, t.Subject)
+				}
+				fmt.Printf("%s   %s\n\n", indent, t.Content)
+			}
+
+			return nil
+		},
+	}
+}
+
+// parseMessageID parses a message ID such as "12" or "#12"
+func parseMessageID(s string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
 // msgsCmd shows messages for current worker
 func msgsCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -240,20 +370,18 @@ Examples:
 				return fmt.Errorf("DEVHIVE_WORKER not set")
 			}
 
-			messages, err := database.GetUnreadMessages(workerName)
+			all, _ := cmd.Flags().GetBool("all")
+			messages, err := database.GetMessages(workerName, all)
 			if err != nil {
 				return err
 			}
+			markRead, _ := cmd.Flags().GetBool("mark-read")
 
-			all, _ := cmd.Flags().GetBool("all")
-			if !all {
-				var unread []db.Message
-				for _, m := range messages {
-					if m.ReadAt == nil {
-						unread = append(unread, m)
-					}
+			if ok, err := writeOutput(cmd, messages); err != nil || ok {
+				if err == nil && markRead {
+					database.MarkAllRead(workerName)
 				}
-				messages = unread
+				return err
 			}
 
 			if len(messages) == 0 {
@@ -268,13 +396,18 @@ Examples:
 				if m.ReadAt == nil {
 					readStatus = " [NEW]"
 				}
-				fmt.Printf("💬 From %s%s\n", m.FromWorker, readStatus)
+				replyTo := ""
+				if m.ReplyTo > 0 {
+					replyTo = fmt.Sprintf(" (re #%d)", m.ReplyTo)
+				}
+				fmt.Printf("💬 #%d From %s%s%s\n", m.ID, m.FromWorker, replyTo, readStatus)
 				fmt.Printf("   %s\n", m.Content)
 				fmt.Printf("   (%s)\n\n", m.CreatedAt.Format("01/02 15:04"))
 			}
 
+			fmt.Println("Reply with: devhive reply <id> \"message\"")
+
 			// Mark as read
-			markRead, _ := cmd.Flags().GetBool("mark-read")
 			if markRead {
 				database.MarkAllRead(workerName)
 				fmt.Println("Marked all as read.")
@@ -286,10 +419,129 @@ Examples:
 
 	cmd.Flags().BoolP("all", "a", false, "Show all messages including read")
 	cmd.Flags().BoolP("mark-read", "r", false, "Mark all messages as read")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// sendCmd sends a message directly to a worker, a role group or everyone
+func sendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send <worker|@role|*|pm> <message>",
+		Short: "Send a message to a worker, role or everyone",
+		Long: `Send a message directly to other workers.
+
+Targets:
+  <worker>  A single worker
+  @<role>   Every worker in the active sprint with that role in .devhive.yaml
+  *         Every worker in the active sprint (except yourself)
+  pm        The project manager
+
+The sender is $DEVHIVE_WORKER (or pm when unset).
+
+Examples:
+  devhive send frontend "API contract changed: see docs/api.md"
+  devhive send @frontend "New endpoint /api/v2/users is ready"
+  devhive send '*' "Please rebase on latest main" --type warning`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := args[0]
+			message := args[1]
+			msgType, _ := cmd.Flags().GetString("type")
+
+			validTypes := map[string]bool{"info": true, "warning": true, "question": true, "answer": true}
+			if !validTypes[msgType] {
+				return fmt.Errorf("invalid message type: %s (valid: info, warning, question, answer)", msgType)
+			}
+
+			from := os.Getenv("DEVHIVE_WORKER")
+			if from == "" {
+				from = "pm"
+			}
+
+			recipients, err := resolveRecipients(target, from)
+			if err != nil {
+				return err
+			}
+
+			subject := fmt.Sprintf("✉️ From %s", from)
+			if _, err := database.SendMessageToMany(from, target, recipients, msgType, subject, message); err != nil {
+				return err
+			}
+
+			fmt.Printf("✅ Message sent to %s\n", strings.Join(recipients, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("type", "t", "info", "Message type (info, warning, question, answer)")
 
 	return cmd
 }
 
+// resolveRecipients expands a send target into worker names
+func resolveRecipients(target, from string) ([]string, error) {
+	switch {
+	case target == "pm":
+		return []string{"pm"}, nil
+
+	case target == "*":
+		workers, err := database.GetAllWorkers()
+		if err != nil {
+			return nil, err
+		}
+		var recipients []string
+		for _, w := range workers {
+			if w.Name != from {
+				recipients = append(recipients, w.Name)
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("no workers in the active sprint")
+		}
+		return recipients, nil
+
+	case strings.HasPrefix(target, "@"):
+		role := strings.TrimPrefix(target, "@")
+		configFile, err := FindComposeFile()
+		if err != nil {
+			return nil, err
+		}
+		config, err := LoadComposeFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		workers, err := database.GetAllWorkers()
+		if err != nil {
+			return nil, err
+		}
+		var recipients []string
+		for _, w := range workers {
+			workerConfig, ok := config.Workers[w.Name]
+			if !ok || w.Name == from {
+				continue
+			}
+			if config.HasRole(workerConfig, role) {
+				recipients = append(recipients, w.Name)
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("no workers with role %s in the active sprint", role)
+		}
+		return recipients, nil
+
+	default:
+		w, err := database.GetWorker(target)
+		if err != nil {
+			return nil, err
+		}
+		if w == nil {
+			return nil, fmt.Errorf("worker not found: %s", target)
+		}
+		return []string{target}, nil
+	}
+}
+
 // broadcastCmd allows PM to send message to all workers
 func broadcastCmd() *cobra.Command {
 	return &cobra.Command{
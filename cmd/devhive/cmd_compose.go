@@ -1,16 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"text/tabwriter"
-	"time"
 
 	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/lifecycle"
 	"github.com/iguchi/devhive/internal/templates"
 	"github.com/spf13/cobra"
 )
@@ -22,20 +25,29 @@ func upCmd() *cobra.Command {
 		Long: `Start workers defined in .devhive.yaml compose file.
 
 This command:
-1. Reads .devhive.yaml from current directory
+1. Reads .devhive.yaml from current directory and validates it
+   (see 'devhive validate')
 2. Creates a sprint if none exists
-3. Registers all workers (or specified workers)
+3. Registers all workers (or specified workers) in dependency order
 4. Creates git worktrees (default)
 
+Workers whose depends_on conditions are not met yet stay pending and
+are started by re-running 'devhive up' once the conditions hold.
+
+Workers with profiles are only started when one of their profiles is
+active (--profile, or DEVHIVE_PROFILES=a,b), or when named explicitly.
+
 Examples:
   devhive up                    # Start all workers with worktrees
   devhive up perf-fe perf-be    # Start specific workers
+  devhive up --profile review   # Also start workers of the review profile
   devhive up --no-worktree      # Start without creating worktrees`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			configFile, _ := cmd.Flags().GetString("file")
 			noWorktree, _ := cmd.Flags().GetBool("no-worktree")
 			repoPath, _ := cmd.Flags().GetString("repo")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			profiles, _ := cmd.Flags().GetStringSlice("profile")
 			createWorktrees := !noWorktree
 
 			// Find compose file
@@ -47,29 +59,57 @@ Examples:
 				}
 			}
 
+			// Refuse to start on a broken config (typos would otherwise be ignored)
+			issues, err := ValidateComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+			if len(issues) > 0 {
+				printValidationIssues(configFile, issues)
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d problem(s) in %s (see 'devhive validate')", len(issues), configFile)
+			}
+
 			// Load config
 			config, err := LoadComposeFile(configFile)
 			if err != nil {
 				return err
 			}
+			if len(profiles) > 0 {
+				if err := config.SetActiveProfiles(profiles); err != nil {
+					return err
+				}
+			}
 
 			configDir := filepath.Dir(configFile)
 			fmt.Printf("Using compose file: %s\n", configFile)
-			fmt.Printf("Project: %s\n\n", config.Project)
+			for _, f := range config.Files {
+				if f != configFile {
+					fmt.Printf("  + %s\n", f)
+				}
+			}
+			fmt.Printf("Project: %s\n", config.Project)
+			if len(config.ActiveProfiles) > 0 {
+				fmt.Printf("Profiles: %s\n", strings.Join(config.ActiveProfiles, ", "))
+			}
+			fmt.Println()
 
 			if dryRun {
 				fmt.Println("=== DRY RUN MODE ===")
 			}
 
-
 			// Step 1: Ensure sprint exists
 			sprint, err := database.GetActiveSprint()
 			if err != nil {
 				return err
 			}
 
-			sprintID := config.GenerateSprintID()
+			var sprintID string
 			if sprint == nil {
+				sprintID, err = database.NewSprintID(config.GenerateSprintID())
+				if err != nil {
+					return err
+				}
 				if dryRun {
 					fmt.Printf("Would create sprint: %s\n\n", sprintID)
 				} else {
@@ -91,31 +131,90 @@ Examples:
 				return nil
 			}
 
+			// Current worker states (updated as workers are registered)
+			states, err := workerStates()
+			if err != nil {
+				return err
+			}
+
 			registeredCount := 0
-			for workerName, worker := range workers {
+			heldCount := 0
+			for _, workerName := range config.GetStartupOrder(args) {
+				worker := workers[workerName]
 				worktreePath := worker.Worktree
 
 				// Resolve role name (for display only)
 				roleName := config.ResolveRole(worker.Role)
 
+				// Workers already started in this sprint keep their status;
+				// others are held back until their dependencies are met
+				existing := states[workerName]
+				started := existing != nil && existing.SprintID == sprintID && existing.Status != "pending"
+				reason := ""
+				if !started {
+					reason = unmetDependencies(worker, states)
+				}
+
 				if dryRun {
+					if reason != "" {
+						fmt.Printf("  → Would hold: %s (%s)\n", workerName, reason)
+						continue
+					}
 					fmt.Printf("  → Would register: %s (branch: %s, role: %s)\n", workerName, worker.Branch, roleName)
 					continue
 				}
 
+				if reason != "" {
+					if err := registerHeldWorker(workerName, sprintID, reason, states); err != nil {
+						fmt.Printf("  ⚠ Failed to register %s: %v\n", workerName, err)
+						continue
+					}
+					fmt.Printf("  ⏸ %s held: %s\n", workerName, reason)
+					heldCount++
+					continue
+				}
+
+				// Assign ports before .envrc and context files refer to them
+				if ports, err := allocateWorkerPorts(config, workerName); err != nil {
+					fmt.Printf("  ⚠ Failed to assign ports for %s: %v\n", workerName, err)
+				} else if len(ports) > 0 {
+					assigned := make(map[string]int)
+					for _, p := range ports {
+						assigned[p.Name] = p.Port
+					}
+					fmt.Printf("  ✓ Ports for %s: %s\n", workerName, formatPorts(assigned))
+				}
+
 				// Create worktree if requested
 				if createWorktrees && worktreePath == "" {
-					wt, err := createGitWorktree(workerName, worker.Branch, repoPath)
+					wt, created, err := createGitWorktree(workerName, worker.Branch, config.resolveBranchFrom(worker), repoPath, worker.Scope)
 					if err != nil {
 						fmt.Printf("  ⚠ Failed to create worktree for %s: %v\n", workerName, err)
 					} else {
 						worktreePath = wt
 						fmt.Printf("  ✓ Worktree: %s\n", wt)
+						if !worker.Scope.IsEmpty() {
+							fmt.Printf("    ✓ Scope: %d excluded, %d writable pattern(s)\n", len(worker.Scope.Exclude), len(worker.Scope.Write))
+						}
+
+						// copy_files / link_files / setup (resumes after a failed step)
+						if err := setupWorktree(config, configDir, workerName, wt, created, os.Stdout); err != nil {
+							step, output, _ := strings.Cut(err.Error(), "\n")
+							fmt.Printf("    ⚠ Setup stopped at %s\n", step)
+							if output != "" {
+								fmt.Println(indentLines(output))
+							}
+							fmt.Printf("      Re-run 'devhive up' to resume from this step\n")
+						}
 
 						// Create .envrc for direnv (unless disabled)
 						generateEnvrc := config.Defaults.GenerateEnvrc == nil || *config.Defaults.GenerateEnvrc
 						if generateEnvrc {
-							if err := createWorkerEnvrc(wt, workerName); err != nil {
+							env, err := config.GetWorkerEnv(workerName, configDir)
+							if err == nil {
+								err = createWorkerEnvrc(wt, env)
+							}
+							if err != nil {
 								fmt.Printf("    ⚠ Failed to create .envrc: %v\n", err)
 							} else if config.Defaults.DirenvAllow {
 								// Auto-run direnv allow if configured
@@ -137,15 +236,22 @@ Examples:
 							} else {
 								fmt.Printf("    ✓ Context: CONTEXT.md\n")
 							}
+							if tool == "claude" && config.Defaults.MCP {
+								fmt.Printf("    ✓ MCP: .mcp.json (devhive mcp)\n")
+							}
 						}
 					}
 				}
 
 				// Register worker (branch, role, tool are in YAML config, not DB)
-				err := database.RegisterWorker(workerName, sprintID)
-				if err != nil {
-					fmt.Printf("  ⚠ Failed to register %s: %v\n", workerName, err)
-					continue
+				if !started {
+					err := database.RegisterWorker(workerName, sprintID)
+					if err != nil {
+						fmt.Printf("  ⚠ Failed to register %s: %v\n", workerName, err)
+						continue
+					}
+					database.SetWorkerPendingReason(workerName, "")
+					refreshWorkerState(workerName, states)
 				}
 
 				// Build output
@@ -155,9 +261,15 @@ Examples:
 				}
 				fmt.Printf("  ✓ %s (branch: %s%s)\n", workerName, worker.Branch, roleStr)
 				registeredCount++
+				if !started {
+					runLifecycleHook(lifecycle.OnUp, workerName, nil)
+				}
 			}
 
 			fmt.Printf("\n✓ Registered %d workers\n", registeredCount)
+			if heldCount > 0 {
+				fmt.Printf("⏸ Held %d worker(s) until their dependencies are met (re-run 'devhive up' to start them)\n", heldCount)
+			}
 
 			if createWorktrees && registeredCount > 0 {
 				generateEnvrc := config.Defaults.GenerateEnvrc == nil || *config.Defaults.GenerateEnvrc
@@ -175,6 +287,7 @@ Examples:
 	cmd.Flags().Bool("no-worktree", false, "Skip creating git worktrees")
 	cmd.Flags().String("repo", "", "Git repository path (default: cwd)")
 	cmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	cmd.Flags().StringSlice("profile", nil, "Activate workers of these profiles (repeatable, or comma-separated)")
 
 	return cmd
 }
@@ -264,23 +377,46 @@ func psCmd() *cobra.Command {
 		Long: `List workers in the current sprint.
 
 Like 'docker ps', shows running workers by default.
-Use -a to show all workers including completed ones.`,
+Use -a to show all workers including completed ones.
+Use --sprint <id> to show the workers of a past sprint.
+
+Use --json or --format for scripts (fields of db.Worker):
+  devhive ps --json
+  devhive ps --format '{{.Name}}\t{{.Status}}\t{{.Progress}}'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			showAll, _ := cmd.Flags().GetBool("all")
 			quiet, _ := cmd.Flags().GetBool("quiet")
+			sprintID, _ := cmd.Flags().GetString("sprint")
 
-			sprint, err := database.GetActiveSprint()
-			if err != nil {
-				return err
-			}
-			if sprint == nil {
-				fmt.Println("No active sprint")
-				return nil
-			}
+			var workers []db.Worker
+			if sprintID != "" {
+				// Past (or specific) sprint: show all of its workers
+				sprint, err := findSprint([]string{sprintID})
+				if err != nil {
+					return err
+				}
+				workers, err = database.GetSprintWorkers(sprint.ID)
+				if err != nil {
+					return err
+				}
+				showAll = true
+			} else {
+				sprint, err := database.GetActiveSprint()
+				if err != nil {
+					return err
+				}
+				if sprint == nil {
+					if ok, err := writeOutput(cmd, []db.Worker{}); err != nil || ok {
+						return err
+					}
+					fmt.Println("No active sprint")
+					return nil
+				}
 
-			workers, err := database.GetAllWorkers()
-			if err != nil {
-				return err
+				workers, err = database.GetAllWorkers()
+				if err != nil {
+					return err
+				}
 			}
 
 			// Filter workers
@@ -289,8 +425,10 @@ Use -a to show all workers including completed ones.`,
 				Status   string
 				Session  string
 				Progress int
+				Ports    string
 				Activity string
 			}
+			var shown []db.Worker
 			hiddenCount := 0
 
 			for _, w := range workers {
@@ -299,8 +437,12 @@ Use -a to show all workers including completed ones.`,
 					hiddenCount++
 					continue
 				}
+				shown = append(shown, w)
 
 				activity := w.Activity
+				if w.Status == "pending" && w.PendingReason != "" {
+					activity = "⏸ " + w.PendingReason
+				}
 				if len(activity) > 40 {
 					activity = activity[:37] + "..."
 				}
@@ -310,16 +452,22 @@ Use -a to show all workers including completed ones.`,
 					Status   string
 					Session  string
 					Progress int
+					Ports    string
 					Activity string
 				}{
 					Name:     w.Name,
 					Status:   w.Status,
 					Session:  w.SessionState,
 					Progress: w.Progress,
+					Ports:    formatPorts(w.Ports),
 					Activity: activity,
 				})
 			}
 
+			if ok, err := writeOutput(cmd, shown); err != nil || ok {
+				return err
+			}
+
 			if len(filtered) == 0 {
 				if showAll {
 					fmt.Println("No workers")
@@ -343,16 +491,44 @@ Use -a to show all workers including completed ones.`,
 
 			// Table output (Docker ps style)
 			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(tw, "NAME\tSTATUS\tSESSION\tPROGRESS\tACTIVITY")
+			fmt.Fprintln(tw, "NAME\tSTATUS\tSESSION\tPROGRESS\tPORTS\tACTIVITY")
 			for _, w := range filtered {
 				statusStr := statusIcon(w.Status)
 				sessionStr := fmt.Sprintf("%s %s", sessionIcon(w.Session), w.Session)
 				progressStr := fmt.Sprintf("%d%%", w.Progress)
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-					w.Name, statusStr, sessionStr, progressStr, w.Activity)
+				portsStr := w.Ports
+				if portsStr == "" {
+					portsStr = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					w.Name, statusStr, sessionStr, progressStr, portsStr, w.Activity)
 			}
 			tw.Flush()
 
+			// Flag commits outside each worker's write scope
+			configFile, _ := FindComposeFile()
+			if configFile != "" {
+				if config, err := LoadComposeFile(configFile); err == nil {
+					cwd, _ := os.Getwd()
+					var warnings []string
+					for _, w := range filtered {
+						workerConfig, ok := config.Workers[w.Name]
+						if !ok {
+							continue
+						}
+						if violations := scopeViolations(cwd, config.GetBaseBranch(), workerConfig.Branch, workerConfig.Scope); len(violations) > 0 {
+							warnings = append(warnings, fmt.Sprintf("⚠ %s: %d file(s) committed outside write scope (e.g. %s)", w.Name, len(violations), violations[0]))
+						}
+					}
+					if len(warnings) > 0 {
+						fmt.Println()
+						for _, warning := range warnings {
+							fmt.Println(warning)
+						}
+					}
+				}
+			}
+
 			// Show hidden count if any
 			if !showAll && hiddenCount > 0 {
 				fmt.Printf("\n(%d completed workers hidden, use -a to show all)\n", hiddenCount)
@@ -364,6 +540,8 @@ Use -a to show all workers including completed ones.`,
 
 	cmd.Flags().BoolP("all", "a", false, "Show all workers (including completed)")
 	cmd.Flags().BoolP("quiet", "q", false, "Only display worker names")
+	cmd.Flags().String("sprint", "", "Show workers of a specific (e.g., past) sprint")
+	addOutputFlags(cmd)
 
 	return cmd
 }
@@ -431,56 +609,120 @@ func logsCmd() *cobra.Command {
 
 Like 'docker logs', shows the event history.
 Without arguments, shows all events.
-With a worker name, shows only that worker's events.`,
+With a worker name, shows only that worker's events.
+
+With --session, shows the worker's terminal output recorded by
+'devhive tmux' (.devhive/logs/session-<worker>.log) instead.
+
+Examples:
+  devhive logs -f                          # Follow all events
+  devhive logs frontend -n 20              # Last 20 events of frontend
+  devhive logs --session frontend -f       # Follow frontend's terminal output
+  devhive logs --session frontend --grep "FAIL|Error"
+  devhive logs --sprint 20250118-01        # Events of a past sprint
+  devhive logs -f --format json            # Stream events as JSON lines
+  devhive logs -f -t worker_error          # Follow errors only`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			limit, _ := cmd.Flags().GetInt("tail")
 			follow, _ := cmd.Flags().GetBool("follow")
+			session, _ := cmd.Flags().GetString("session")
+			grep, _ := cmd.Flags().GetString("grep")
+			sprintID, _ := cmd.Flags().GetString("sprint")
+			eventType, _ := cmd.Flags().GetString("type")
+
+			var sprint *db.Sprint
+			if sprintID != "" {
+				var err error
+				sprint, err = findSprint([]string{sprintID})
+				if err != nil {
+					return err
+				}
+				if follow && sprint.Status != "active" {
+					return fmt.Errorf("cannot follow logs of an ended sprint: %s", sprint.ID)
+				}
+			}
 
-			var workerPtr *string
+			// Session output mode
+			if session != "" {
+				projectRoot, _ := os.Getwd()
+				if configFile, err := FindComposeFile(); err == nil {
+					projectRoot = filepath.Dir(configFile)
+				}
+				logPath := sessionLogPath(projectRoot, session)
+				// Past sprints' session logs are rotated into .devhive/logs/<sprint>/
+				if sprint != nil && sessionLogSprint(logPath) != sprint.ID {
+					logPath = archivedSessionLogPath(projectRoot, sprint.ID, session)
+				}
+				return showSessionLog(logPath, limit, follow, grep)
+			}
+			if grep != "" {
+				return fmt.Errorf("--grep requires --session")
+			}
+
+			var workerPtr, typePtr *string
+			var filter db.EventFilter
 			if len(args) > 0 {
 				workerPtr = &args[0]
+				filter.Workers = []string{args[0]}
+			}
+			if eventType != "" {
+				typePtr = &eventType
+				filter.Types = []string{eventType}
+			}
+
+			f, err := getOutputFormatter(cmd)
+			if err != nil {
+				return err
 			}
 
-			// If follow mode, use watch-like behavior
+			// If follow mode, stream new events as they are written
 			if follow {
-				fmt.Println("Following logs... (Ctrl+C to stop)")
+				if f == nil {
+					fmt.Println("Following logs... (Ctrl+C to stop)")
+				}
 
 				lastID, _ := database.GetLastEventID()
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
 
-				for {
-					time.Sleep(1 * time.Second)
-
-					events, err := database.GetEventsSince(lastID, nil)
-					if err != nil {
+				for e := range database.Subscribe(ctx, lastID, filter) {
+					if f != nil {
+						f.WriteItem(os.Stdout, e)
 						continue
 					}
-
-					for _, e := range events {
-						lastID = e.ID
-						// Filter by worker if specified
-						if workerPtr != nil && e.Worker != *workerPtr {
-							continue
-						}
-						printLogLine(e)
-					}
+					printLogLine(e)
 				}
+				return nil
 			}
 
 			// Non-follow mode
-			events, err := database.GetRecentEvents(limit, nil, workerPtr)
+			var events []db.Event
+			if sprint != nil {
+				events, err = database.GetSprintEvents(sprint.ID, limit, filter)
+			} else {
+				events, err = database.GetRecentEvents(limit, typePtr, workerPtr)
+			}
 			if err != nil {
 				return err
 			}
 
+			// Reverse to show oldest first (like docker logs)
+			for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+				events[i], events[j] = events[j], events[i]
+			}
+
+			if f != nil {
+				return f.Write(os.Stdout, events)
+			}
+
 			if len(events) == 0 {
 				fmt.Println("No logs")
 				return nil
 			}
 
-			// Reverse to show oldest first (like docker logs)
-			for i := len(events) - 1; i >= 0; i-- {
-				printLogLine(events[i])
+			for _, e := range events {
+				printLogLine(e)
 			}
 
 			return nil
@@ -489,24 +731,33 @@ With a worker name, shows only that worker's events.`,
 
 	cmd.Flags().IntP("tail", "n", 50, "Number of lines to show")
 	cmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	cmd.Flags().String("session", "", "Show a worker's recorded terminal output")
+	cmd.Flags().String("grep", "", "Only show session lines matching a regular expression")
+	cmd.Flags().String("sprint", "", "Show logs of a specific (e.g., past) sprint")
+	cmd.Flags().StringP("type", "t", "", "Only show events of a type (e.g., worker_error)")
+	addOutputFlags(cmd)
 
 	return cmd
 }
 
 func printLogLine(e db.Event) {
-	timestamp := e.CreatedAt.Format("2006-01-02 15:04:05")
-	fmt.Printf("%s %s", timestamp, e.EventType)
+	fmt.Println(e.CreatedAt.Format("2006-01-02 15:04:05") + " " + formatEvent(e))
+}
+
+// formatEvent returns an event as "type [worker] key:value,..."
+func formatEvent(e db.Event) string {
+	s := e.EventType
 	if e.Worker != "" {
-		fmt.Printf(" [%s]", e.Worker)
+		s += fmt.Sprintf(" [%s]", e.Worker)
 	}
 	if e.Data != "" && e.Data != "{}" {
 		// Clean up JSON formatting
 		data := strings.ReplaceAll(e.Data, "\"", "")
 		data = strings.ReplaceAll(data, "{", "")
 		data = strings.ReplaceAll(data, "}", "")
-		fmt.Printf(" %s", data)
+		s += " " + data
 	}
-	fmt.Println()
+	return s
 }
 
 // createExecCommand creates an exec.Cmd for the given command
@@ -566,7 +817,8 @@ func execCmd() *cobra.Command {
 		Short: "Execute a command in a worker's worktree",
 		Long: `Execute a command in a worker's worktree directory.
 
-Like 'docker exec', runs a command in the worker's environment.`,
+Like 'docker exec', runs a command in the worker's environment:
+DEVHIVE_WORKER and the worker's env / env_file from .devhive.yaml.`,
 		Args: cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workerName := args[0]
@@ -596,8 +848,18 @@ Like 'docker exec', runs a command in the worker's environment.`,
 			execCmd.Stderr = os.Stderr
 			execCmd.Stdin = os.Stdin
 
-			// Set environment
-			execCmd.Env = append(os.Environ(), fmt.Sprintf("DEVHIVE_WORKER=%s", workerName))
+			// Set environment (env / env_file from the compose file, if any)
+			env := []string{"DEVHIVE_WORKER=" + workerName}
+			if configFile, err := FindProjectComposeFile(); err == nil {
+				config, err := LoadComposeFile(configFile)
+				if err != nil {
+					return err
+				}
+				if env, err = config.GetWorkerEnv(workerName, filepath.Dir(configFile)); err != nil {
+					return err
+				}
+			}
+			execCmd.Env = append(os.Environ(), env...)
 
 			return execCmd.Run()
 		},
@@ -606,6 +868,14 @@ Like 'docker exec', runs a command in the worker's environment.`,
 	return cmd
 }
 
+// roleInfo is a row of 'devhive roles' (also its --json/--format output)
+type roleInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	File        string `json:"file,omitempty"`
+}
+
 func rolesCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "roles",
@@ -619,12 +889,18 @@ Roles are defined in .devhive/roles/*.md files.`,
 
 			if showBuiltin {
 				// Show builtin roles
-				builtinRoles := templates.GetBuiltinRoles()
+				var roles []roleInfo
+				for _, role := range templates.GetBuiltinRoles() {
+					roles = append(roles, roleInfo{Name: "@" + role.Name, Description: role.Description, Type: "builtin"})
+				}
+				if ok, err := writeOutput(cmd, roles); err != nil || ok {
+					return err
+				}
 
 				tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 				fmt.Fprintln(tw, "NAME\tDESCRIPTION\tTYPE")
-				for _, role := range builtinRoles {
-					fmt.Fprintf(tw, "@%s\t%s\tbuiltin\n", role.Name, role.Description)
+				for _, role := range roles {
+					fmt.Fprintf(tw, "%s\t%s\t%s\n", role.Name, role.Description, role.Type)
 				}
 				tw.Flush()
 				return nil
@@ -633,32 +909,39 @@ Roles are defined in .devhive/roles/*.md files.`,
 			// Show user-defined roles from .devhive/roles/ directory
 			rolesDir := filepath.Join(".devhive", "roles")
 			entries, err := os.ReadDir(rolesDir)
-			if err != nil {
-				if os.IsNotExist(err) {
-					fmt.Println("No roles directory found (.devhive/roles/)")
-					fmt.Println("\nTip: Use 'devhive roles --builtin' to see built-in roles")
-					return nil
-				}
+			if err != nil && !os.IsNotExist(err) {
 				return err
 			}
 
-			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(tw, "NAME\tFILE")
-
-			roleCount := 0
+			var roles []roleInfo
 			for _, entry := range entries {
 				if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
 					continue
 				}
-				roleName := strings.TrimSuffix(entry.Name(), ".md")
-				roleFile := filepath.Join(rolesDir, entry.Name())
-				fmt.Fprintf(tw, "%s\t%s\n", roleName, roleFile)
-				roleCount++
+				roles = append(roles, roleInfo{
+					Name: strings.TrimSuffix(entry.Name(), ".md"),
+					Type: "user",
+					File: filepath.Join(rolesDir, entry.Name()),
+				})
+			}
+			if ok, err := writeOutput(cmd, roles); err != nil || ok {
+				return err
 			}
 
+			if os.IsNotExist(err) {
+				fmt.Println("No roles directory found (.devhive/roles/)")
+				fmt.Println("\nTip: Use 'devhive roles --builtin' to see built-in roles")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tFILE")
+			for _, role := range roles {
+				fmt.Fprintf(tw, "%s\t%s\n", role.Name, role.File)
+			}
 			tw.Flush()
 
-			if roleCount == 0 {
+			if len(roles) == 0 {
 				fmt.Println("No role files found in .devhive/roles/")
 				fmt.Println("\nTip: Use 'devhive roles --builtin' to see built-in roles")
 			}
@@ -668,6 +951,7 @@ Roles are defined in .devhive/roles/*.md files.`,
 	}
 
 	cmd.Flags().BoolP("builtin", "b", false, "Show built-in roles only")
+	addOutputFlags(cmd)
 
 	return cmd
 }
@@ -739,6 +1023,11 @@ func configCmd() *cobra.Command {
 			}
 
 			fmt.Printf("Compose file: %s\n", configFile)
+			for _, f := range config.Files {
+				if f != configFile {
+					fmt.Printf("  + %s\n", f)
+				}
+			}
 			fmt.Printf("Version: %s\n", config.Version)
 			fmt.Printf("Project: %s\n", config.Project)
 			fmt.Println()
@@ -775,14 +1064,15 @@ func configCmd() *cobra.Command {
 			if len(config.Workers) > 0 {
 				fmt.Println("Workers:")
 				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-				fmt.Fprintln(w, "  NAME\tBRANCH\tROLE\tDISABLED")
-				fmt.Fprintln(w, "  ----\t------\t----\t--------")
-				for name, worker := range config.Workers {
+				fmt.Fprintln(w, "  NAME\tBRANCH\tROLE\tPROFILES\tDISABLED")
+				fmt.Fprintln(w, "  ----\t------\t----\t--------\t--------")
+				for _, name := range config.orderWorkerNames(config.Workers) {
+					worker := config.Workers[name]
 					disabled := ""
 					if worker.Disabled {
 						disabled = "yes"
 					}
-					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", name, worker.Branch, worker.Role, disabled)
+					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", name, worker.Branch, worker.Role, strings.Join(worker.Profiles, ","), disabled)
 				}
 				w.Flush()
 			}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/conflicts"
+	"github.com/spf13/cobra"
+)
+
+// conflictsCmd predicts merge conflicts between worker branches
+func conflictsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conflicts [worker...]",
+		Short: "Predict merge conflicts between worker branches",
+		Long: `Merge every pair of active worker branches in memory (git merge-tree)
+and each branch with the base branch, and report:
+
+  conflicts  files and hunks that will not merge cleanly
+  overlap    paths both workers modify that still merge cleanly
+
+Only committed changes are compared. Completed workers are skipped.
+With worker names, only pairs involving those workers are shown.
+
+With --notify, the affected workers get a warning message (once per
+finding, again when it changes) and a conflict_predicted event is logged.
+'devhive watch' does this on every check (defaults.watch.conflicts).
+
+Examples:
+  devhive conflicts              # All active workers
+  devhive conflicts frontend     # Pairs involving frontend
+  devhive conflicts --notify     # Also warn the affected workers
+  devhive conflicts --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notify, _ := cmd.Flags().GetBool("notify")
+
+			configFile, err := FindProjectComposeFile()
+			if err != nil {
+				return err
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				if _, ok := config.Workers[name]; !ok {
+					return fmt.Errorf("worker %s not found in config", name)
+				}
+			}
+
+			results, branches, err := predictConflicts(config, filepath.Dir(configFile), args)
+			if err != nil {
+				return err
+			}
+			if notify {
+				if _, err := notifyConflicts(results); err != nil {
+					return err
+				}
+			}
+
+			if results == nil {
+				results = []conflicts.Conflict{}
+			}
+			if ok, err := writeOutput(cmd, results); err != nil || ok {
+				return err
+			}
+
+			if len(results) == 0 {
+				fmt.Printf("✓ No conflicts predicted across %d branch(es) and %s\n", len(branches), config.GetBaseBranch())
+				return nil
+			}
+			for _, c := range results {
+				printConflict(c)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("notify", false, "Send a warning message to the affected workers")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// conflictBranches returns the branches of the active sprint's workers
+// that are not completed
+func conflictBranches(config *ComposeConfig) ([]conflicts.Branch, error) {
+	states, err := workerStates()
+	if err != nil {
+		return nil, err
+	}
+	var branches []conflicts.Branch
+	for _, name := range config.GetOrderedWorkerNames(nil) {
+		w := states[name]
+		if w == nil || w.Status == "completed" || config.Workers[name].Branch == "" {
+			continue
+		}
+		branches = append(branches, conflicts.Branch{Worker: name, Branch: config.Workers[name].Branch})
+	}
+	return branches, nil
+}
+
+// predictConflicts checks the active workers' branches against each other
+// and the base branch. With names, only results involving them are returned.
+func predictConflicts(config *ComposeConfig, projectRoot string, names []string) ([]conflicts.Conflict, []conflicts.Branch, error) {
+	branches, err := conflictBranches(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := conflicts.Predict(projectRoot, config.GetBaseBranch(), branches)
+	if err != nil {
+		return nil, branches, err
+	}
+	if len(names) == 0 {
+		return results, branches, nil
+	}
+
+	var filtered []conflicts.Conflict
+	for _, c := range results {
+		if containsString(names, c.A.Worker) || containsString(names, c.B.Worker) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, branches, nil
+}
+
+// notifyConflicts sends each affected worker a warning message and records
+// a conflict_predicted event, unless the same finding was already reported.
+// Returns the findings that were reported now.
+func notifyConflicts(results []conflicts.Conflict) ([]conflicts.Conflict, error) {
+	var reported []conflicts.Conflict
+	for _, c := range results {
+		sides := []struct{ self, other conflicts.Branch }{{c.A, c.B}}
+		if c.B.Worker != "" {
+			sides = append(sides, struct{ self, other conflicts.Branch }{c.B, c.A})
+		}
+
+		sent := false
+		for _, side := range sides {
+			seen, err := conflictReported(side.self.Worker, side.other.Name(), c.Paths(), c.Overlap)
+			if err != nil {
+				return reported, err
+			}
+			if seen {
+				continue
+			}
+			subject, content := conflictMessage(c, side.self, side.other)
+			if _, err := database.SendMessage("devhive", side.self.Worker, "warning", subject, content); err != nil {
+				return reported, err
+			}
+			if err := database.ReportConflictPredicted(side.self.Worker, side.other.Name(), c.Paths(), c.Overlap); err != nil {
+				return reported, err
+			}
+			sent = true
+		}
+		if sent {
+			reported = append(reported, c)
+		}
+	}
+	return reported, nil
+}
+
+// conflictReported reports whether the latest conflict_predicted event of a
+// worker about other has the same files and overlap
+func conflictReported(worker, other string, files, overlap []string) (bool, error) {
+	eventType := "conflict_predicted"
+	events, err := database.GetRecentEvents(100, &eventType, &worker)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range events {
+		var data struct {
+			With    string   `json:"with"`
+			Files   []string `json:"files"`
+			Overlap []string `json:"overlap"`
+		}
+		if json.Unmarshal([]byte(e.Data), &data) != nil || data.With != other {
+			continue
+		}
+		// Newest first: only the latest report for this pair counts
+		return sameStrings(data.Files, files) && sameStrings(data.Overlap, overlap), nil
+	}
+	return false, nil
+}
+
+// sameStrings reports whether two lists are equal (nil equals empty)
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictMessage builds the warning sent to self about other
+func conflictMessage(c conflicts.Conflict, self, other conflicts.Branch) (string, string) {
+	var sb strings.Builder
+	var subject string
+	otherLabel := other.Name()
+	if other.Worker != "" {
+		otherLabel = fmt.Sprintf("%s (%s)", other.Worker, other.Branch)
+	}
+
+	if c.HasConflicts() {
+		subject = fmt.Sprintf("⚠️ Conflict predicted with %s", other.Name())
+		fmt.Fprintf(&sb, "Your branch %s will conflict with %s:\n", self.Branch, otherLabel)
+		for _, f := range c.Files {
+			fmt.Fprintf(&sb, "  %s (%s", f.Path, f.Type)
+			if len(f.Hunks) > 0 {
+				fmt.Fprintf(&sb, ", %d hunk(s)", len(f.Hunks))
+			}
+			sb.WriteString(")\n")
+		}
+	} else {
+		subject = fmt.Sprintf("⚠️ Overlapping edits with %s", other.Name())
+		fmt.Fprintf(&sb, "Your branch %s and %s modify the same files (no conflict yet):\n", self.Branch, otherLabel)
+	}
+	if len(c.Overlap) > 0 {
+		if c.HasConflicts() {
+			sb.WriteString("Both branches also modify:\n")
+		}
+		for _, p := range c.Overlap {
+			fmt.Fprintf(&sb, "  %s\n", p)
+		}
+	}
+
+	if other.Worker != "" {
+		fmt.Fprintf(&sb, "\nCoordinate before continuing: devhive send %s \"...\"", other.Worker)
+	} else {
+		fmt.Fprintf(&sb, "\nUpdate your branch from %s and resolve the conflicts early.", other.Branch)
+	}
+	return subject, sb.String()
+}
+
+// printConflict prints a prediction with its hunks
+func printConflict(c conflicts.Conflict) {
+	if c.HasConflicts() {
+		fmt.Printf("✗ %s ↔ %s: %d conflicting file(s)\n", c.A.Name(), c.B.Name(), len(c.Files))
+		for _, f := range c.Files {
+			fmt.Printf("    %s (%s)\n", f.Path, f.Type)
+			for _, h := range f.Hunks {
+				fmt.Printf("      @ line %d\n", h.Line)
+				printHunkSide("<", h.Ours)
+				printHunkSide(">", h.Theirs)
+			}
+		}
+	} else {
+		fmt.Printf("⚠ %s ↔ %s: %d file(s) modified by both (no conflict yet)\n", c.A.Name(), c.B.Name(), len(c.Overlap))
+	}
+	if len(c.Overlap) > 0 {
+		indent := "    "
+		if c.HasConflicts() {
+			fmt.Println("    also modified by both:")
+			indent = "      "
+		}
+		for _, p := range c.Overlap {
+			fmt.Printf("%s%s\n", indent, p)
+		}
+	}
+	fmt.Println()
+}
+
+// printHunkSide prints up to 5 lines of one side of a hunk
+func printHunkSide(marker string, lines []string) {
+	const maxLines = 5
+	for i, line := range lines {
+		if i == maxLines {
+			fmt.Printf("      %s ... (%d more)\n", marker, len(lines)-maxLines)
+			break
+		}
+		fmt.Printf("      %s %s\n", marker, line)
+	}
+}
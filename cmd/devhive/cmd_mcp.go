@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iguchi/devhive/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+// mcpCmd serves devhive tools to the worker's agent over MCP (stdio)
+func mcpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve devhive tools to agents over MCP (stdio)",
+		Long: `Speak the Model Context Protocol over stdin/stdout so the AI agent of a
+worker can report progress and talk to the PM through structured tools
+instead of shelling out to the CLI.
+
+The worker is $DEVHIVE_WORKER (or --worker). Register it as an MCP server
+in the agent; with defaults.mcp: true, 'devhive up' writes .mcp.json into
+Claude worktrees and lists the tools in the context files.
+
+Tools:
+  report_progress  Update progress (0-100) and activity (like 'devhive progress')
+  request_help     Send a help/review/unblock/clarify request (like 'devhive request')
+  send_report      Send a progress report to the PM (like 'devhive report')
+  read_messages    Read (and mark read) messages sent to the worker
+  reply_message    Reply to a message in its thread (like 'devhive reply')
+  get_task         Show the worker's task, role, branch and status
+  list_peers       List the other workers in the sprint
+
+Examples:
+  claude mcp add devhive -- devhive mcp
+  devhive mcp --worker frontend`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workerName, _ := cmd.Flags().GetString("worker")
+			if workerName == "" {
+				workerName = os.Getenv("DEVHIVE_WORKER")
+			}
+			if workerName == "" {
+				return fmt.Errorf("worker name required: set DEVHIVE_WORKER or use --worker flag")
+			}
+
+			srv := mcp.New(database, workerName)
+			srv.Version = version
+			if configFile, _ := FindProjectComposeFile(); configFile != "" {
+				config, err := LoadComposeFile(configFile)
+				if err != nil {
+					return err
+				}
+				projectRoot := filepath.Dir(configFile)
+				srv.AutoComplete = config.Defaults.AutoComplete
+				srv.Complete = func(name string) (bool, error) {
+					return completeWorker(config, projectRoot, name, nil)
+				}
+				srv.Config = func(name string) *mcp.WorkerConfig {
+					worker, ok := config.Workers[name]
+					if !ok {
+						return nil
+					}
+					return &mcp.WorkerConfig{
+						Role:   worker.Role,
+						Branch: worker.Branch,
+						Task:   GetTaskContent(projectRoot, name, worker.Task),
+					}
+				}
+			}
+
+			// stdout carries the protocol; nothing else may be printed there
+			return srv.Serve(os.Stdin, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringP("worker", "w", "", "Worker to act as (default: $DEVHIVE_WORKER)")
+
+	return cmd
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/monitor"
+	"github.com/spf13/cobra"
+)
+
+// monitorCmd periodically shows observed git facts next to self-reported progress
+func monitorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "monitor [worker...]",
+		Short: "Watch workers' git activity",
+		Long: `Periodically read each worker's worktree and show observable facts:
+commits ahead of the base branch, insertions/deletions, uncommitted
+changes and the time of the last commit, next to the self-reported
+progress. Observed state is stored in the database.
+
+Examples:
+  devhive monitor frontend              # Refresh every 5 seconds
+  devhive monitor frontend --interval 30
+  devhive monitor --once                # All workers, single snapshot`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			interval, _ := cmd.Flags().GetInt("interval")
+			once, _ := cmd.Flags().GetBool("once")
+			if interval < 1 {
+				return fmt.Errorf("interval must be at least 1 second")
+			}
+
+			configFile, err := FindProjectComposeFile()
+			if err != nil {
+				return err
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+
+			for {
+				workers, err := monitoredWorkers(args)
+				if err != nil {
+					return err
+				}
+				refreshGitStates(workers, config, filepath.Dir(configFile))
+
+				// Re-read to pick up the stored state
+				workers, err = monitoredWorkers(args)
+				if err != nil {
+					return err
+				}
+
+				if !once && isTerminal() {
+					fmt.Print("\033[H\033[2J")
+				}
+				fmt.Printf("=== DevHive Monitor (%s) ===\n\n", time.Now().Format("15:04:05"))
+				for _, w := range workers {
+					fmt.Printf("%s  %s  %d%%", w.Name, statusIcon(w.Status), w.Progress)
+					if w.Activity != "" {
+						fmt.Printf("  %s", w.Activity)
+					}
+					fmt.Println()
+					fmt.Printf("  git: %s\n\n", formatGitState(w))
+				}
+
+				if once {
+					return nil
+				}
+				time.Sleep(time.Duration(interval) * time.Second)
+			}
+		},
+	}
+
+	cmd.Flags().IntP("interval", "i", 5, "Refresh interval in seconds")
+	cmd.Flags().Bool("once", false, "Show a single snapshot and exit")
+
+	return cmd
+}
+
+// monitoredWorkers returns the named workers, or all workers in the active sprint
+func monitoredWorkers(names []string) ([]db.Worker, error) {
+	if len(names) == 0 {
+		return database.GetAllWorkers()
+	}
+	var workers []db.Worker
+	for _, name := range names {
+		w, err := database.GetWorker(name)
+		if err != nil {
+			return nil, err
+		}
+		if w == nil {
+			return nil, fmt.Errorf("worker not found: %s", name)
+		}
+		workers = append(workers, *w)
+	}
+	return workers, nil
+}
+
+// refreshGitStates collects and stores the git state of each worker's worktree
+// (relative to projectRoot). Workers without a worktree are skipped
+func refreshGitStates(workers []db.Worker, config *ComposeConfig, projectRoot string) {
+	if config == nil {
+		config = &ComposeConfig{}
+	}
+	for _, w := range workers {
+		worktree := workerWorktreeDir(config, projectRoot, w.Name)
+		if _, err := os.Stat(worktree); err != nil {
+			continue
+		}
+
+		state, err := monitor.CollectGitState(worktree, config.GetBaseBranch(), GeneratedWorktreeFiles...)
+		if err != nil {
+			continue
+		}
+		database.UpdateWorkerGitState(w.Name, state)
+	}
+}
+
+// formatGitState returns a one-line summary of a worker's observed git state
+func formatGitState(w db.Worker) string {
+	if w.MonitoredAt == nil {
+		return "not monitored"
+	}
+	s := fmt.Sprintf("%d commit(s), +%d/-%d, %d uncommitted", w.CommitsAhead, w.Insertions, w.Deletions, w.Uncommitted)
+	if w.LastCommitAt != nil {
+		s += fmt.Sprintf(", last commit %s", formatAgo(*w.LastCommitAt))
+	}
+	return s
+}
+
+// formatAgo returns a short relative time like "5m ago"
+func formatAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
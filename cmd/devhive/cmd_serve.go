@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd runs the local HTTP API server
+func serveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the dashboard and API over HTTP",
+		Long: `Serve workers, messages, events and sprints as a local REST/JSON API,
+and a browser dashboard at / (worker cards, live event feed, PM inbox).
+
+The observed git state of each worker (commits, +/- lines, uncommitted
+changes) is refreshed every --refresh seconds for the dashboard.
+
+Writes go through the same operations as the CLI, so events are logged
+exactly as 'devhive progress', 'devhive reply' etc. log them.
+
+Requests must address the server as localhost or its listen address, and
+POSTs must be application/json from the same origin, so other web pages
+cannot write through a browser.
+
+Endpoints:
+  GET  /api/workers[?sprint=<id>]        List workers
+  GET  /api/workers/<name>               Show a worker
+  POST /api/workers/<name>/progress      {"progress": 50, "activity": "..."}
+  POST /api/workers/<name>/status        {"status": "working"}
+  POST /api/workers/<name>/session       {"state": "running"}
+  GET  /api/messages?worker=<name>[&all=true]
+  POST /api/messages                     {"to", "type", "subject", "content"} (sent as pm)
+  GET  /api/messages/<id>                Show a message
+  POST /api/messages/<id>/reply          {"content": "..."} (PM inbox only)
+  POST /api/messages/<id>/read           Mark a message read
+  GET  /api/threads/<id>                 Show a thread
+  GET  /api/events[?worker=&type=&limit=&since=&sprint=]
+  GET  /api/events/stream[?worker=&type=] Server-Sent Events
+  GET  /api/sprints                      List sprints
+  GET  /api/sprints/active               Show the active sprint
+  GET  /api/sprints/<id>[/workers]       Show a sprint (and its workers)
+
+Examples:
+  devhive serve
+  devhive serve --listen 127.0.0.1:8080
+  curl -N localhost:7717/api/events/stream?type=worker_error
+  curl -H 'Content-Type: application/json' -d '{"status": "working"}' localhost:7717/api/workers/fe/status`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listen, _ := cmd.Flags().GetString("listen")
+			refresh, _ := cmd.Flags().GetInt("refresh")
+
+			srv := server.New(database)
+			var config *ComposeConfig
+			projectRoot := db.FindProjectRoot()
+			if configFile, _ := FindProjectComposeFile(); configFile != "" {
+				if c, err := LoadComposeFile(configFile); err == nil {
+					config, projectRoot = c, filepath.Dir(configFile)
+					srv.AutoComplete = config.Defaults.AutoComplete
+					srv.Complete = func(name string) (bool, error) {
+						return completeWorker(c, filepath.Dir(configFile), name, nil)
+					}
+				}
+			}
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", listen, err)
+			}
+			if host, _, _ := net.SplitHostPort(listen); !isLoopback(host) {
+				fmt.Printf("⚠ Listening on a non-loopback address (%s): the API has no authentication\n", listen)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			// Requests share ctx so open event streams end on Ctrl+C
+			httpServer := &http.Server{
+				Handler:     srv,
+				BaseContext: func(net.Listener) context.Context { return ctx },
+			}
+			// Keep the observed git state (diffstat) fresh for the dashboard
+			if refresh > 0 {
+				go refreshGitStatesEvery(ctx, time.Duration(refresh)*time.Second, config, projectRoot)
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				httpServer.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Printf("Serving DevHive API on http://%s (Ctrl+C to stop)\n", ln.Addr())
+			if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("listen", "l", "127.0.0.1:7717", "Address to listen on")
+	cmd.Flags().Int("refresh", 30, "Seconds between git state refreshes (0 to disable)")
+
+	return cmd
+}
+
+// refreshGitStatesEvery refreshes the observed git state of all workers until ctx is done
+func refreshGitStatesEvery(ctx context.Context, interval time.Duration, config *ComposeConfig, projectRoot string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if workers, err := database.GetAllWorkers(); err == nil {
+			refreshGitStates(workers, config, projectRoot)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// isLoopback reports whether a listen host only accepts local connections
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
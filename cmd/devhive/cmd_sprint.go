@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// sprintCmd groups the sprint lifecycle commands
+func sprintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sprint",
+		Short: "Manage sprints",
+		Long: `Create, inspect and end sprints.
+
+Sprint IDs are generated from the date plus a counter (e.g., 20250118-01)
+unless defaults.sprint or --id is given.
+
+Examples:
+  devhive sprint new --goal "Login flow"
+  devhive sprint list
+  devhive sprint show 20250118-01
+  devhive sprint close              # Complete the active sprint and its workers
+  devhive sprint abort              # End the active sprint, keeping worker statuses`,
+	}
+
+	cmd.AddCommand(sprintNewCmd())
+	cmd.AddCommand(sprintListCmd())
+	cmd.AddCommand(sprintShowCmd())
+	cmd.AddCommand(sprintCloseCmd())
+	cmd.AddCommand(sprintAbortCmd())
+
+	return cmd
+}
+
+func sprintNewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "Start a new sprint",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, _ := cmd.Flags().GetString("id")
+			goal, _ := cmd.Flags().GetString("goal")
+
+			if id == "" {
+				if configFile, err := FindComposeFile(); err == nil {
+					if config, err := LoadComposeFile(configFile); err == nil {
+						id = config.GenerateSprintID()
+					}
+				}
+			} else if existing, err := database.GetSprint(id); err != nil {
+				return err
+			} else if existing != nil {
+				return fmt.Errorf("sprint already exists: %s", id)
+			}
+
+			id, err := database.NewSprintID(id)
+			if err != nil {
+				return err
+			}
+			if err := database.CreateSprintWithGoal(id, goal); err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ Created sprint: %s\n", id)
+			if goal != "" {
+				fmt.Printf("  Goal: %s\n", goal)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("id", "", "Sprint ID (default: generated)")
+	cmd.Flags().StringP("goal", "g", "", "Sprint goal")
+
+	return cmd
+}
+
+func sprintListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List sprints",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprints, err := database.ListSprints()
+			if err != nil {
+				return err
+			}
+			if len(sprints) == 0 {
+				fmt.Println("No sprints")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSTATUS\tWORKERS\tSTARTED\tDURATION\tGOAL")
+			for _, s := range sprints {
+				workers, err := database.GetSprintWorkers(s.ID)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n",
+					s.ID, sprintStatusIcon(s.Status), len(workers),
+					s.StartedAt.Local().Format("2006-01-02 15:04"), sprintDuration(s), s.Goal)
+			}
+			tw.Flush()
+			return nil
+		},
+	}
+}
+
+func sprintShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [sprint-id]",
+		Short: "Show a sprint and its workers (default: active sprint)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprint, err := findSprint(args)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Sprint:   %s\n", sprint.ID)
+			fmt.Printf("Status:   %s\n", sprintStatusIcon(sprint.Status))
+			if sprint.Goal != "" {
+				fmt.Printf("Goal:     %s\n", sprint.Goal)
+			}
+			fmt.Printf("Started:  %s\n", sprint.StartedAt.Local().Format("2006-01-02 15:04:05"))
+			if sprint.CompletedAt != nil {
+				fmt.Printf("Ended:    %s\n", sprint.CompletedAt.Local().Format("2006-01-02 15:04:05"))
+			}
+			fmt.Printf("Duration: %s\n", sprintDuration(*sprint))
+
+			workers, err := database.GetSprintWorkers(sprint.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			if len(workers) == 0 {
+				fmt.Println("No workers")
+				return nil
+			}
+
+			fmt.Println("Workers:")
+			for _, w := range workers {
+				fmt.Printf("  %-12s %s %s %3d%%", w.Name, statusIcon(w.Status), progressBar(w.Progress, 10), w.Progress)
+				if w.ErrorCount > 0 {
+					fmt.Printf("  (%d error(s))", w.ErrorCount)
+				}
+				fmt.Println()
+				if w.MonitoredAt != nil {
+					fmt.Printf("  %-12s git: %s\n", "", formatGitState(w))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func sprintCloseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "close",
+		Short: "Complete the active sprint and its workers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := database.CompleteSprint()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Sprint '%s' completed\n", id)
+			return nil
+		},
+	}
+}
+
+func sprintAbortCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort",
+		Short: "End the active sprint without completing its workers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := database.AbortSprint()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Sprint '%s' aborted\n", id)
+			return nil
+		},
+	}
+}
+
+// findSprint returns the sprint named in args, or the active sprint
+func findSprint(args []string) (*db.Sprint, error) {
+	if len(args) == 0 || args[0] == "" {
+		sprint, err := database.GetActiveSprint()
+		if err != nil {
+			return nil, err
+		}
+		if sprint == nil {
+			return nil, fmt.Errorf("no active sprint")
+		}
+		return sprint, nil
+	}
+
+	sprint, err := database.GetSprint(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if sprint == nil {
+		return nil, fmt.Errorf("sprint not found: %s", args[0])
+	}
+	return sprint, nil
+}
+
+// sprintStatusIcon returns an emoji icon for sprint status
+func sprintStatusIcon(status string) string {
+	switch status {
+	case "active":
+		return "🔨 active"
+	case "completed":
+		return "✅ completed"
+	case "aborted":
+		return "🛑 aborted"
+	default:
+		return status
+	}
+}
+
+// sprintDuration returns how long a sprint ran (or has been running)
+func sprintDuration(s db.Sprint) string {
+	end := time.Now()
+	if s.CompletedAt != nil {
+		end = *s.CompletedAt
+	}
+	d := end.Sub(s.StartedAt).Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	if d < 24*time.Hour {
+		return d.String()[:len(d.String())-2] // Drop trailing "0s"
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
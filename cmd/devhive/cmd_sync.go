@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/iguchi/devhive/internal/branchsync"
+	"github.com/spf13/cobra"
+)
+
+// syncCmd updates worker worktrees from the base branch
+func syncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync [worker...]",
+		Short: "Update worker worktrees from the base branch",
+		Long: `Rebase each worker's branch onto defaults.base_branch (or merge the base
+branch into it) inside its worktree.
+
+  - Worktrees with uncommitted changes to tracked files are skipped
+  - On conflicts the rebase/merge is aborted, the worktree is left as it
+    was, and the worker gets a message with instructions to sync by hand
+
+Without worker names, every worker of the active sprint that has a
+worktree is synced, except completed ones.
+
+With --check nothing is changed: only how far each branch is ahead of and
+behind the base branch is reported.
+
+Examples:
+  devhive sync                     # Rebase all active workers
+  devhive sync frontend backend    # Only these workers
+  devhive sync --strategy merge    # Merge the base branch instead
+  devhive sync --check             # Ahead/behind report only
+  devhive sync --check --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strategy, _ := cmd.Flags().GetString("strategy")
+			checkOnly, _ := cmd.Flags().GetBool("check")
+			if strategy != branchsync.StrategyRebase && strategy != branchsync.StrategyMerge {
+				return fmt.Errorf("invalid strategy: %s (use rebase or merge)", strategy)
+			}
+
+			configFile, err := FindProjectComposeFile()
+			if err != nil {
+				return err
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+			projectRoot := filepath.Dir(configFile)
+
+			names, err := syncWorkers(config, projectRoot, args)
+			if err != nil {
+				return err
+			}
+			base := config.GetBaseBranch()
+
+			formatter, err := getOutputFormatter(cmd)
+			if err != nil {
+				return err
+			}
+
+			results := []branchsync.Result{}
+			failed := 0
+			for _, name := range names {
+				dir := workerWorktreeDir(config, projectRoot, name)
+				var r branchsync.Result
+				if checkOnly {
+					r = branchsync.Check(dir, base)
+				} else {
+					r = branchsync.Sync(dir, base, strategy)
+				}
+				r.Worker = name
+				results = append(results, r)
+
+				if !checkOnly {
+					if err := reportSync(r, strategy); err != nil {
+						return err
+					}
+					if formatter == nil {
+						printSyncResult(r, strategy)
+					}
+				}
+				if r.Status == branchsync.StatusConflict || r.Status == branchsync.StatusError {
+					failed++
+				}
+			}
+
+			if formatter != nil {
+				if err := formatter.Write(os.Stdout, results); err != nil {
+					return err
+				}
+			} else if checkOnly {
+				printSyncCheck(results)
+			} else if len(results) == 0 {
+				fmt.Println("No worktrees to sync.")
+			}
+
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d worktree(s) could not be synced", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("strategy", "s", branchsync.StrategyRebase, "How to update branches: rebase or merge")
+	cmd.Flags().Bool("check", false, "Only report how far branches are ahead of and behind the base branch")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// syncWorkers returns the workers to sync: the given ones, or the active
+// sprint's workers that are not completed and have a worktree
+func syncWorkers(config *ComposeConfig, projectRoot string, names []string) ([]string, error) {
+	if len(names) > 0 {
+		for _, name := range names {
+			if _, ok := config.Workers[name]; !ok {
+				return nil, fmt.Errorf("worker %s not found in config", name)
+			}
+			if _, err := os.Stat(workerWorktreeDir(config, projectRoot, name)); err != nil {
+				return nil, fmt.Errorf("worker %s has no worktree", name)
+			}
+		}
+		return names, nil
+	}
+
+	states, err := workerStates()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, name := range config.GetOrderedWorkerNames(nil) {
+		if w := states[name]; w == nil || w.Status == "completed" {
+			continue
+		}
+		if _, err := os.Stat(workerWorktreeDir(config, projectRoot, name)); err != nil {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// reportSync logs a branch_synced event for a synced worktree, and sends
+// the worker instructions when conflicts stopped the sync
+func reportSync(r branchsync.Result, strategy string) error {
+	switch r.Status {
+	case branchsync.StatusSynced:
+		return database.ReportBranchSynced(r.Worker, r.Base, strategy, r.Behind, r.Commit)
+	case branchsync.StatusConflict:
+		subject, content := syncConflictMessage(r, strategy)
+		_, err := database.SendMessage("devhive", r.Worker, "warning", subject, content)
+		return err
+	}
+	return nil
+}
+
+// syncConflictMessage builds the instructions sent when a sync conflicts
+func syncConflictMessage(r branchsync.Result, strategy string) (string, string) {
+	var sb strings.Builder
+	verb, resume := "rebased onto", "git rebase --continue"
+	if strategy == branchsync.StrategyMerge {
+		verb, resume = "merged with", "git commit"
+	}
+
+	fmt.Fprintf(&sb, "Your branch %s could not be %s %s (%d new commit(s)) because of conflicts in:\n", r.Branch, verb, r.Base, r.Behind)
+	for _, f := range r.Conflicts {
+		fmt.Fprintf(&sb, "  %s\n", f)
+	}
+	fmt.Fprintf(&sb, "\nNothing was changed in your worktree. Update your branch yourself:\n")
+	fmt.Fprintf(&sb, "  git %s %s\n", strategy, r.Base)
+	fmt.Fprintf(&sb, "  # resolve the conflicts, then: git add <files> && %s\n", resume)
+	return fmt.Sprintf("⚠️ Sync with %s stopped: conflicts", r.Base), sb.String()
+}
+
+// printSyncResult prints the outcome of syncing one worktree
+func printSyncResult(r branchsync.Result, strategy string) {
+	switch r.Status {
+	case branchsync.StatusUpToDate:
+		fmt.Printf("= %s: up to date with %s\n", r.Worker, r.Base)
+	case branchsync.StatusSynced:
+		verb := "rebased onto"
+		if strategy == branchsync.StrategyMerge {
+			verb = "merged"
+		}
+		fmt.Printf("✓ %s: %s %s (%d new commit(s))\n", r.Worker, verb, r.Base, r.Behind)
+	case branchsync.StatusDirty:
+		fmt.Printf("⚠ %s: uncommitted changes, skipped (%d behind %s)\n", r.Worker, r.Behind, r.Base)
+	case branchsync.StatusConflict:
+		fmt.Printf("✗ %s: conflicts in %s (aborted, worker notified)\n", r.Worker, strings.Join(r.Conflicts, ", "))
+	default:
+		fmt.Printf("✗ %s: %s\n", r.Worker, r.Error)
+	}
+}
+
+// printSyncCheck prints the ahead/behind report
+func printSyncCheck(results []branchsync.Result) {
+	if len(results) == 0 {
+		fmt.Println("No worktrees to check.")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tBRANCH\tAHEAD\tBEHIND\tSTATE")
+	for _, r := range results {
+		state := strings.ReplaceAll(r.Status, "_", " ")
+		switch {
+		case r.Status == branchsync.StatusError:
+			state = "error: " + r.Error
+		case r.Status == branchsync.StatusDirty:
+			state = "behind (uncommitted changes)"
+		case r.Dirty:
+			state += " (uncommitted changes)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", r.Worker, r.Branch, r.Ahead, r.Behind, state)
+	}
+	tw.Flush()
+}
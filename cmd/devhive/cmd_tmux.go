@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -18,10 +19,13 @@ func tmuxCmd() *cobra.Command {
 
 All workers are displayed in a single window with split panes.
 Each pane runs the worker's configured command in its worktree.
+Pane output is recorded to .devhive/logs/session-<worker>.log
+(view with 'devhive logs --session <worker>').
 
 Examples:
   devhive tmux                    # Start all workers in tmux
   devhive tmux frontend backend   # Start specific workers
+  devhive tmux --profile review   # Also start workers of the review profile
   devhive tmux --session myapp    # Use custom session name
   devhive tmux --no-attach        # Create but don't attach`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -29,6 +33,11 @@ Examples:
 			attach, _ := cmd.Flags().GetBool("attach")
 			noAttach, _ := cmd.Flags().GetBool("no-attach")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			profiles, _ := cmd.Flags().GetStringSlice("profile")
+			capture := true
+			if noCapture, _ := cmd.Flags().GetBool("no-capture"); noCapture {
+				capture = false
+			}
 
 			// Handle --no-attach flag
 			if noAttach {
@@ -54,6 +63,11 @@ Examples:
 			if err != nil {
 				return err
 			}
+			if len(profiles) > 0 {
+				if err := config.SetActiveProfiles(profiles); err != nil {
+					return err
+				}
+			}
 
 			// Get workers
 			workers := config.GetEffectiveWorkers(args)
@@ -61,8 +75,14 @@ Examples:
 				return fmt.Errorf("no workers to start")
 			}
 
-			// Get worker names in yaml definition order
-			workerNames := config.GetOrderedWorkerNames(args)
+			// Get worker names in startup order, holding back workers with unmet dependencies
+			workerNames, err := readyWorkers(config, config.GetStartupOrder(args))
+			if err != nil {
+				return err
+			}
+			if len(workerNames) == 0 {
+				return fmt.Errorf("all workers are waiting for their dependencies")
+			}
 
 			// Default session name
 			if sessionName == "" {
@@ -118,15 +138,30 @@ Examples:
 			// Track successful pane creation
 			createdPanes := 0
 
+			// Sprint ID is recorded in session logs for per-sprint rotation
+			sprintID := ""
+			if sprint, err := database.GetActiveSprint(); err == nil && sprint != nil {
+				sprintID = sprint.ID
+			}
+
 			// Create new session with first pane
-			firstCmd := buildPaneCommand(firstName, firstWorker, firstWorktree, config, configDir)
-			newSessionCmd := exec.Command("tmux", "new-session", "-d", "-s", sessionName,
-				"-c", firstWorktree, "-n", "workers")
-			if err := newSessionCmd.Run(); err != nil {
+			firstEnv, err := paneEnvArgs(firstName, config, configDir)
+			if err != nil {
+				return fmt.Errorf("%s: %w", firstName, err)
+			}
+			newSessionArgs := append([]string{"new-session", "-d", "-s", sessionName,
+				"-c", firstWorktree, "-n", "workers", "-P", "-F", "#{pane_id}"}, firstEnv...)
+			newSessionCmd := exec.Command("tmux", newSessionArgs...)
+			firstPane, err := newSessionCmd.Output()
+			if err != nil {
 				return fmt.Errorf("failed to create tmux session: %w", err)
 			}
+			if capture {
+				capturePane(configDir, firstName, strings.TrimSpace(string(firstPane)), sprintID)
+			}
 
 			// Send command to first pane
+			firstCmd := firstWorker.GetFullCommand(firstName, config, configDir)
 			sendKeysCmd := exec.Command("tmux", "send-keys", "-t", sessionName+":workers", firstCmd, "Enter")
 			sendKeysCmd.Run()
 
@@ -162,12 +197,21 @@ Examples:
 				// This helps prevent "pane too small" errors with many panes
 				exec.Command("tmux", "select-layout", "-t", sessionName+":workers", "tiled").Run()
 
+				paneEnv, err := paneEnvArgs(name, config, configDir)
+				if err != nil {
+					fmt.Printf("⚠ %s: %v\n", name, err)
+					continue
+				}
+
 				// Split pane
-				splitCmd := exec.Command("tmux", "split-window", "-t", sessionName+":workers",
-					"-c", worktree)
-				splitOutput, err := splitCmd.CombinedOutput()
+				splitArgs := append([]string{"split-window", "-t", sessionName + ":workers",
+					"-c", worktree, "-P", "-F", "#{pane_id}"}, paneEnv...)
+				splitCmd := exec.Command("tmux", splitArgs...)
+				var splitErr bytes.Buffer
+				splitCmd.Stderr = &splitErr
+				paneID, err := splitCmd.Output()
 				if err != nil {
-					errMsg := strings.TrimSpace(string(splitOutput))
+					errMsg := strings.TrimSpace(splitErr.String())
 					if errMsg == "" {
 						errMsg = err.Error()
 					}
@@ -175,9 +219,12 @@ Examples:
 					fmt.Printf("  (hint: tmux window may be too small to create more panes)\n")
 					continue
 				}
+				if capture {
+					capturePane(configDir, name, strings.TrimSpace(string(paneID)), sprintID)
+				}
 
 				// Send command
-				paneCmd := buildPaneCommand(name, worker, worktree, config, configDir)
+				paneCmd := worker.GetFullCommand(name, config, configDir)
 				sendKeysCmd := exec.Command("tmux", "send-keys", "-t", sessionName+":workers", paneCmd, "Enter")
 				sendKeysCmd.Run()
 
@@ -210,10 +257,23 @@ Examples:
 	cmd.Flags().Bool("attach", true, "Attach to session after creation (auto-disabled if not in TTY)")
 	cmd.Flags().Bool("no-attach", false, "Don't attach to session after creation")
 	cmd.Flags().Bool("dry-run", false, "Show what would be done")
+	cmd.Flags().Bool("no-capture", false, "Don't record pane output to .devhive/logs/")
+	cmd.Flags().StringSlice("profile", nil, "Activate workers of these profiles (repeatable, or comma-separated)")
 
 	return cmd
 }
 
+// capturePane records a pane's output to the worker's session log
+func capturePane(projectRoot, workerName, paneID, sprintID string) {
+	logPath, err := prepareSessionLog(projectRoot, workerName, sprintID)
+	if err == nil {
+		err = startPaneCapture(paneID, logPath)
+	}
+	if err != nil {
+		fmt.Printf("⚠ Failed to capture session for %s: %v\n", workerName, err)
+	}
+}
+
 // getWorktreePath returns the worktree path for a worker
 func getWorktreePath(workerName, override string) string {
 	if override != "" {
@@ -222,11 +282,19 @@ func getWorktreePath(workerName, override string) string {
 	return filepath.Join(".devhive", "worktrees", workerName)
 }
 
-// buildPaneCommand builds the command to run in a pane
-func buildPaneCommand(name string, worker ComposeWorker, worktree string, config *ComposeConfig, projectRoot string) string {
-	// Set DEVHIVE_WORKER environment variable and run command
-	cmd := worker.GetFullCommand(name, config, projectRoot)
-	return fmt.Sprintf("export DEVHIVE_WORKER=%s && %s", name, cmd)
+// paneEnvArgs returns the tmux -e options that set a worker's environment
+// (DEVHIVE_WORKER, env, env_file, ports) in a new pane. Values are never
+// typed into the pane, so they stay out of the session log and shell history.
+func paneEnvArgs(name string, config *ComposeConfig, projectRoot string) ([]string, error) {
+	env, err := config.GetWorkerEnv(name, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, 2*len(env))
+	for _, kv := range env {
+		args = append(args, "-e", kv)
+	}
+	return args, nil
 }
 
 // tmuxAttach attaches to a tmux session
@@ -288,27 +356,39 @@ Examples:
 	}
 }
 
+// tmuxSession is a row of 'devhive tmux-list' (also its --json/--format output)
+type tmuxSession struct {
+	Name     string `json:"name"`
+	Attached bool   `json:"attached"`
+}
+
 func tmuxListCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "tmux-list",
 		Short: "List DevHive tmux sessions",
 		Long:  `List all tmux sessions that match DevHive naming convention.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			listCmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
+			devhiveSessions := []tmuxSession{}
+			listCmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}\t#{session_attached}")
 			output, err := listCmd.Output()
+			if err == nil {
+				for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+					name, attached, _ := strings.Cut(line, "\t")
+					if strings.HasPrefix(name, "devhive-") {
+						devhiveSessions = append(devhiveSessions, tmuxSession{Name: name, Attached: attached != "" && attached != "0"})
+					}
+				}
+			}
+
+			if ok, err := writeOutput(cmd, devhiveSessions); err != nil || ok {
+				return err
+			}
+
 			if err != nil {
 				fmt.Println("No tmux sessions")
 				return nil
 			}
 
-			sessions := strings.Split(strings.TrimSpace(string(output)), "\n")
-			devhiveSessions := []string{}
-			for _, s := range sessions {
-				if strings.HasPrefix(s, "devhive-") {
-					devhiveSessions = append(devhiveSessions, s)
-				}
-			}
-
 			if len(devhiveSessions) == 0 {
 				fmt.Println("No DevHive tmux sessions")
 				return nil
@@ -316,11 +396,19 @@ func tmuxListCmd() *cobra.Command {
 
 			fmt.Println("DevHive tmux sessions:")
 			for _, s := range devhiveSessions {
-				fmt.Printf("  %s\n", s)
+				if s.Attached {
+					fmt.Printf("  %s (attached)\n", s.Name)
+				} else {
+					fmt.Printf("  %s\n", s.Name)
+				}
 			}
 			return nil
 		},
 	}
+
+	addOutputFlags(cmd)
+
+	return cmd
 }
 
 // isTerminal checks if stdin is connected to a terminal
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// topSortOrders are the orders 's' cycles through
+var topSortOrders = []string{"status", "progress", "name"}
+
+// topStatusRank orders workers needing attention first
+var topStatusRank = map[string]int{"error": 0, "blocked": 1, "working": 2, "pending": 3, "completed": 4}
+
+// topCmd shows a live, interactive view of workers and the PM inbox
+func topCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Interactive terminal dashboard",
+		Long: `Full-screen view of workers and the PM inbox, refreshed live.
+
+Combines 'devhive ps', 'devhive logs -f' and 'devhive inbox' in one terminal.
+
+Keys:
+  ↑/↓ or k/j   Select a worker (or a message in the inbox)
+  Enter        Expand/collapse a worker (git state, recent events, notes)
+  Tab or i     Switch between workers and inbox
+  s            Cycle sort order (status, progress, name)
+  a            Show/hide completed workers
+  r            Reply to the selected message
+  m            Mark the selected message read
+  t            Jump to the worker's tmux pane
+  q            Quit`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isTerminal() {
+				return fmt.Errorf("devhive top requires a terminal")
+			}
+
+			sortBy, _ := cmd.Flags().GetString("sort")
+			if !containsString(topSortOrders, sortBy) {
+				return fmt.Errorf("invalid sort order: %s (valid: %s)", sortBy, strings.Join(topSortOrders, ", "))
+			}
+			sessionName, _ := cmd.Flags().GetString("session")
+
+			m := &topModel{
+				sortBy:      sortBy,
+				sessionName: sessionName,
+				focus:       "workers",
+				expanded:    make(map[string]bool),
+			}
+			m.projectRoot, _ = os.Getwd()
+			if configFile, _ := FindComposeFile(); configFile != "" {
+				m.projectRoot = filepath.Dir(configFile)
+				if config, err := LoadComposeFile(configFile); err == nil && m.sessionName == "" {
+					m.sessionName = "devhive-" + config.Project
+				}
+			}
+
+			return m.run()
+		},
+	}
+
+	cmd.Flags().String("sort", "status", "Sort order: status, progress or name")
+	cmd.Flags().StringP("session", "s", "", "tmux session name for 't' (default: devhive-<project>)")
+
+	return cmd
+}
+
+// topModel is the state of 'devhive top'
+type topModel struct {
+	projectRoot string
+	sessionName string
+
+	sortBy   string
+	showAll  bool
+	focus    string // workers or inbox
+	expanded map[string]bool
+
+	sprint   *db.Sprint
+	workers  []db.Worker
+	hidden   int
+	details  map[string][]string
+	messages []db.Message
+
+	selectedWorker  string
+	selectedMessage int
+
+	replying bool
+	input    []rune
+	flash    string
+
+	// attachTo is a tmux pane to attach to after quitting
+	attachTo string
+}
+
+// run draws the dashboard until the user quits
+func (m *topModel) run() error {
+	restore, err := enterFullScreen()
+	if err != nil {
+		return err
+	}
+	var once sync.Once
+	restoreOnce := func() { once.Do(restore) }
+	defer restoreOnce()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lastID, _ := database.GetLastEventID()
+	events := database.Subscribe(ctx, lastID, db.EventFilter{})
+
+	keys := make(chan string)
+	go func() {
+		for {
+			key, err := readKey()
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- key
+		}
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	// Redraw regularly for relative times, git state and terminal resizes
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	m.load()
+	m.draw()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || m.handleKey(key) {
+				restoreOnce()
+				return m.attach()
+			}
+		case <-events:
+			// Coalesce bursts of events into one reload
+			for drained := false; !drained; {
+				select {
+				case <-events:
+				default:
+					drained = true
+				}
+			}
+			m.load()
+		case <-ticker.C:
+			m.load()
+		case <-sigs:
+			return nil
+		}
+		m.draw()
+	}
+}
+
+// attach replaces the process with 'tmux attach' if a pane was chosen outside tmux
+func (m *topModel) attach() error {
+	if m.attachTo == "" {
+		return nil
+	}
+	tmux, err := exec.LookPath("tmux")
+	if err != nil {
+		return err
+	}
+	// Exec (rather than run) so no goroutine keeps reading the terminal
+	return syscall.Exec(tmux, []string{"tmux", "attach", "-t", m.sessionName}, os.Environ())
+}
+
+// load refreshes workers, details and the inbox from the database
+func (m *topModel) load() {
+	m.sprint, _ = database.GetActiveSprint()
+
+	workers, err := database.GetAllWorkers()
+	if err != nil {
+		m.flash = "Error: " + err.Error()
+		return
+	}
+	m.workers = m.workers[:0]
+	m.hidden = 0
+	for _, w := range workers {
+		if !m.showAll && w.Status == "completed" {
+			m.hidden++
+			continue
+		}
+		m.workers = append(m.workers, w)
+	}
+	sortWorkers(m.workers, m.sortBy)
+	if m.selectedWorker == "" && len(m.workers) > 0 {
+		m.selectedWorker = m.workers[0].Name
+	}
+
+	m.details = make(map[string][]string)
+	for _, w := range m.workers {
+		if m.expanded[w.Name] {
+			m.details[w.Name] = m.workerDetails(w)
+		}
+	}
+
+	if messages, err := database.GetMessages("pm", false); err == nil {
+		m.messages = messages
+	}
+	if m.selectedMessage >= len(m.messages) {
+		m.selectedMessage = len(m.messages) - 1
+	}
+	if m.selectedMessage < 0 {
+		m.selectedMessage = 0
+	}
+}
+
+// sortWorkers sorts workers by status (attention first), progress (highest first) or name
+func sortWorkers(workers []db.Worker, by string) {
+	sort.SliceStable(workers, func(i, j int) bool {
+		a, b := workers[i], workers[j]
+		switch by {
+		case "status":
+			if topStatusRank[a.Status] != topStatusRank[b.Status] {
+				return topStatusRank[a.Status] < topStatusRank[b.Status]
+			}
+		case "progress":
+			if a.Progress != b.Progress {
+				return a.Progress > b.Progress
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// workerDetails returns the expanded lines for a worker
+func (m *topModel) workerDetails(w db.Worker) []string {
+	lines := []string{"git: " + formatGitState(w)}
+	if w.Status == "pending" && w.PendingReason != "" {
+		lines = append(lines, "⏸ "+w.PendingReason)
+	}
+	if w.LastError != "" {
+		lines = append(lines, ansiRed+fmt.Sprintf("last error (%d total): %s", w.ErrorCount, w.LastError)+ansiReset)
+	}
+
+	name := w.Name
+	if events, err := database.GetRecentEvents(5, nil, &name); err == nil && len(events) > 0 {
+		lines = append(lines, ansiBold+"Recent events:"+ansiReset)
+		for i := len(events) - 1; i >= 0; i-- {
+			lines = append(lines, "  "+ansiDim+events[i].CreatedAt.Local().Format("15:04:05")+ansiReset+" "+formatEvent(events[i]))
+		}
+	}
+
+	if notes := lastNoteLines(filepath.Join(m.projectRoot, ".devhive", "workers", w.Name+".md"), 5); len(notes) > 0 {
+		lines = append(lines, ansiBold+"Notes:"+ansiReset)
+		for _, note := range notes {
+			lines = append(lines, "  "+note)
+		}
+	}
+	return lines
+}
+
+// lastNoteLines returns the last n non-empty lines of a notes file (skipping its title)
+func lastNoteLines(path string, n int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// handleKey applies a key press. Returns true to quit.
+func (m *topModel) handleKey(key string) bool {
+	m.flash = ""
+
+	if m.replying {
+		switch key {
+		case keyEnter:
+			m.sendReply()
+		case keyEscape:
+			m.replying = false
+			m.input = nil
+		case keyBackspace:
+			if len(m.input) > 0 {
+				m.input = m.input[:len(m.input)-1]
+			}
+		default:
+			if len(key) > 0 && key[0] >= 0x20 {
+				m.input = append(m.input, []rune(key)...)
+			}
+		}
+		return false
+	}
+
+	switch key {
+	case "q", keyEscape:
+		return true
+	case keyUp, "k":
+		m.move(-1)
+	case keyDown, "j":
+		m.move(1)
+	case keyTab, "i":
+		if m.focus == "workers" {
+			m.focus = "inbox"
+		} else {
+			m.focus = "workers"
+		}
+	case keyEnter:
+		if m.focus == "workers" && m.selectedWorker != "" {
+			m.expanded[m.selectedWorker] = !m.expanded[m.selectedWorker]
+			m.load()
+		}
+	case "s":
+		for i, order := range topSortOrders {
+			if order == m.sortBy {
+				m.sortBy = topSortOrders[(i+1)%len(topSortOrders)]
+				break
+			}
+		}
+		m.load()
+	case "a":
+		m.showAll = !m.showAll
+		m.load()
+	case "r":
+		if m.focus == "inbox" && len(m.messages) > 0 {
+			m.replying = true
+			m.input = nil
+		}
+	case "m":
+		if m.focus == "inbox" && len(m.messages) > 0 {
+			msg := m.messages[m.selectedMessage]
+			if err := database.MarkMessageRead(msg.ID); err != nil {
+				m.flash = "Error: " + err.Error()
+			} else {
+				m.flash = fmt.Sprintf("Marked #%d read", msg.ID)
+			}
+			m.load()
+		}
+	case "t":
+		return m.jumpToPane()
+	}
+	return false
+}
+
+// move moves the selection in the focused list
+func (m *topModel) move(delta int) {
+	if m.focus == "inbox" {
+		m.selectedMessage += delta
+		if m.selectedMessage >= len(m.messages) {
+			m.selectedMessage = len(m.messages) - 1
+		}
+		if m.selectedMessage < 0 {
+			m.selectedMessage = 0
+		}
+		return
+	}
+
+	for i, w := range m.workers {
+		if w.Name == m.selectedWorker {
+			if j := i + delta; j >= 0 && j < len(m.workers) {
+				m.selectedWorker = m.workers[j].Name
+			}
+			return
+		}
+	}
+	if len(m.workers) > 0 {
+		m.selectedWorker = m.workers[0].Name
+	}
+}
+
+// sendReply replies to the selected message with the typed text
+func (m *topModel) sendReply() {
+	m.replying = false
+	text := strings.TrimSpace(string(m.input))
+	m.input = nil
+	if text == "" || len(m.messages) == 0 {
+		return
+	}
+
+	parent := m.messages[m.selectedMessage]
+	reply, err := database.ReplyToMessage(parent.ID, "pm", "reply", parent.ReplySubject(), text)
+	if err != nil {
+		m.flash = "Error: " + err.Error()
+		return
+	}
+	m.flash = fmt.Sprintf("✅ Reply #%d sent to %s", reply.ID, reply.ToWorker)
+	m.load()
+}
+
+// jumpToPane selects the tmux pane of the selected worker.
+// Inside tmux the client switches to it; outside, top quits and attaches.
+func (m *topModel) jumpToPane() bool {
+	if m.selectedWorker == "" {
+		return false
+	}
+	if m.sessionName == "" {
+		m.flash = "No tmux session (use --session)"
+		return false
+	}
+
+	paneID, err := findWorkerPane(m.sessionName, m.selectedWorker)
+	if err != nil {
+		m.flash = "Error: " + err.Error()
+		return false
+	}
+	exec.Command("tmux", "select-window", "-t", paneID).Run()
+	exec.Command("tmux", "select-pane", "-t", paneID).Run()
+
+	if os.Getenv("TMUX") != "" {
+		if err := exec.Command("tmux", "switch-client", "-t", paneID).Run(); err != nil {
+			m.flash = "Error: " + err.Error()
+		}
+		return false
+	}
+	m.attachTo = paneID
+	return true
+}
+
+// findWorkerPane returns the ID of the pane titled with the worker's name by 'devhive tmux'
+func findWorkerPane(sessionName, worker string) (string, error) {
+	out, err := exec.Command("tmux", "list-panes", "-s", "-t", sessionName, "-F", "#{pane_id}\t#{pane_title}").Output()
+	if err != nil {
+		return "", fmt.Errorf("tmux session not found: %s", sessionName)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		id, title, _ := strings.Cut(line, "\t")
+		if title == worker {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("no tmux pane for %s in %s", worker, sessionName)
+}
+
+// ============================================
+// Rendering
+// ============================================
+
+// draw renders the whole screen
+func (m *topModel) draw() {
+	width, height := terminalSize()
+	body, cursor := m.render()
+
+	// Scroll so the selected line stays visible above the footer
+	visible := height - 1
+	start := 0
+	if len(body) > visible && cursor >= visible {
+		start = cursor - visible + 1
+	}
+	end := start + visible
+	if end > len(body) {
+		end = len(body)
+	}
+
+	var out strings.Builder
+	out.WriteString(ansiClearScreen)
+	for _, line := range body[start:end] {
+		out.WriteString(truncateLine(line, width) + "\r\n")
+	}
+	for i := end - start; i < visible; i++ {
+		out.WriteString("\r\n")
+	}
+	out.WriteString(truncateLine(m.footer(), width))
+	fmt.Print(out.String())
+}
+
+// render returns the body lines and the index of the selected line
+func (m *topModel) render() ([]string, int) {
+	var lines []string
+	cursor := 0
+
+	title := ansiBold + "DevHive top" + ansiReset
+	if m.sprint != nil {
+		title += " — sprint " + m.sprint.ID
+		if m.sprint.Goal != "" {
+			title += ": " + m.sprint.Goal
+		}
+	} else {
+		title += " — no active sprint"
+	}
+	title += ansiDim + fmt.Sprintf("  (sort: %s, %s)", m.sortBy, time.Now().Format("15:04:05")) + ansiReset
+	lines = append(lines, title, "")
+
+	// Workers
+	lines = append(lines, ansiBold+"  "+padRight("NAME", 14)+padRight("STATUS", 11)+padRight("SESSION", 20)+padRight("PROGRESS", 18)+padRight("UNREAD", 8)+"ACTIVITY"+ansiReset)
+	if len(m.workers) == 0 {
+		lines = append(lines, ansiDim+"  No running workers"+ansiReset)
+	}
+	for _, w := range m.workers {
+		selected := m.focus == "workers" && w.Name == m.selectedWorker
+		if selected {
+			cursor = len(lines)
+		}
+		lines = append(lines, m.workerRow(w, selected))
+		for _, detail := range m.details[w.Name] {
+			lines = append(lines, "      "+detail)
+		}
+	}
+	if m.hidden > 0 {
+		lines = append(lines, ansiDim+fmt.Sprintf("  (%d completed hidden, 'a' to show)", m.hidden)+ansiReset)
+	}
+
+	// Inbox
+	lines = append(lines, "", ansiBold+fmt.Sprintf("Inbox (%d unread)", len(m.messages))+ansiReset)
+	if len(m.messages) == 0 {
+		lines = append(lines, ansiDim+"  No messages"+ansiReset)
+	}
+	for i, msg := range m.messages {
+		selected := m.focus == "inbox" && i == m.selectedMessage
+		line := fmt.Sprintf("#%d %s %s from %s", msg.ID, getMessageIcon(msg.MessageType), msg.Subject, msg.FromWorker)
+		if msg.ReplyTo > 0 {
+			line += fmt.Sprintf(" (re #%d)", msg.ReplyTo)
+		}
+		line += ansiDim + " " + formatAgo(msg.CreatedAt) + ansiReset
+		if selected {
+			cursor = len(lines)
+			lines = append(lines, ansiReverse+"> "+line+ansiReset)
+			for _, content := range strings.Split(msg.Content, "\n") {
+				lines = append(lines, "    "+content)
+			}
+			continue
+		}
+		lines = append(lines, "  "+line)
+	}
+
+	return lines, cursor
+}
+
+// workerRow renders a worker as a table row
+func (m *topModel) workerRow(w db.Worker, selected bool) string {
+	prefix := "  "
+	if m.expanded[w.Name] {
+		prefix = "▾ "
+	}
+	if selected {
+		prefix = "> "
+	}
+
+	color := ""
+	switch w.Status {
+	case "working":
+		color = ansiBlue
+	case "completed":
+		color = ansiGreen
+	case "pending":
+		color = ansiYellow
+	case "blocked", "error":
+		color = ansiRed
+	}
+
+	unread := ""
+	if w.UnreadMessages > 0 {
+		unread = fmt.Sprintf("%d", w.UnreadMessages)
+	}
+	activity := w.Activity
+	if w.Status == "pending" && w.PendingReason != "" {
+		activity = "⏸ " + w.PendingReason
+	}
+
+	row := prefix + padRight(w.Name, 14) +
+		color + padRight(w.Status, 11) + ansiReset +
+		padRight(sessionIcon(w.SessionState)+" "+w.SessionState, 20) +
+		padRight(fmt.Sprintf("%s %3d%%", progressBar(w.Progress, 10), w.Progress), 18) +
+		padRight(unread, 8) + activity
+	if selected {
+		// Keep the highlight across the color reset
+		row = ansiReverse + strings.ReplaceAll(row, ansiReset, ansiReset+ansiReverse) + ansiReset
+	}
+	return row
+}
+
+// footer returns the bottom line: the reply prompt, a flash message or key help
+func (m *topModel) footer() string {
+	if m.replying && len(m.messages) > 0 {
+		return fmt.Sprintf("Reply to #%d (Enter to send, Esc to cancel): %s█", m.messages[m.selectedMessage].ID, string(m.input))
+	}
+	if m.flash != "" {
+		return m.flash
+	}
+	if m.focus == "inbox" {
+		return ansiDim + "[↑↓] select  [r] reply  [m] mark read  [tab] workers  [q] quit" + ansiReset
+	}
+	return ansiDim + "[↑↓] select  [enter] expand  [s] sort  [a] all  [t] tmux  [tab] inbox  [q] quit" + ansiReset
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,33 +10,43 @@ import (
 	"strings"
 	"time"
 
+	"github.com/iguchi/devhive/internal/checks"
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/mergequeue"
 	"github.com/spf13/cobra"
 )
 
-// mergeCmd merges a worker's branch into a target branch
+// mergeCmd merges worker branches into a target branch through the merge queue
 func mergeCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "merge <worker> <target-branch>",
-		Short: "Merge worker branch into target branch",
-		Long: `Merge a worker's branch into a specified target branch.
+		Use:   "merge [<worker> <target-branch> | --queue [worker...]]",
+		Short: "Merge worker branches into a target branch, gated by checks",
+		Long: `Merge worker branches into a target branch through a merge queue.
+
+Each branch is merged in a temporary integration worktree (.devhive/merge/)
+on top of the target and the branches merged before it. The checks in
+defaults.checks of .devhive.yaml run there, and the target is fast-forwarded
+only if they pass. A branch that conflicts or fails a check is skipped and
+the queue moves on. The main working copy is not checked out.
+
+Each step is logged as a branch_merged or merge_failed event.
+
+With --queue, the completed workers are merged in startup order
+(dependencies first) into --into (default: defaults.base_branch).
 
 Examples:
-  devhive merge frontend develop    # Merge frontend branch into develop
-  devhive merge backend staging     # Merge backend branch into staging
-  devhive merge fe-auth main        # Merge fe-auth into main (with caution)`,
-		Args: cobra.ExactArgs(2),
+  devhive merge --queue                   # Merge all completed workers into the base branch
+  devhive merge --queue --into develop    # ...into develop
+  devhive merge --queue fe be             # Merge fe, then be
+  devhive merge frontend develop          # Merge one worker's branch into develop`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			workerName := args[0]
-			targetBranch := args[1]
-
-			// Verify worker exists in DB
-			worker, err := database.GetWorker(workerName)
-			if err != nil || worker == nil {
-				return fmt.Errorf("worker not found: %s", workerName)
+			queue, _ := cmd.Flags().GetBool("queue")
+			into, _ := cmd.Flags().GetString("into")
+			if !queue && len(args) != 2 {
+				return fmt.Errorf("usage: devhive merge <worker> <target-branch>, or devhive merge --queue [worker...]")
 			}
 
-			// Load config to get branch
-			configFile, err := FindComposeFile()
+			configFile, err := FindProjectComposeFile()
 			if err != nil {
 				return err
 			}
@@ -44,17 +55,59 @@ Examples:
 				return err
 			}
 
-			workerConfig, ok := config.Workers[workerName]
-			if !ok {
-				return fmt.Errorf("worker %s not found in config", workerName)
+			states, err := workerStates()
+			if err != nil {
+				return err
 			}
-			if workerConfig.Branch == "" {
-				return fmt.Errorf("worker %s has no branch configured", workerName)
+
+			var names []string
+			targetBranch := into
+			if queue {
+				if targetBranch == "" {
+					targetBranch = config.GetBaseBranch()
+				}
+				if len(args) > 0 {
+					names = config.GetStartupOrder(args)
+					if len(names) != len(args) {
+						for _, name := range args {
+							if _, ok := config.Workers[name]; !ok {
+								return fmt.Errorf("worker %s not found in config", name)
+							}
+						}
+					}
+				} else {
+					for _, name := range config.GetStartupOrder(nil) {
+						if w := states[name]; w != nil && w.Status == "completed" {
+							names = append(names, name)
+						}
+					}
+				}
+			} else {
+				names, targetBranch = args[:1], args[1]
+				if _, ok := config.Workers[names[0]]; !ok {
+					return fmt.Errorf("worker %s not found in config", names[0])
+				}
+			}
+
+			var entries []mergequeue.Entry
+			for _, name := range names {
+				if states[name] == nil {
+					return fmt.Errorf("worker not found: %s", name)
+				}
+				branch := config.Workers[name].Branch
+				if branch == "" {
+					return fmt.Errorf("worker %s has no branch configured", name)
+				}
+				entries = append(entries, mergequeue.Entry{Worker: name, Branch: branch})
+			}
+			if len(entries) == 0 {
+				fmt.Println("No completed workers to merge.")
+				return nil
 			}
-			branch := workerConfig.Branch
 
 			// Warning for main/master
-			if targetBranch == "main" || targetBranch == "master" {
+			yes, _ := cmd.Flags().GetBool("yes")
+			if (targetBranch == "main" || targetBranch == "master") && !yes {
 				fmt.Printf("⚠️  Warning: Merging into %s branch\n", targetBranch)
 				fmt.Print("Continue? [y/N]: ")
 				var response string
@@ -65,44 +118,78 @@ Examples:
 				}
 			}
 
-			// Get project root
-			cwd, _ := os.Getwd()
-
-			// Checkout target branch
-			fmt.Printf("Checking out %s...\n", targetBranch)
-			if err := runGit(cwd, "checkout", targetBranch); err != nil {
-				return fmt.Errorf("failed to checkout %s: %w", targetBranch, err)
+			var gates []checks.Check
+			for _, c := range config.Defaults.Checks {
+				gates = append(gates, checks.Check{Name: c.Name, Run: c.Run})
 			}
-
-			// Pull latest
-			fmt.Println("Pulling latest changes...")
-			runGit(cwd, "pull") // Ignore error if no remote
-
-			// Merge worker branch
-			fmt.Printf("Merging %s into %s...\n", branch, targetBranch)
 			noFF, _ := cmd.Flags().GetBool("no-ff")
-			mergeArgs := []string{"merge", branch}
-			if noFF {
-				mergeArgs = append(mergeArgs, "--no-ff")
+			q := &mergequeue.Queue{
+				Repo:     filepath.Dir(configFile),
+				Target:   targetBranch,
+				Checks:   gates,
+				Env:      []string{"DEVHIVE_MERGE_TARGET=" + targetBranch},
+				NoFF:     noFF,
+				Log:      os.Stdout,
+				OnResult: func(r mergequeue.Result) { logMergeResult(targetBranch, r) },
+			}
+			if len(gates) == 0 {
+				fmt.Println("⚠️  No checks configured (defaults.checks): branches are merged without a test gate")
+			}
+
+			results, err := q.Run(entries)
+			merged, failed := 0, 0
+			for _, r := range results {
+				switch r.Status {
+				case mergequeue.StatusMerged:
+					merged++
+				case mergequeue.StatusConflict, mergequeue.StatusCheckFailed, mergequeue.StatusError:
+					failed++
+				}
 			}
-			if err := runGit(cwd, mergeArgs...); err != nil {
-				return fmt.Errorf("merge failed: %w\nResolve conflicts and commit manually", err)
+			fmt.Printf("\n%d merged, %d failed into %s\n", merged, failed, targetBranch)
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d branches not merged", failed, len(entries))
 			}
-
-			fmt.Printf("✅ Successfully merged %s into %s\n", branch, targetBranch)
-
-			// Log event
-			database.LogEvent("branch_merged", workerName, fmt.Sprintf(`{"from":"%s","to":"%s"}`, branch, targetBranch))
-
 			return nil
 		},
 	}
 
+	cmd.Flags().Bool("queue", false, "Merge completed workers (or the given workers) in order")
+	cmd.Flags().String("into", "", "Target branch for --queue (default: defaults.base_branch)")
 	cmd.Flags().Bool("no-ff", false, "Create merge commit even for fast-forward")
+	cmd.Flags().BoolP("yes", "y", false, "Do not ask before merging into main/master")
 
 	return cmd
 }
 
+// logMergeResult logs a merge queue step as a branch_merged or merge_failed event
+func logMergeResult(target string, r mergequeue.Result) {
+	data := map[string]interface{}{"from": r.Branch, "to": target}
+	switch r.Status {
+	case mergequeue.StatusMerged:
+		data["commit"] = r.Commit
+		b, _ := json.Marshal(data)
+		database.LogEvent("branch_merged", r.Worker, string(b))
+		return
+	case mergequeue.StatusConflict:
+		data["conflicts"] = r.Conflicts
+	case mergequeue.StatusCheckFailed:
+		data["check"] = r.Check.Name
+		data["exit_code"] = r.Check.ExitCode
+		data["output"] = checks.Tail(r.Check.Output, 20)
+	case mergequeue.StatusError:
+		data["error"] = r.Err.Error()
+	default:
+		return
+	}
+	data["reason"] = r.Status
+	b, _ := json.Marshal(data)
+	database.LogEvent("merge_failed", r.Worker, string(b))
+}
+
 // progressCmd updates worker progress
 func progressCmd() *cobra.Command {
 	return &cobra.Command{
@@ -111,11 +198,13 @@ func progressCmd() *cobra.Command {
 		Long: `Update the progress percentage of a worker (0-100).
 
 If defaults.auto_complete is true in .devhive.yaml, the worker will be
-automatically marked as completed when progress reaches 100%.
+automatically marked as completed when progress reaches 100%, provided its
+checks (defaults.checks and the worker's checks) pass. If a check fails,
+the worker is set to error instead (see 'devhive check').
 
 Examples:
   devhive progress frontend 50    # Set frontend to 50%
-  devhive progress backend 100    # Set backend to 100% (auto-complete if configured)`,
+  devhive progress backend 100    # Set backend to 100% (auto-complete if configured and checks pass)`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workerName := args[0]
@@ -132,14 +221,19 @@ Examples:
 
 			// Auto-complete if progress is 100% and auto_complete is enabled
 			if progress == 100 {
-				configFile, _ := FindComposeFile()
+				configFile, _ := FindProjectComposeFile()
 				if configFile != "" {
 					config, err := LoadComposeFile(configFile)
 					if err == nil && config.Defaults.AutoComplete {
-						if err := database.UpdateWorkerStatus(workerName, "completed", nil); err != nil {
+						completed, err := completeWorker(config, filepath.Dir(configFile), workerName, os.Stdout)
+						switch {
+						case err != nil:
 							fmt.Printf("⚠ Failed to auto-complete: %v\n", err)
-						} else {
+						case completed:
 							fmt.Printf("✅ %s auto-completed\n", workerName)
+						default:
+							cmd.SilenceUsage = true
+							return fmt.Errorf("%s not completed: checks failed (status set to error)", workerName)
 						}
 					}
 				}
@@ -400,9 +494,17 @@ Examples:
 	return cmd
 }
 
+// statusReport is the --json/--format output of 'devhive status'
+type statusReport struct {
+	Workers  []db.Worker    `json:"workers"`
+	Counts   map[string]int `json:"counts"`
+	Total    int            `json:"total"`
+	Progress int            `json:"progress"`
+}
+
 // statusCmd shows overall project status
 func statusCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show overall project status",
 		Long: `Show a summary of all workers, their progress, and status.
@@ -410,23 +512,40 @@ func statusCmd() *cobra.Command {
 This provides a quick overview of:
   - Worker count by status
   - Overall progress
-  - Recent activity`,
+  - Observed git state (commits, changes, last commit)
+
+Use --json or --format for scripts, e.g.:
+  devhive status --format '{{.Progress}}'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workers, err := database.GetAllWorkers()
 			if err != nil {
 				return err
 			}
 
-			if len(workers) == 0 {
+			f, err := getOutputFormatter(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(workers) == 0 && f == nil {
 				fmt.Println("No workers registered.")
 				return nil
 			}
 
 			// Load config to get branch info
 			var config *ComposeConfig
-			configFile, _ := FindComposeFile()
+			projectRoot := db.FindProjectRoot()
+			configFile, _ := FindProjectComposeFile()
 			if configFile != "" {
 				config, _ = LoadComposeFile(configFile)
+				projectRoot = filepath.Dir(configFile)
+			}
+			cwd, _ := os.Getwd()
+
+			// Refresh observed git state (agents may forget to report progress)
+			refreshGitStates(workers, config, projectRoot)
+			if refreshed, err := database.GetAllWorkers(); err == nil {
+				workers = refreshed
 			}
 
 			// Count by status
@@ -438,6 +557,26 @@ This provides a quick overview of:
 				"error":     0,
 			}
 			totalProgress := 0
+			for _, w := range workers {
+				counts[w.Status]++
+				totalProgress += w.Progress
+			}
+			avgProgress := 0
+			if len(workers) > 0 {
+				avgProgress = totalProgress / len(workers)
+			}
+
+			if f != nil {
+				if workers == nil {
+					workers = []db.Worker{}
+				}
+				return f.Write(os.Stdout, statusReport{
+					Workers:  workers,
+					Counts:   counts,
+					Total:    len(workers),
+					Progress: avgProgress,
+				})
+			}
 
 			fmt.Println("=== DevHive Status ===")
 			fmt.Println()
@@ -445,9 +584,6 @@ This provides a quick overview of:
 			// Worker details
 			fmt.Println("Workers:")
 			for _, w := range workers {
-				counts[w.Status]++
-				totalProgress += w.Progress
-
 				icon := statusIcon(w.Status)
 				bar := progressBar(w.Progress, 10)
 				fmt.Printf("  %-12s %s %s %3d%%", w.Name, icon, bar, w.Progress)
@@ -456,9 +592,18 @@ This provides a quick overview of:
 				if config != nil {
 					if workerConfig, ok := config.Workers[w.Name]; ok && workerConfig.Branch != "" {
 						fmt.Printf("  (%s)", workerConfig.Branch)
+						if violations := scopeViolations(cwd, config.GetBaseBranch(), workerConfig.Branch, workerConfig.Scope); len(violations) > 0 {
+							fmt.Printf("  ⚠ %d file(s) outside write scope", len(violations))
+						}
 					}
 				}
 				fmt.Println()
+				if w.Status == "pending" && w.PendingReason != "" {
+					fmt.Printf("  %-12s ⏸ %s\n", "", w.PendingReason)
+				}
+				if w.MonitoredAt != nil {
+					fmt.Printf("  %-12s git: %s\n", "", formatGitState(w))
+				}
 			}
 
 			// Summary
@@ -482,12 +627,15 @@ This provides a quick overview of:
 			}
 
 			// Average progress
-			avgProgress := totalProgress / len(workers)
 			fmt.Printf("\n  Overall Progress: %s %d%%\n", progressBar(avgProgress, 20), avgProgress)
 
 			return nil
 		},
 	}
+
+	addOutputFlags(cmd)
+
+	return cmd
 }
 
 // Helper functions
@@ -520,3 +668,62 @@ func progressBar(progress, width int) string {
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
 	return "[" + bar + "]"
 }
+
+// guardCmd checks staged files against a worker's write scope
+func guardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guard [worker]",
+		Short: "Check staged files against worker scope (for git hooks)",
+		Long: `Check that staged files are inside the worker's write scope.
+
+Installed automatically as a pre-commit hook in worktrees of workers
+that define a 'scope' block. Exits with an error if any staged file is
+excluded or outside the 'write' patterns.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workerName, err := getWorkerName(args, 0)
+			if err != nil {
+				return err
+			}
+
+			configFile, _ := cmd.Flags().GetString("file")
+			if configFile == "" {
+				configFile, err = FindComposeFile()
+				if err != nil {
+					return err
+				}
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+
+			workerConfig, ok := config.Workers[workerName]
+			if !ok || workerConfig.Scope.IsEmpty() {
+				return nil
+			}
+
+			cwd, _ := os.Getwd()
+			staged, err := stagedFiles(cwd)
+			if err != nil {
+				return fmt.Errorf("failed to list staged files: %w", err)
+			}
+
+			violations := workerConfig.Scope.OutOfScope(staged)
+			if len(violations) == 0 {
+				return nil
+			}
+
+			fmt.Fprintf(os.Stderr, "🚫 %s: %d file(s) outside write scope:\n", workerName, len(violations))
+			for _, v := range violations {
+				fmt.Fprintf(os.Stderr, "   %s\n", v)
+			}
+			cmd.SilenceUsage = true
+			return fmt.Errorf("commit rejected by devhive scope guard")
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "Compose file path")
+
+	return cmd
+}
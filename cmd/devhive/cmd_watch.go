@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iguchi/devhive/internal/conflicts"
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd detects stalled or struggling workers and notifies the PM
+func watchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Detect stalled workers and notify the PM",
+		Long: `Periodically check workers against observable facts and send a
+system message to 'pm' when a rule fires:
+
+  stalled          working, but no events, commits or session output
+  error_storm      many errors reported in a short window
+  permission_wait  session state stuck in waiting_permission
+
+Each finding is also logged as a worker_stalled event. Thresholds are
+configured under defaults.watch in .devhive.yaml.
+
+Worker branches are also checked for conflicts with each other and the
+base branch (see 'devhive conflicts'); the affected workers get a warning
+message. Disable with defaults.watch.conflicts: false.
+
+Examples:
+  devhive watch                 # Check every 60 seconds
+  devhive watch --interval 30
+  devhive watch --once          # Single check (e.g. from cron)`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFile, err := FindComposeFile()
+			if err != nil {
+				return err
+			}
+			config, err := LoadComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+
+			interval := config.Defaults.Watch.Interval
+			if cmd.Flags().Changed("interval") || interval <= 0 {
+				interval, _ = cmd.Flags().GetInt("interval")
+			}
+			once, _ := cmd.Flags().GetBool("once")
+			if interval < 1 {
+				return fmt.Errorf("interval must be at least 1 second")
+			}
+
+			projectRoot := filepath.Dir(configFile)
+			rules := config.Defaults.Watch.Rules()
+
+			if !once {
+				fmt.Printf("Watching workers every %ds (Ctrl+C to stop)\n", interval)
+			}
+			for {
+				findings, err := checkWorkers(config, projectRoot, rules, time.Now())
+				if err != nil {
+					return err
+				}
+				for _, f := range findings {
+					fmt.Printf("%s ⚠ %s: %s (%s)\n", time.Now().Format("15:04:05"), f.Worker, f.Message, f.Rule)
+				}
+				issues := len(findings)
+				if config.Defaults.Watch.Conflicts == nil || *config.Defaults.Watch.Conflicts {
+					// A failed prediction (e.g. a missing base branch) must not stop the watch
+					results, _, err := predictConflicts(config, projectRoot, nil)
+					var reported []conflicts.Conflict
+					if err == nil {
+						reported, err = notifyConflicts(results)
+					}
+					if err != nil {
+						fmt.Printf("%s ⚠ conflict prediction failed: %v\n", time.Now().Format("15:04:05"), err)
+					}
+					for _, c := range reported {
+						fmt.Printf("%s ⚠ %s ↔ %s: %d conflicting, %d overlapping file(s) (conflicts)\n",
+							time.Now().Format("15:04:05"), c.A.Name(), c.B.Name(), len(c.Files), len(c.Overlap))
+					}
+					issues += len(reported)
+				}
+				if once {
+					if issues == 0 {
+						fmt.Println("No issues detected")
+					}
+					return nil
+				}
+				time.Sleep(time.Duration(interval) * time.Second)
+			}
+		},
+	}
+
+	cmd.Flags().IntP("interval", "i", 60, "Check interval in seconds")
+	cmd.Flags().Bool("once", false, "Run a single check and exit")
+
+	return cmd
+}
+
+// Rules converts the configured thresholds into watch rules
+func (w ComposeWatch) Rules() watch.Rules {
+	rules := watch.DefaultRules()
+	minutes := func(configured int, def time.Duration) time.Duration {
+		switch {
+		case configured < 0:
+			return 0
+		case configured == 0:
+			return def
+		default:
+			return time.Duration(configured) * time.Minute
+		}
+	}
+	rules.Stall = minutes(w.StallMinutes, rules.Stall)
+	rules.ErrorWindow = minutes(w.ErrorWindowMinutes, rules.ErrorWindow)
+	rules.Permission = minutes(w.PermissionMinutes, rules.Permission)
+	if w.ErrorThreshold < 0 {
+		rules.ErrorThreshold = 0
+	} else if w.ErrorThreshold > 0 {
+		rules.ErrorThreshold = w.ErrorThreshold
+	}
+	return rules
+}
+
+// checkWorkers evaluates the rules for all workers, notifies the PM and
+// records a worker_stalled event for each finding
+func checkWorkers(config *ComposeConfig, projectRoot string, rules watch.Rules, now time.Time) ([]watch.Finding, error) {
+	workers, err := database.GetAllWorkers()
+	if err != nil {
+		return nil, err
+	}
+	// Commits count as activity
+	refreshGitStates(workers, config, projectRoot)
+	workers, err = database.GetAllWorkers()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []watch.Finding
+	for _, w := range workers {
+		facts, err := collectWatchFacts(w, projectRoot, rules, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range watch.Evaluate(facts, rules, now) {
+			subject := fmt.Sprintf("%s: %s", f.Worker, f.Rule)
+			content := fmt.Sprintf("Worker '%s' needs attention: %s.\nCheck with: devhive logs --session %s", f.Worker, f.Message, f.Worker)
+			if _, err := database.SendMessage("devhive", "pm", "system", subject, content); err != nil {
+				return nil, err
+			}
+			if err := database.ReportWorkerStalled(f.Worker, f.Rule, f.Message); err != nil {
+				return nil, err
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// collectWatchFacts gathers the observable facts about a worker
+func collectWatchFacts(w db.Worker, projectRoot string, rules watch.Rules, now time.Time) (watch.Facts, error) {
+	facts := watch.Facts{
+		Worker:       w.Name,
+		Status:       w.Status,
+		SessionState: w.SessionState,
+		LastAlerts:   map[string]time.Time{},
+	}
+
+	// Last activity: events (except our own alerts), commits and session output
+	lastEvent, err := database.GetLastWorkerEventTime(w.Name, "worker_stalled", "conflict_predicted")
+	if err != nil {
+		return facts, err
+	}
+	if lastEvent != nil {
+		facts.LastActivity = *lastEvent
+	}
+	if w.LastCommitAt != nil && w.LastCommitAt.After(facts.LastActivity) {
+		facts.LastActivity = *w.LastCommitAt
+	}
+	if info, err := os.Stat(sessionLogPath(projectRoot, w.Name)); err == nil && info.ModTime().After(facts.LastActivity) {
+		facts.LastActivity = info.ModTime()
+	}
+
+	if rules.ErrorWindow > 0 {
+		facts.RecentErrors, err = database.CountWorkerEventsSince(w.Name, "worker_error", now.Add(-rules.ErrorWindow))
+		if err != nil {
+			return facts, err
+		}
+	}
+
+	eventType := "worker_session_changed"
+	if events, err := database.GetRecentEvents(1, &eventType, &w.Name); err == nil && len(events) > 0 {
+		facts.SessionSince = events[0].CreatedAt
+	}
+
+	// Previous alerts, newest first
+	eventType = "worker_stalled"
+	alerts, err := database.GetRecentEvents(50, &eventType, &w.Name)
+	if err != nil {
+		return facts, err
+	}
+	for _, e := range alerts {
+		var data struct {
+			Rule string `json:"rule"`
+		}
+		if json.Unmarshal([]byte(e.Data), &data) != nil || data.Rule == "" {
+			continue
+		}
+		if _, seen := facts.LastAlerts[data.Rule]; !seen {
+			facts.LastAlerts[data.Rule] = e.CreatedAt
+		}
+	}
+
+	return facts, nil
+}
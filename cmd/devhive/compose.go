@@ -1,12 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/iguchi/devhive/internal/db"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,6 +20,13 @@ type ComposeConfig struct {
 	Defaults    ComposeDefaults          `yaml:"defaults"`
 	Workers     map[string]ComposeWorker `yaml:"workers"`
 	WorkerOrder []string                 `yaml:"-"` // Preserves yaml definition order
+
+	Notifications ComposeNotifications `yaml:"notifications"` // Outbound webhooks for worker events
+	Hooks         ComposeHooks         `yaml:"hooks"`         // Shell commands run on worker state transitions
+
+	Include        []string `yaml:"include"` // Compose files merged under this one (relative to it)
+	Files          []string `yaml:"-"`       // Files merged into this config, in merge order
+	ActiveProfiles []string `yaml:"-"`       // Profiles whose workers are started (DEVHIVE_PROFILES, --profile)
 }
 
 // ComposeRole represents a role definition in compose config
@@ -33,26 +42,216 @@ type ComposeRole struct {
 type ComposeDefaults struct {
 	CreateWorktree bool              `yaml:"create_worktree"`
 	BaseBranch     string            `yaml:"base_branch"`
-	Sprint         string            `yaml:"sprint"`           // Default sprint ID
-	PromptTemplate string            `yaml:"prompt_template"`  // Custom prompt template for AI tools
-	ToolArgs       map[string]string `yaml:"tool_args"`        // Default args per tool (e.g., claude: "--dangerously-skip-permissions")
-	AutoPrompt     bool              `yaml:"auto_prompt"`      // Auto-generate initial prompt for AI tools
-	GenerateEnvrc  *bool             `yaml:"generate_envrc"`   // Generate .envrc file (default: true)
-	DirenvAllow    bool              `yaml:"direnv_allow"`     // Auto-run direnv allow after creating worktree
-	AutoComplete   bool              `yaml:"auto_complete"`    // Auto-mark worker as completed when progress reaches 100%
+	Sprint         string            `yaml:"sprint"`          // Default sprint ID
+	PromptTemplate string            `yaml:"prompt_template"` // Custom prompt template for AI tools
+	ToolArgs       map[string]string `yaml:"tool_args"`       // Default args per tool (e.g., claude: "--dangerously-skip-permissions")
+	AutoPrompt     bool              `yaml:"auto_prompt"`     // Auto-generate initial prompt for AI tools
+	GenerateEnvrc  *bool             `yaml:"generate_envrc"`  // Generate .envrc file (default: true)
+	DirenvAllow    bool              `yaml:"direnv_allow"`    // Auto-run direnv allow after creating worktree
+	AutoComplete   bool              `yaml:"auto_complete"`   // Auto-mark worker as completed when progress reaches 100% and its checks pass
+	Watch          ComposeWatch      `yaml:"watch"`           // Stall/error detection rules for 'devhive watch'
+	MCP            bool              `yaml:"mcp"`             // Offer 'devhive mcp' tools to agents (context files, .mcp.json)
+	Checks         ComposeChecks     `yaml:"checks"`          // Verification commands gating worker completion and 'devhive merge'
+	Env            map[string]string `yaml:"env"`             // Environment variables for every worker
+	EnvFile        ComposeEnvFiles   `yaml:"env_file"`        // KEY=VALUE files for every worker (relative to the project root)
+	Ports          []string          `yaml:"ports"`           // Named ports assigned to every worker (DEVHIVE_PORT_<NAME>)
+	PortRange      string            `yaml:"port_range"`      // Ports are assigned from this range (default: 4000-4999)
+	CopyFiles      []string          `yaml:"copy_files"`      // Copied from the project root into new worktrees (e.g. .env)
+	LinkFiles      []string          `yaml:"link_files"`      // Symlinked from the project root into new worktrees (e.g. node_modules)
+	Setup          []string          `yaml:"setup"`           // Commands run in new worktrees after copy_files and link_files
+}
+
+// ComposeWatch configures the rules used by 'devhive watch'
+// Zero uses the default, a negative value disables the rule
+type ComposeWatch struct {
+	Interval           int `yaml:"interval"`             // Seconds between checks (default: 60)
+	StallMinutes       int `yaml:"stall_minutes"`        // No events/commits/session output (default: 30)
+	ErrorThreshold     int `yaml:"error_threshold"`      // Errors within the window (default: 5)
+	ErrorWindowMinutes int `yaml:"error_window_minutes"` // Window for error_threshold (default: 10)
+	PermissionMinutes  int `yaml:"permission_minutes"`   // Waiting for permission (default: 10)
+
+	Conflicts *bool `yaml:"conflicts"` // Predict conflicts between worker branches (default: true)
 }
 
 // ComposeWorker represents a worker definition in compose config
 type ComposeWorker struct {
-	Branch   string `yaml:"branch"`
-	Role     string `yaml:"role"`
-	Task     string `yaml:"task"`
-	Tool     string `yaml:"tool"`     // AI tool: claude, codex, gemini, generic (default: generic)
-	Command  string `yaml:"command"`  // Command to execute (default: tool name, generic: $SHELL)
-	Args     string `yaml:"args"`     // Arguments for the command
-	Prompt   string `yaml:"prompt"`   // Initial prompt to pass to AI tool
-	Worktree string `yaml:"worktree"` // Override worktree path
-	Disabled bool   `yaml:"disabled"` // Skip this worker
+	Branch   string       `yaml:"branch"`
+	Role     string       `yaml:"role"`
+	Task     string       `yaml:"task"`
+	Tool     string       `yaml:"tool"`     // AI tool: claude, codex, gemini, generic (default: generic)
+	Command  string       `yaml:"command"`  // Command to execute (default: tool name, generic: $SHELL)
+	Args     string       `yaml:"args"`     // Arguments for the command
+	Prompt   string       `yaml:"prompt"`   // Initial prompt to pass to AI tool
+	Worktree string       `yaml:"worktree"` // Override worktree path
+	Disabled bool         `yaml:"disabled"` // Skip this worker
+	Profiles []string     `yaml:"profiles"` // Only started when one of these profiles is active
+	Scope    ComposeScope `yaml:"scope"`    // File scope control (exclude/write)
+
+	Checks ComposeChecks `yaml:"checks"` // Checks on top of defaults.checks (same name replaces)
+
+	Env     map[string]string `yaml:"env"`      // Environment variables on top of defaults.env
+	EnvFile ComposeEnvFiles   `yaml:"env_file"` // KEY=VALUE files on top of defaults.env_file
+	Ports   []string          `yaml:"ports"`    // Named ports on top of defaults.ports (DEVHIVE_PORT_<NAME>)
+
+	CopyFiles []string `yaml:"copy_files"` // Files on top of defaults.copy_files
+	LinkFiles []string `yaml:"link_files"` // Files on top of defaults.link_files
+	Setup     []string `yaml:"setup"`      // Commands run after defaults.setup
+
+	DependsOn  ComposeDependencies `yaml:"depends_on"`  // Workers that must reach a condition first
+	BranchFrom string              `yaml:"branch_from"` // Start the branch from this worker's branch (or git ref) instead of HEAD
+}
+
+// ComposeScope restricts which files a worker can see and modify
+// Patterns use gitignore-style globs (e.g., "packages/web/**", "**/*.env")
+type ComposeScope struct {
+	Exclude []string `yaml:"exclude"` // Removed from the worktree via sparse-checkout
+	Write   []string `yaml:"write"`   // Writable paths (empty: everything not excluded)
+}
+
+// IsEmpty reports whether the scope has no restrictions
+func (s ComposeScope) IsEmpty() bool {
+	return len(s.Exclude) == 0 && len(s.Write) == 0
+}
+
+// ComposeDependency is a condition on another worker that must hold before start
+type ComposeDependency struct {
+	Worker    string
+	Condition string // completed (default), started, progress>=N
+}
+
+// ComposeDependencies is the depends_on list. Accepts either a list of
+// worker names or a mapping of worker name to condition:
+//
+//	depends_on: [backend]
+//	depends_on:
+//	  backend:
+//	    condition: progress>=50
+type ComposeDependencies []ComposeDependency
+
+// UnmarshalYAML accepts the list and mapping forms of depends_on
+func (d *ComposeDependencies) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: depends_on entries must be worker names", item.Line)
+			}
+			*d = append(*d, ComposeDependency{Worker: item.Value, Condition: "completed"})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			dep := ComposeDependency{Worker: node.Content[i].Value, Condition: "completed"}
+			value := node.Content[i+1]
+			switch value.Kind {
+			case yaml.ScalarNode:
+				// backend: progress>=50
+				if value.Value != "" {
+					dep.Condition = value.Value
+				}
+			case yaml.MappingNode:
+				var opts struct {
+					Condition string `yaml:"condition"`
+				}
+				if err := value.Decode(&opts); err != nil {
+					return err
+				}
+				if opts.Condition != "" {
+					dep.Condition = opts.Condition
+				}
+			default:
+				return fmt.Errorf("line %d: invalid depends_on entry for %s", value.Line, dep.Worker)
+			}
+			*d = append(*d, dep)
+		}
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list or mapping", node.Line)
+	}
+	return nil
+}
+
+// ComposeCheck is a named verification command (run with the shell)
+type ComposeCheck struct {
+	Name string
+	Run  string
+}
+
+// ComposeChecks are checks in definition order, written as a mapping:
+//
+//	checks:
+//	  lint: golangci-lint run
+//	  test: go test ./...
+type ComposeChecks []ComposeCheck
+
+// UnmarshalYAML reads the checks mapping, keeping its order
+func (c *ComposeChecks) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: checks must be a mapping of name to command", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode || strings.TrimSpace(value.Value) == "" {
+			return fmt.Errorf("line %d: check %s must be a command", value.Line, name.Value)
+		}
+		*c = append(*c, ComposeCheck{Name: name.Value, Run: value.Value})
+	}
+	return nil
+}
+
+// ComposeHooks are shell commands run when workers change state, with the
+// worker's env and a JSON payload on stdin (see internal/lifecycle)
+type ComposeHooks struct {
+	OnUp       string `yaml:"on_up"`       // devhive up started the worker
+	OnComplete string `yaml:"on_complete"` // Status became completed
+	OnError    string `yaml:"on_error"`    // An error was reported or status became error
+	OnBlocked  string `yaml:"on_blocked"`  // Status became blocked
+	OnMerge    string `yaml:"on_merge"`    // The worker's branch was merged
+}
+
+// ComposeNotifications configures where worker events are sent
+type ComposeNotifications struct {
+	Webhooks []ComposeWebhook `yaml:"webhooks"`
+}
+
+// ComposeWebhook is an endpoint that receives matching events as JSON
+type ComposeWebhook struct {
+	URL    string             `yaml:"url"`
+	Format string             `yaml:"format"` // generic (default) or slack
+	Events []ComposeEventRule `yaml:"events"` // Empty: every event
+}
+
+// ComposeEventRule selects events by type and optionally by their data.
+// Accepts an event type or a mapping:
+//
+//	events:
+//	  - worker_error
+//	  - type: message_sent
+//	    data: {to: pm, type: help}
+type ComposeEventRule struct {
+	Type string
+	Data map[string]string
+}
+
+// UnmarshalYAML accepts the string and mapping forms of an event rule
+func (r *ComposeEventRule) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		r.Type = node.Value
+	case yaml.MappingNode:
+		var rule struct {
+			Type string            `yaml:"type"`
+			Data map[string]string `yaml:"data"`
+		}
+		if err := node.Decode(&rule); err != nil {
+			return err
+		}
+		r.Type, r.Data = rule.Type, rule.Data
+	default:
+		return fmt.Errorf("line %d: events entries must be an event type or a mapping with type and data", node.Line)
+	}
+	if r.Type == "" {
+		return fmt.Errorf("line %d: event type is required", node.Line)
+	}
+	return nil
 }
 
 // SupportedTools lists the supported AI tools
@@ -83,20 +282,50 @@ func FindComposeFile() (string, error) {
 	return "", fmt.Errorf("compose file not found (tried: %s)", strings.Join(DefaultComposeFiles, ", "))
 }
 
-// LoadComposeFile loads and parses a compose configuration file
+// FindProjectComposeFile finds the project's compose file the same way the
+// database is found: searching parent directories, and from a worktree under
+// .devhive/worktrees/ the main checkout. Commands run by agents use it.
+func FindProjectComposeFile() (string, error) {
+	if root := db.FindProjectRoot(); root != "" {
+		for _, filename := range DefaultComposeFiles {
+			path := filepath.Join(root, filename)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("compose file not found (tried: %s)", strings.Join(DefaultComposeFiles, ", "))
+}
+
+// LoadComposeFile loads and parses a compose configuration file, merged
+// with the files it includes and its override file
 func LoadComposeFile(path string) (*ComposeConfig, error) {
-	data, err := os.ReadFile(path)
+	doc, err := loadComposeDocument(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read compose file: %w", err)
+		return nil, err
 	}
 
 	var config ComposeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := doc.root.Decode(&config); err != nil {
 		return nil, fmt.Errorf("failed to parse compose file: %w", err)
 	}
+	config.Files = doc.files
 
 	// Extract worker order from yaml using yaml.Node
-	config.WorkerOrder = extractWorkerOrder(data)
+	config.WorkerOrder = extractWorkerOrder(doc.root)
+
+	for _, p := range strings.Split(os.Getenv("DEVHIVE_PROFILES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			config.ActiveProfiles = append(config.ActiveProfiles, p)
+		}
+	}
+
+	if err := config.validateDependencies(); err != nil {
+		return nil, err
+	}
+	if err := config.validateNotifications(); err != nil {
+		return nil, err
+	}
 
 	// Set defaults
 	if config.Version == "" {
@@ -111,19 +340,10 @@ func LoadComposeFile(path string) (*ComposeConfig, error) {
 	return &config, nil
 }
 
-// extractWorkerOrder parses yaml to extract worker keys in definition order
-func extractWorkerOrder(data []byte) []string {
-	var root yaml.Node
-	if err := yaml.Unmarshal(data, &root); err != nil {
-		return nil
-	}
-
-	// root.Content[0] is the document node
-	if len(root.Content) == 0 {
-		return nil
-	}
-	doc := root.Content[0]
-
+// extractWorkerOrder extracts worker keys in definition order from the
+// (merged) top-level mapping. Workers first defined in a later file come
+// after those of earlier files.
+func extractWorkerOrder(doc *yaml.Node) []string {
 	// Find "workers" key in the document
 	for i := 0; i < len(doc.Content)-1; i += 2 {
 		keyNode := doc.Content[i]
@@ -169,9 +389,9 @@ func (c *ComposeConfig) GetEffectiveWorkers(workerNames []string) map[string]Com
 	result := make(map[string]ComposeWorker)
 
 	if len(workerNames) == 0 {
-		// Return all non-disabled workers
+		// Return all non-disabled workers of the active profiles
 		for name, worker := range c.Workers {
-			if !worker.Disabled {
+			if !worker.Disabled && c.profileActive(worker) {
 				result[name] = worker
 			}
 		}
@@ -187,11 +407,60 @@ func (c *ComposeConfig) GetEffectiveWorkers(workerNames []string) map[string]Com
 	return result
 }
 
+// profileActive reports whether a worker is started without naming it:
+// workers without profiles always are, others when one of their profiles
+// is active
+func (c *ComposeConfig) profileActive(worker ComposeWorker) bool {
+	if len(worker.Profiles) == 0 {
+		return true
+	}
+	for _, p := range worker.Profiles {
+		if containsString(c.ActiveProfiles, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProfiles returns the profiles used by workers, sorted
+func (c *ComposeConfig) GetProfiles() []string {
+	var profiles []string
+	for _, worker := range c.Workers {
+		for _, p := range worker.Profiles {
+			if !containsString(profiles, p) {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// SetActiveProfiles selects the profiles whose workers are started,
+// replacing DEVHIVE_PROFILES. Unknown profiles are an error.
+func (c *ComposeConfig) SetActiveProfiles(profiles []string) error {
+	defined := c.GetProfiles()
+	for _, p := range profiles {
+		if !containsString(defined, p) {
+			if len(defined) == 0 {
+				return fmt.Errorf("unknown profile: %s (no worker has profiles)", p)
+			}
+			return fmt.Errorf("unknown profile: %s (defined: %s)", p, strings.Join(defined, ", "))
+		}
+	}
+	c.ActiveProfiles = profiles
+	return nil
+}
+
 // GetOrderedWorkerNames returns worker names in yaml definition order
 // If specific names are provided, returns them in the order they appear in yaml
 // Falls back to alphabetical order if WorkerOrder is not available
 func (c *ComposeConfig) GetOrderedWorkerNames(filterNames []string) []string {
-	workers := c.GetEffectiveWorkers(filterNames)
+	return c.orderWorkerNames(c.GetEffectiveWorkers(filterNames))
+}
+
+// orderWorkerNames returns the names of workers in yaml definition order
+func (c *ComposeConfig) orderWorkerNames(workers map[string]ComposeWorker) []string {
 	if len(workers) == 0 {
 		return nil
 	}
@@ -253,13 +522,53 @@ func (c *ComposeConfig) ResolveRole(roleName string) string {
 	return roleName
 }
 
-// GenerateSprintID generates a sprint ID based on config or timestamp
-func (c *ComposeConfig) GenerateSprintID() string {
-	if c.Defaults.Sprint != "" {
-		return c.Defaults.Sprint
+// HasRole reports whether a worker has the given role
+// Matches the role as written, its resolved name, or a role file's base name
+func (c *ComposeConfig) HasRole(worker ComposeWorker, role string) bool {
+	if worker.Role == "" || role == "" {
+		return false
+	}
+	resolved := c.ResolveRole(worker.Role)
+	return worker.Role == role || resolved == role ||
+		strings.TrimSuffix(filepath.Base(resolved), ".md") == role
+}
+
+// GetWorkerChecks returns the checks a worker must pass to complete:
+// defaults.checks followed by its own. A worker check with the name of a
+// default replaces it in place.
+func (c *ComposeConfig) GetWorkerChecks(name string) ComposeChecks {
+	own := map[string]ComposeCheck{}
+	for _, check := range c.Workers[name].Checks {
+		own[check.Name] = check
+	}
+	var result ComposeChecks
+	for _, check := range c.Defaults.Checks {
+		if o, ok := own[check.Name]; ok {
+			check = o
+			delete(own, check.Name)
+		}
+		result = append(result, check)
+	}
+	for _, check := range c.Workers[name].Checks {
+		if _, ok := own[check.Name]; ok {
+			result = append(result, check)
+		}
+	}
+	return result
+}
+
+// GetBaseBranch returns the base branch, defaulting to "main"
+func (c *ComposeConfig) GetBaseBranch() string {
+	if c.Defaults.BaseBranch != "" {
+		return c.Defaults.BaseBranch
 	}
-	// Use a simple incrementing name
-	return "sprint"
+	return "main"
+}
+
+// GenerateSprintID returns the preferred sprint ID from config
+// Empty means a date-based ID is generated (see db.NewSprintID)
+func (c *ComposeConfig) GenerateSprintID() string {
+	return c.Defaults.Sprint
 }
 
 // GetTaskContent returns the task content for a worker
@@ -476,9 +785,14 @@ func GetDefaultPromptTemplate() string {
 - ` + "`devhive request unblock \"理由\"`" + ` - ブロック解除
 - ` + "`devhive report \"進捗報告\"`" + ` - 進捗報告
 - ` + "`devhive msgs`" + ` - メッセージ確認
+- ` + "`devhive reply <id> \"回答\"`" + ` - メッセージに返信
+- ` + "`devhive send <worker|@role> \"内容\"`" + ` - 他のワーカーに連絡
 `
 }
 
+// GeneratedWorktreeFiles are files devhive writes into worktrees
+var GeneratedWorktreeFiles = []string{".envrc", "CONTEXT.md", "CLAUDE.md", "AGENTS.md", "GEMINI.md", ".mcp.json"}
+
 // GenerateContextFiles generates context files for a worker in the worktree
 func GenerateContextFiles(worktreePath, workerName string, worker ComposeWorker, config *ComposeConfig, projectRoot string) error {
 	// Always generate CONTEXT.md (generic)
@@ -497,6 +811,11 @@ func GenerateContextFiles(worktreePath, workerName string, worker ComposeWorker,
 		if err := os.WriteFile(claudePath, []byte(claudeContent), 0644); err != nil {
 			return fmt.Errorf("failed to write CLAUDE.md: %w", err)
 		}
+		if config.Defaults.MCP {
+			if err := writeMCPConfig(worktreePath, workerName); err != nil {
+				return fmt.Errorf("failed to write .mcp.json: %w", err)
+			}
+		}
 	case "codex":
 		codexContent := generateCodexContent(workerName, worker, config, projectRoot)
 		codexPath := filepath.Join(worktreePath, "AGENTS.md")
@@ -555,9 +874,31 @@ func generateContextContent(workerName string, worker ComposeWorker, config *Com
 	}
 	sb.WriteString("\n")
 
+	// Scope
+	if !worker.Scope.IsEmpty() {
+		sb.WriteString("## Scope\n\n")
+		if len(worker.Scope.Write) > 0 {
+			sb.WriteString("編集可能なパス（これ以外へのコミットは拒否されます）:\n\n")
+			for _, p := range worker.Scope.Write {
+				sb.WriteString(fmt.Sprintf("- `%s`\n", p))
+			}
+			sb.WriteString("\n")
+		}
+		if len(worker.Scope.Exclude) > 0 {
+			sb.WriteString("除外されたパス（worktreeに存在しません）:\n\n")
+			for _, p := range worker.Scope.Exclude {
+				sb.WriteString(fmt.Sprintf("- `%s`\n", p))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	// Ports
+	sb.WriteString(portsSection(workerName))
+
 	// Communication
 	sb.WriteString("## Communication\n\n")
-	sb.WriteString("PMとの通信には以下のコマンドを使用:\n\n")
+	sb.WriteString("PMや他のワーカーとの通信には以下のコマンドを使用:\n\n")
 	sb.WriteString("```bash\n")
 	sb.WriteString("devhive request help \"質問内容\"     # ヘルプ要求\n")
 	sb.WriteString("devhive request review \"内容\"      # レビュー依頼\n")
@@ -565,11 +906,64 @@ func generateContextContent(workerName string, worker ComposeWorker, config *Com
 	sb.WriteString("devhive report \"進捗報告\"          # 進捗報告\n")
 	sb.WriteString("devhive progress 50                 # 進捗更新 (0-100)\n")
 	sb.WriteString("devhive msgs                        # メッセージ確認\n")
+	sb.WriteString("devhive reply <id> \"回答\"           # メッセージに返信（同じスレッド）\n")
+	sb.WriteString("devhive send <worker|@role> \"内容\"  # 他のワーカーに連絡\n")
 	sb.WriteString("```\n")
 
+	if config.Defaults.MCP {
+		sb.WriteString("\n")
+		sb.WriteString(mcpToolsSection())
+	}
+
 	return sb.String()
 }
 
+// mcpToolsSection describes the tools of 'devhive mcp' for context files
+func mcpToolsSection() string {
+	return `## MCP Tools
+
+MCPサーバー ` + "`devhive`" + `（` + "`devhive mcp`" + `）が利用できます。進捗報告や連絡にはCLIよりこちらを優先してください。
+
+| ツール | 用途 |
+|--------|------|
+| ` + "`report_progress`" + ` | 進捗（0-100）と現在の作業を報告 |
+| ` + "`request_help`" + ` | PMへの依頼（help / review / unblock / clarify） |
+| ` + "`send_report`" + ` | PMに進捗報告を送信 |
+| ` + "`read_messages`" + ` | 自分宛てのメッセージを確認（既読になる） |
+| ` + "`reply_message`" + ` | メッセージに返信（同じスレッド） |
+| ` + "`get_task`" + ` | タスク・ロール・ブランチ・状態を確認 |
+| ` + "`list_peers`" + ` | 同じスプリントの他のワーカーを確認 |
+`
+}
+
+// writeMCPConfig registers 'devhive mcp' in the worktree's .mcp.json
+// (read by Claude Code), keeping any servers the repository already defines
+func writeMCPConfig(worktreePath, workerName string) error {
+	path := filepath.Join(worktreePath, ".mcp.json")
+
+	config := map[string]interface{}{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("invalid existing .mcp.json: %w", err)
+		}
+	}
+	servers, _ := config["mcpServers"].(map[string]interface{})
+	if servers == nil {
+		servers = map[string]interface{}{}
+	}
+	servers["devhive"] = map[string]interface{}{
+		"command": "devhive",
+		"args":    []string{"mcp", "--worker", workerName},
+	}
+	config["mcpServers"] = servers
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
 // generateClaudeContent creates Claude-specific CLAUDE.md
 func generateClaudeContent(workerName string, worker ComposeWorker, config *ComposeConfig, projectRoot string) string {
 	roleContent := getRoleContent(worker.Role, config, projectRoot)
@@ -592,6 +986,10 @@ func generateClaudeContent(workerName string, worker ComposeWorker, config *Comp
 	var sb strings.Builder
 	sb.WriteString("# Claude Code Instructions\n\n")
 	sb.WriteString(RenderPromptTemplate(template, vars))
+	if config.Defaults.MCP {
+		sb.WriteString("\n")
+		sb.WriteString(mcpToolsSection())
+	}
 
 	return sb.String()
 }
@@ -618,6 +1016,10 @@ func generateCodexContent(workerName string, worker ComposeWorker, config *Compo
 	var sb strings.Builder
 	sb.WriteString("# Codex Agent Instructions\n\n")
 	sb.WriteString(RenderPromptTemplate(template, vars))
+	if config.Defaults.MCP {
+		sb.WriteString("\n")
+		sb.WriteString(mcpToolsSection())
+	}
 
 	return sb.String()
 }
@@ -644,6 +1046,10 @@ func generateGeminiContent(workerName string, worker ComposeWorker, config *Comp
 	var sb strings.Builder
 	sb.WriteString("# Gemini Instructions\n\n")
 	sb.WriteString(RenderPromptTemplate(template, vars))
+	if config.Defaults.MCP {
+		sb.WriteString("\n")
+		sb.WriteString(mcpToolsSection())
+	}
 
 	return sb.String()
 }
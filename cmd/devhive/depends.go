@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// parseDependencyCondition parses a depends_on condition
+// Returns the kind (completed, started, progress) and the progress threshold
+func parseDependencyCondition(cond string) (string, int, error) {
+	cond = strings.ReplaceAll(strings.TrimSpace(cond), " ", "")
+	switch cond {
+	case "", "completed":
+		return "completed", 0, nil
+	case "started":
+		return "started", 0, nil
+	}
+	if strings.HasPrefix(cond, "progress>=") {
+		n, err := strconv.Atoi(strings.TrimPrefix(cond, "progress>="))
+		if err != nil || n < 0 || n > 100 {
+			return "", 0, fmt.Errorf("invalid progress condition: %s (expected progress>=0..100)", cond)
+		}
+		return "progress", n, nil
+	}
+	return "", 0, fmt.Errorf("unknown condition: %s (valid: completed, started, progress>=N)", cond)
+}
+
+// validateDependencies checks that depends_on refers to known workers,
+// uses valid conditions and has no cycles
+func (c *ComposeConfig) validateDependencies() error {
+	for name, worker := range c.Workers {
+		for _, dep := range worker.DependsOn {
+			if dep.Worker == name {
+				return fmt.Errorf("worker %s: cannot depend on itself", name)
+			}
+			if _, ok := c.Workers[dep.Worker]; !ok {
+				return fmt.Errorf("worker %s: depends_on unknown worker: %s", name, dep.Worker)
+			}
+			if _, _, err := parseDependencyCondition(dep.Condition); err != nil {
+				return fmt.Errorf("worker %s: depends_on %s: %w", name, dep.Worker, err)
+			}
+		}
+	}
+
+	// Detect cycles with a depth-first search
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range c.Workers[name].DependsOn {
+			if err := visit(dep.Worker, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, name := range c.orderWorkerNames(c.Workers) {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStartupOrder returns worker names so that dependencies come first
+// Otherwise yaml definition order is kept
+func (c *ComposeConfig) GetStartupOrder(filterNames []string) []string {
+	names := c.GetOrderedWorkerNames(filterNames)
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	var ordered []string
+	added := make(map[string]bool)
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		for _, dep := range c.Workers[name].DependsOn {
+			if selected[dep.Worker] {
+				add(dep.Worker)
+			}
+		}
+		ordered = append(ordered, name)
+	}
+	for _, name := range names {
+		add(name)
+	}
+	return ordered
+}
+
+// Check reports whether the dependency's condition holds for the given worker state
+// Returns a short description of what is awaited when it does not
+func (d ComposeDependency) Check(w *db.Worker) (bool, string) {
+	kind, threshold, err := parseDependencyCondition(d.Condition)
+	if err != nil {
+		return false, err.Error()
+	}
+	label := kind
+	if kind == "progress" {
+		label = fmt.Sprintf("progress>=%d", threshold)
+	}
+	if w == nil {
+		return false, fmt.Sprintf("%s (%s, not registered)", d.Worker, label)
+	}
+
+	switch kind {
+	case "started":
+		if w.Status != "pending" {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s (%s)", d.Worker, label)
+	case "progress":
+		if w.Status == "completed" || w.Progress >= threshold {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s (progress>=%d, now %d%%)", d.Worker, threshold, w.Progress)
+	default:
+		if w.Status == "completed" {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s (%s)", d.Worker, label)
+	}
+}
+
+// unmetDependencies returns why a worker must wait, or "" if all dependencies hold
+func unmetDependencies(worker ComposeWorker, states map[string]*db.Worker) string {
+	var waiting []string
+	for _, dep := range worker.DependsOn {
+		if ok, desc := dep.Check(states[dep.Worker]); !ok {
+			waiting = append(waiting, desc)
+		}
+	}
+	if len(waiting) == 0 {
+		return ""
+	}
+	return "waiting for " + strings.Join(waiting, ", ")
+}
+
+// workerStates returns the current state of all registered workers by name
+func workerStates() (map[string]*db.Worker, error) {
+	workers, err := database.GetAllWorkers()
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*db.Worker)
+	for i := range workers {
+		states[workers[i].Name] = &workers[i]
+	}
+	return states, nil
+}
+
+// resolveBranchFrom returns the start point for a new worker branch
+// branch_from may name another worker (its branch is used) or any git ref
+func (c *ComposeConfig) resolveBranchFrom(worker ComposeWorker) string {
+	if worker.BranchFrom == "" {
+		return ""
+	}
+	if from, ok := c.Workers[worker.BranchFrom]; ok && from.Branch != "" {
+		return from.Branch
+	}
+	return worker.BranchFrom
+}
+
+// registerHeldWorker registers a worker as pending with the reason it is held back
+func registerHeldWorker(name, sprintID, reason string, states map[string]*db.Worker) error {
+	if err := database.RegisterWorker(name, sprintID); err != nil {
+		return err
+	}
+	if err := database.SetWorkerPendingReason(name, reason); err != nil {
+		return err
+	}
+	refreshWorkerState(name, states)
+	return nil
+}
+
+// refreshWorkerState re-reads a worker so later dependency checks see its new state
+func refreshWorkerState(name string, states map[string]*db.Worker) {
+	if w, err := database.GetWorker(name); err == nil && w != nil {
+		states[name] = w
+	}
+}
+
+// readyWorkers filters out workers whose dependencies are not met yet,
+// recording the reason on registered pending workers
+func readyWorkers(config *ComposeConfig, names []string) ([]string, error) {
+	states, err := workerStates()
+	if err != nil {
+		return nil, err
+	}
+
+	var ready []string
+	for _, name := range names {
+		state := states[name]
+		if state != nil && state.Status != "pending" {
+			ready = append(ready, name)
+			continue
+		}
+		reason := unmetDependencies(config.Workers[name], states)
+		if reason == "" {
+			ready = append(ready, name)
+			continue
+		}
+		fmt.Printf("⏸ Holding %s: %s\n", name, reason)
+		if state != nil {
+			database.SetWorkerPendingReason(name, reason)
+		}
+	}
+	return ready, nil
+}
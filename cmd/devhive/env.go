@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envName matches a valid environment variable name
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ComposeEnvFiles is env_file: a single file or a list of files
+type ComposeEnvFiles []string
+
+// UnmarshalYAML accepts a file path or a list of file paths
+func (f *ComposeEnvFiles) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "" {
+			*f = ComposeEnvFiles{node.Value}
+		}
+	case yaml.SequenceNode:
+		var files []string
+		if err := node.Decode(&files); err != nil {
+			return err
+		}
+		*f = files
+	default:
+		return fmt.Errorf("line %d: env_file must be a file or a list of files", node.Line)
+	}
+	return nil
+}
+
+// interpolate expands ${VAR} and ${VAR:-default} in s (the default is used
+// when VAR is unset or empty). $${ is a literal ${.
+func interpolate(s string, lookup func(string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			// $${ -> ${
+			sb.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.Index(s[i:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed ${ in %q", s)
+		}
+		expr := s[i+2 : i+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !envName.MatchString(name) {
+			return "", fmt.Errorf("invalid variable ${%s} (use ${VAR} or ${VAR:-default})", expr)
+		}
+		value, _ := lookup(name)
+		if value == "" && hasDefault {
+			value = def
+		}
+		sb.WriteString(s[:i] + value)
+		s = s[i+end+1:]
+	}
+}
+
+// interpolateNode expands variables in every scalar value (not keys) of a
+// YAML tree from the environment
+func interpolateNode(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := interpolateNode(c); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := interpolateNode(n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "${") {
+			return nil
+		}
+		value, err := interpolate(n.Value, os.LookupEnv)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if value != n.Value && n.Style == 0 {
+			// Re-resolve plain scalars, so port: ${PORT:-3000} is still an int
+			n.Tag = ""
+		}
+		n.Value = value
+	}
+	return nil
+}
+
+// readEnvFile reads KEY=VALUE lines. Blank lines, # comments and an
+// "export " prefix are ignored, and quotes around values are removed.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env_file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envName.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			value = value[1 : n-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
+
+// GetWorkerEnv returns a worker's environment as KEY=VALUE, sorted by key.
+// Later sources win: defaults.env_file, defaults.env, the worker's env_file,
+// the worker's env. DEVHIVE_WORKER is always set to the worker's name, and
+// DEVHIVE_PORT_<NAME> to each port assigned to it.
+// env_file paths are relative to projectRoot.
+func (c *ComposeConfig) GetWorkerEnv(name, projectRoot string) ([]string, error) {
+	worker := c.Workers[name]
+	layers := []struct {
+		files ComposeEnvFiles
+		vars  map[string]string
+	}{
+		{c.Defaults.EnvFile, c.Defaults.Env},
+		{worker.EnvFile, worker.Env},
+	}
+
+	env := make(map[string]string)
+	for _, layer := range layers {
+		for _, file := range layer.files {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(projectRoot, file)
+			}
+			vars, err := readEnvFile(file)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range vars {
+				env[k] = v
+			}
+		}
+		for k, v := range layer.vars {
+			env[k] = v
+		}
+	}
+	if database != nil {
+		ports, err := database.GetWorkerPorts(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ports {
+			env[portEnvName(p.Name)] = strconv.Itoa(p.Port)
+		}
+	}
+	env["DEVHIVE_WORKER"] = name
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, k+"="+env[k])
+	}
+	return result, nil
+}
+
+// exportEnv returns shell export statements for KEY=VALUE pairs
+func exportEnv(env []string) []string {
+	exports := make([]string, 0, len(env))
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		exports = append(exports, "export "+key+"="+shellWord(value))
+	}
+	return exports
+}
+
+// plainShellWord matches values that need no quoting in a shell
+var plainShellWord = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]+$`)
+
+// shellWord quotes s for a POSIX shell unless it is safe as is
+func shellWord(s string) string {
+	if plainShellWord.MatchString(s) {
+		return s
+	}
+	return shellQuote(s)
+}
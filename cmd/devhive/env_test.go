@@ -0,0 +1,182 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"HOST": "db.local", "PORT": "5432", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"${HOST}:${PORT}", "db.local:5432", ""},
+		{"postgres://${HOST}/app", "postgres://db.local/app", ""},
+		{"${UNSET}", "", ""},
+		// The default is used when the variable is unset or empty
+		{"${UNSET:-3000}", "3000", ""},
+		{"${EMPTY:-3000}", "3000", ""},
+		{"${PORT:-3000}", "5432", ""},
+		{"${UNSET:-}", "", ""},
+		{"${UNSET:-a:-b}", "a:-b", ""},
+		// $${ is a literal ${
+		{"$${HOST}", "${HOST}", ""},
+		{"cost: $$${PORT}", "cost: $${PORT}", ""},
+		{"echo $HOST", "echo $HOST", ""},
+		{"${HOST", "", "unclosed ${"},
+		{"ok ${PORT} ${HOST", "", "unclosed ${"},
+		{"${}", "", "invalid variable ${}"},
+		{"${1ST}", "", "invalid variable ${1ST}"},
+		{"${HOST-x}", "", "invalid variable ${HOST-x}"},
+	}
+	for _, tt := range tests {
+		got, err := interpolate(tt.in, lookup)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("interpolate(%q): expected error %q, got %q, %v", tt.in, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("interpolate(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestInterpolateNodeRetagsPlainScalars(t *testing.T) {
+	t.Setenv("DEVHIVE_TEST_FLAG", "true")
+
+	var doc yaml.Node
+	src := `port: ${DEVHIVE_TEST_UNSET:-3000}
+flag: ${DEVHIVE_TEST_FLAG}
+quoted: "${DEVHIVE_TEST_UNSET:-3000}"
+literal: '3000'
+${DEVHIVE_TEST_FLAG}: key
+`
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := interpolateNode(&doc); err != nil {
+		t.Fatalf("interpolateNode failed: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := doc.Decode(&got); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"port":                 3000,   // plain: resolved again as an int
+		"flag":                 true,   // plain: resolved again as a bool
+		"quoted":               "3000", // quoted: stays a string
+		"literal":              "3000",
+		"${DEVHIVE_TEST_FLAG}": "key", // keys are not expanded
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if err := yaml.Unmarshal([]byte("a: 1\nb: ${OOPS\n"), &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := interpolateNode(&doc); err == nil || !strings.HasPrefix(err.Error(), "line 2: unclosed ${") {
+		t.Errorf("Expected an error with the line, got %v", err)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "comments and blank lines",
+			content: `# database
+DB_HOST=localhost
+
+  # indented comment
+DB_PORT=5432
+`,
+			want: map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432"},
+		},
+		{
+			name:    "export prefix and spaces",
+			content: "export TOKEN=abc\n  NAME = value with spaces  \nEMPTY=\n",
+			want:    map[string]string{"TOKEN": "abc", "NAME": "value with spaces", "EMPTY": ""},
+		},
+		{
+			name: "quotes",
+			content: `DOUBLE="a b"
+SINGLE='$HOME stays'
+HASH="keep # this"
+EQUALS="a=b"
+UNBALANCED="open
+`,
+			want: map[string]string{
+				"DOUBLE":     "a b",
+				"SINGLE":     "$HOME stays",
+				"HASH":       "keep # this",
+				"EQUALS":     "a=b",
+				"UNBALANCED": `"open`,
+			},
+		},
+		{
+			name:    "inline comment",
+			content: "URL=http://x/#anchor # the url\nPLAIN=value #comment\n",
+			want:    map[string]string{"URL": "http://x/#anchor", "PLAIN": "value"},
+		},
+		{
+			name:    "later lines win",
+			content: "A=1\nA=2\n",
+			want:    map[string]string{"A": "2"},
+		},
+		{
+			name:    "missing =",
+			content: "A=1\nJUST_A_NAME\n",
+			wantErr: ".env:2: expected KEY=VALUE",
+		},
+		{
+			name:    "invalid name",
+			content: "1A=x\n",
+			wantErr: ".env:1: expected KEY=VALUE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+			got, err := readEnvFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readEnvFile failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := readEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
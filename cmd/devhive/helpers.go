@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // getWorkerName returns the worker name from args or environment variable
@@ -27,16 +28,17 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-// createGitWorktree creates a git worktree for the worker
-// Returns the path to the created worktree
-func createGitWorktree(workerName, branch, repoPath string) (string, error) {
+// createGitWorktree creates a git worktree for the worker and applies its file scope
+// A new branch starts from startPoint (empty: HEAD). Returns the path to the
+// worktree and whether it was created (false: it already existed)
+func createGitWorktree(workerName, branch, startPoint, repoPath string, scope ComposeScope) (string, bool, error) {
 	var err error
 
 	// Determine repo path (project root)
 	if repoPath == "" {
 		repoPath, err = os.Getwd()
 		if err != nil {
-			return "", err
+			return "", false, err
 		}
 	}
 
@@ -45,13 +47,16 @@ func createGitWorktree(workerName, branch, repoPath string) (string, error) {
 
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(worktreePath), 0755); err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	// Check if worktree already exists
 	if _, err := os.Stat(worktreePath); err == nil {
-		// Already exists - return the path (useful for re-running up)
-		return worktreePath, nil
+		// Already exists - re-apply scope (it may have changed) and return the path
+		if err := applyWorktreeScope(workerName, repoPath, worktreePath, scope); err != nil {
+			return "", false, err
+		}
+		return worktreePath, false, nil
 	}
 
 	// Check if branch exists locally
@@ -63,16 +68,24 @@ func createGitWorktree(workerName, branch, repoPath string) (string, error) {
 		// Branch exists, create worktree
 		cmd = exec.Command("git", "-C", repoPath, "worktree", "add", worktreePath, branch)
 	} else {
-		// Branch doesn't exist, create new branch
-		cmd = exec.Command("git", "-C", repoPath, "worktree", "add", "-b", branch, worktreePath)
+		// Branch doesn't exist, create new branch (from HEAD or the given start point)
+		args := []string{"-C", repoPath, "worktree", "add", "-b", branch, worktreePath}
+		if startPoint != "" {
+			args = append(args, startPoint)
+		}
+		cmd = exec.Command("git", args...)
 	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("git worktree add failed: %s\n%s", err, string(output))
+		return "", false, fmt.Errorf("git worktree add failed: %s\n%s", err, string(output))
 	}
 
-	return worktreePath, nil
+	if err := applyWorktreeScope(workerName, repoPath, worktreePath, scope); err != nil {
+		return "", false, fmt.Errorf("failed to apply scope: %w", err)
+	}
+
+	return worktreePath, true, nil
 }
 
 // statusIcon returns an emoji icon for worker status
@@ -120,10 +133,15 @@ func parseEventData(data, key string) string {
 }
 
 // createWorkerEnvrc creates a .envrc file in the worktree directory for direnv
-func createWorkerEnvrc(worktreePath, workerName string) error {
+// env is the worker's environment (GetWorkerEnv), which may hold secrets
+func createWorkerEnvrc(worktreePath string, env []string) error {
 	envrcPath := filepath.Join(worktreePath, ".envrc")
-	content := fmt.Sprintf("export DEVHIVE_WORKER=%s\n", workerName)
-	return os.WriteFile(envrcPath, []byte(content), 0644)
+	content := strings.Join(exportEnv(env), "\n") + "\n"
+	if err := os.WriteFile(envrcPath, []byte(content), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(envrcPath, 0600)
 }
 
 // runDirenvAllow runs 'direnv allow' in the specified directory
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeDocument is a compose file merged with the files it includes and
+// its override file, as a single YAML tree.
+//
+// ${VAR} and ${VAR:-default} are expanded in each file before merging.
+// Files are merged in this order, later files winning:
+//
+//	included files (in list order, each with its own includes first)
+//	the compose file itself
+//	<name>.override.yaml next to the compose file
+//
+// Mappings are merged key by key; anything else (scalars, lists) is
+// replaced. Keys keep the position of their first definition and new keys
+// are appended, so worker order stays stable across the merged files.
+type composeDocument struct {
+	root  *yaml.Node            // Merged top-level mapping
+	files []string              // Files in merge order
+	owner map[*yaml.Node]string // File each node was read from
+}
+
+// OverrideComposeFile returns the override file for a compose file
+// (.devhive.yaml -> .devhive.override.yaml)
+func OverrideComposeFile(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".override" + ext
+}
+
+// loadComposeDocument reads a compose file with its includes and override
+func loadComposeDocument(path string) (*composeDocument, error) {
+	d := &composeDocument{owner: make(map[*yaml.Node]string)}
+	root, err := d.load(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	override := OverrideComposeFile(path)
+	if _, err := os.Stat(override); err == nil {
+		o, err := d.load(override, nil)
+		if err != nil {
+			return nil, err
+		}
+		root = mergeNodes(root, o)
+	}
+
+	d.root = root
+	return d, nil
+}
+
+// load reads one file and merges it over the files it includes.
+// stack holds the files being included, to detect cycles.
+func (d *composeDocument) load(path string, stack []string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if containsString(stack, abs) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(root.Content) > 0 {
+		doc = root.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: line %d: compose file must be a mapping", path, doc.Line)
+	}
+	if err := interpolateNode(doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	d.record(doc, path)
+
+	var merged *yaml.Node
+	if includes := mappingValue(doc, "include"); includes != nil {
+		if includes.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s: line %d: include must be a list of files", path, includes.Line)
+		}
+		for _, item := range includes.Content {
+			if item.Kind != yaml.ScalarNode || item.Value == "" {
+				return nil, fmt.Errorf("%s: line %d: include entries must be file paths", path, item.Line)
+			}
+			// Relative to the including file
+			file := item.Value
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			n, err := d.load(file, append(stack, abs))
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeNodes(merged, n)
+		}
+	}
+
+	d.files = append(d.files, path)
+	return mergeNodes(merged, doc), nil
+}
+
+// record remembers which file every node of a tree came from
+func (d *composeDocument) record(n *yaml.Node, path string) {
+	d.owner[n] = path
+	for _, c := range n.Content {
+		d.record(c, path)
+	}
+}
+
+// mergeNodes merges override into base (in place) and returns the result.
+// Mappings are merged key by key, anything else is replaced.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		if j := mappingIndex(base, key.Value); j >= 0 {
+			base.Content[j+1] = mergeNodes(base.Content[j+1], value)
+		} else {
+			base.Content = append(base.Content, key, value)
+		}
+	}
+	return base
+}
+
+// mappingIndex returns the index of a key in a mapping node, or -1
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value of a key in a mapping node, or nil
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(n, key); i >= 0 {
+		return n.Content[i+1]
+	}
+	return nil
+}
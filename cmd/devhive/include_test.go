@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeProject writes files into a new project directory and returns it
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	return dir
+}
+
+func TestComposeIncludeOrder(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		".devhive.yaml": `include: [compose/a.yaml, compose/b.yaml]
+defaults:
+  env:
+    MAIN: main
+`,
+		// Includes are relative to the including file
+		"compose/a.yaml": `include: [c.yaml]
+defaults:
+  env:
+    SHARED: a
+    A: a
+`,
+		"compose/b.yaml": `defaults:
+  env:
+    SHARED: b
+    MAIN: b
+`,
+		"compose/c.yaml": `defaults:
+  env:
+    SHARED: c
+    A: c
+    C: c
+`,
+	})
+
+	config, err := LoadComposeFile(filepath.Join(dir, ".devhive.yaml"))
+	if err != nil {
+		t.Fatalf("LoadComposeFile failed: %v", err)
+	}
+
+	var files []string
+	for _, f := range config.Files {
+		rel, _ := filepath.Rel(dir, f)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	want := []string{"compose/c.yaml", "compose/a.yaml", "compose/b.yaml", ".devhive.yaml"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected merge order %v, got %v", want, files)
+	}
+
+	// Later files win: c < a < b < the compose file
+	wantEnv := map[string]string{"SHARED": "b", "A": "a", "C": "c", "MAIN": "main"}
+	if !reflect.DeepEqual(config.Defaults.Env, wantEnv) {
+		t.Errorf("Expected env %v, got %v", wantEnv, config.Defaults.Env)
+	}
+}
+
+func TestComposeOverridePrecedence(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		".devhive.yaml": `include: [base.yaml]
+workers:
+  fe:
+    branch: feat/fe
+    tool: claude
+    ports: [web, api]
+`,
+		"base.yaml": `workers:
+  fe:
+    branch: feat/base
+    role: "@frontend"
+`,
+		".devhive.override.yaml": `workers:
+  fe:
+    branch: feat/local
+    ports: [storybook]
+`,
+	})
+
+	config, err := LoadComposeFile(filepath.Join(dir, ".devhive.yaml"))
+	if err != nil {
+		t.Fatalf("LoadComposeFile failed: %v", err)
+	}
+	if last := config.Files[len(config.Files)-1]; filepath.Base(last) != ".devhive.override.yaml" {
+		t.Errorf("Expected the override file to be merged last, got %v", config.Files)
+	}
+
+	fe := config.Workers["fe"]
+	if fe.Branch != "feat/local" {
+		t.Errorf("Expected the override branch, got %s", fe.Branch)
+	}
+	// Mappings merge key by key, lists are replaced
+	if fe.Tool != "claude" || fe.Role != "@frontend" {
+		t.Errorf("Expected keys not overridden to be kept, got tool %q role %q", fe.Tool, fe.Role)
+	}
+	if !reflect.DeepEqual(fe.Ports, []string{"storybook"}) {
+		t.Errorf("Expected the override to replace ports, got %v", fe.Ports)
+	}
+}
+
+func TestComposeIncludeCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"self", map[string]string{
+			".devhive.yaml": "include: [.devhive.yaml]\n",
+		}},
+		{"indirect", map[string]string{
+			".devhive.yaml": "include: [a.yaml]\n",
+			"a.yaml":        "include: [b.yaml]\n",
+			"b.yaml":        "include: [a.yaml]\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeProject(t, tt.files)
+			_, err := LoadComposeFile(filepath.Join(dir, ".devhive.yaml"))
+			if err == nil || !strings.Contains(err.Error(), "include cycle") {
+				t.Errorf("Expected an include cycle error, got %v", err)
+			}
+		})
+	}
+
+	// Including the same file twice is not a cycle
+	dir := writeProject(t, map[string]string{
+		".devhive.yaml": "include: [a.yaml, b.yaml]\n",
+		"a.yaml":        "include: [common.yaml]\n",
+		"b.yaml":        "include: [common.yaml]\n",
+		"common.yaml":   "defaults:\n  env:\n    COMMON: \"1\"\n",
+	})
+	if _, err := LoadComposeFile(filepath.Join(dir, ".devhive.yaml")); err != nil {
+		t.Errorf("Expected a diamond include to load, got %v", err)
+	}
+}
+
+func TestComposeWorkerOrderAcrossFiles(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"workers.yaml": `workers:
+  db:
+    branch: feat/db
+  api:
+    branch: feat/api
+`,
+		".devhive.yaml": `include: [workers.yaml]
+workers:
+  web:
+    branch: feat/web
+  api:
+    tool: codex
+`,
+		".devhive.override.yaml": `workers:
+  docs:
+    branch: feat/docs
+  db:
+    disabled: true
+`,
+	})
+
+	config, err := LoadComposeFile(filepath.Join(dir, ".devhive.yaml"))
+	if err != nil {
+		t.Fatalf("LoadComposeFile failed: %v", err)
+	}
+	// Workers keep the position of their first definition; new ones are appended
+	want := []string{"db", "api", "web", "docs"}
+	if !reflect.DeepEqual(config.WorkerOrder, want) {
+		t.Errorf("Expected worker order %v, got %v", want, config.WorkerOrder)
+	}
+	if api := config.Workers["api"]; api.Branch != "feat/api" || api.Tool != "codex" {
+		t.Errorf("Expected api merged from both files, got %+v", api)
+	}
+}
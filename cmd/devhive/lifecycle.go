@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iguchi/devhive/internal/checks"
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/lifecycle"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the command of a hook ("" if not set)
+func (h ComposeHooks) Command(hook string) string {
+	switch hook {
+	case lifecycle.OnUp:
+		return h.OnUp
+	case lifecycle.OnComplete:
+		return h.OnComplete
+	case lifecycle.OnError:
+		return h.OnError
+	case lifecycle.OnBlocked:
+		return h.OnBlocked
+	case lifecycle.OnMerge:
+		return h.OnMerge
+	}
+	return ""
+}
+
+// IsEmpty reports whether no hook is set
+func (h ComposeHooks) IsEmpty() bool {
+	return h == ComposeHooks{}
+}
+
+// lifecycleHooks holds the config of the running command's hooks (config is
+// nil without hooks) and the hooks queued by a long-running command
+var lifecycleHooks struct {
+	config      *ComposeConfig
+	projectRoot string
+
+	mu      sync.Mutex
+	last    chan struct{} // closed when the last queued hook is done
+	running sync.WaitGroup
+}
+
+// startLifecycleHooks runs the project's hooks for events logged by this
+// process. Commands run by a hook do not run hooks themselves.
+//
+// Short-lived commands run a hook before they go on. Long-running commands
+// must not hold up their requests for up to lifecycle.Timeout, so they run
+// hooks in the background, one at a time in the order of the events.
+func startLifecycleHooks(cmd *cobra.Command) {
+	if os.Getenv("DEVHIVE_HOOK") != "" {
+		return
+	}
+	configFile, err := FindProjectComposeFile()
+	if err != nil {
+		return
+	}
+	// Invalid config is reported by the commands that use it
+	config, err := LoadComposeFile(configFile)
+	if err != nil || config.Hooks.IsEmpty() {
+		return
+	}
+
+	lifecycleHooks.config, lifecycleHooks.projectRoot = config, filepath.Dir(configFile)
+	background := containsString(longRunningCommands, cmd.Name())
+	database.OnEvent(func(e db.Event) {
+		hook := lifecycle.HookFor(e)
+		if hook == "" {
+			return
+		}
+		if !background {
+			runLifecycleHook(hook, e.Worker, &e)
+			return
+		}
+		queueLifecycleHook(hook, e)
+	})
+}
+
+// queueLifecycleHook runs a hook in the background after the hooks queued
+// before it
+func queueLifecycleHook(hook string, e db.Event) {
+	lifecycleHooks.mu.Lock()
+	prev, done := lifecycleHooks.last, make(chan struct{})
+	lifecycleHooks.last = done
+	lifecycleHooks.running.Add(1)
+	lifecycleHooks.mu.Unlock()
+
+	go func() {
+		defer lifecycleHooks.running.Done()
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		runLifecycleHook(hook, e.Worker, &e)
+	}()
+}
+
+// stopLifecycleHooks waits for the hooks queued in the background
+func stopLifecycleHooks() {
+	lifecycleHooks.running.Wait()
+}
+
+// runLifecycleHook runs a hook for a worker in the project root, with the
+// worker's env, DEVHIVE_HOOK and the payload on stdin. Failures are printed
+// to stderr and do not fail the command.
+func runLifecycleHook(hook, worker string, e *db.Event) {
+	if lifecycleHooks.config == nil {
+		return
+	}
+	command := lifecycleHooks.config.Hooks.Command(hook)
+	if command == "" {
+		return
+	}
+
+	payload := lifecycle.Payload{Hook: hook, Project: db.GetProjectName(), Event: e}
+	var env []string
+	if worker != "" {
+		if w, err := database.GetWorker(worker); err == nil {
+			payload.Worker = w
+		}
+		var err error
+		if env, err = lifecycleHooks.config.GetWorkerEnv(worker, lifecycleHooks.projectRoot); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ hook %s for %s: %v\n", hook, worker, err)
+		}
+	}
+	env = append(env, "DEVHIVE_HOOK="+hook)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	r := checks.Run(lifecycleHooks.projectRoot, checks.Check{Name: hook, Run: command, Timeout: lifecycle.Timeout, Stdin: data}, env)
+	if !r.Passed {
+		fmt.Fprintf(os.Stderr, "⚠ hook %s failed for %s (exit %d)\n", hook, worker, r.ExitCode)
+		if output := checks.Tail(r.Output, 20); output != "" {
+			fmt.Fprintln(os.Stderr, indentLines(output))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/lifecycle"
+)
+
+func TestQueueLifecycleHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh")
+	}
+	dir := t.TempDir()
+	lifecycleHooks.config = &ComposeConfig{Hooks: ComposeHooks{
+		OnComplete: "sleep 0.3; echo $DEVHIVE_HOOK >> hooks.log",
+		OnMerge:    "echo $DEVHIVE_HOOK >> hooks.log",
+	}}
+	lifecycleHooks.projectRoot = dir
+	t.Cleanup(func() { lifecycleHooks.config, lifecycleHooks.projectRoot = nil, "" })
+
+	// Queuing does not wait for the hooks
+	start := time.Now()
+	queueLifecycleHook(lifecycle.OnComplete, db.Event{EventType: "worker_status_changed"})
+	queueLifecycleHook(lifecycle.OnMerge, db.Event{EventType: "branch_merged"})
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Expected queuing to return at once, took %v", elapsed)
+	}
+
+	// They run one at a time in order
+	stopLifecycleHooks()
+	data, err := os.ReadFile(filepath.Join(dir, "hooks.log"))
+	if err != nil {
+		t.Fatalf("Expected the hooks to have run: %v", err)
+	}
+	if string(data) != "on_complete\non_merge\n" {
+		t.Errorf("Expected on_complete then on_merge, got %q", data)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// version is the devhive release version
+const version = "0.9.0"
+
 var database *db.DB
 var projectFlag string
 
@@ -26,7 +29,7 @@ Quick Start:
   6. devhive down      Stop all workers`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Skip DB for commands that don't need it
-			if cmd.Name() == "version" || cmd.Name() == "help" || cmd.Name() == "init" {
+			if cmd.Name() == "version" || cmd.Name() == "help" || cmd.Name() == "init" || cmd.Name() == "guard" || cmd.Name() == "validate" {
 				return nil
 			}
 
@@ -37,12 +40,12 @@ Quick Start:
 
 			var err error
 			database, err = db.Open("")
-			return err
-		},
-		PersistentPostRun: func(cmd *cobra.Command, args []string) {
-			if database != nil {
-				database.Close()
+			if err != nil {
+				return err
 			}
+			startNotifications(cmd)
+			startLifecycleHooks(cmd)
+			return nil
 		},
 	}
 
@@ -61,10 +64,15 @@ Quick Start:
 	rootCmd.AddCommand(withGroup(initCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(upCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(downCmd(), "basic"))
+	rootCmd.AddCommand(withGroup(sprintCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(psCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(statusCmd(), "basic"))
+	rootCmd.AddCommand(withGroup(monitorCmd(), "basic"))
+	rootCmd.AddCommand(withGroup(watchCmd(), "basic"))
+	rootCmd.AddCommand(withGroup(topCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(logsCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(configCmd(), "basic"))
+	rootCmd.AddCommand(withGroup(validateCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(tmuxCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(tmuxKillCmd(), "basic"))
 	rootCmd.AddCommand(withGroup(tmuxListCmd(), "basic"))
@@ -78,10 +86,16 @@ Quick Start:
 
 	// Utility commands
 	rootCmd.AddCommand(withGroup(progressCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(checkCmd(), "utility"))
 	rootCmd.AddCommand(withGroup(mergeCmd(), "utility"))
 	rootCmd.AddCommand(withGroup(diffCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(conflictsCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(syncCmd(), "utility"))
 	rootCmd.AddCommand(withGroup(noteCmd(), "utility"))
 	rootCmd.AddCommand(withGroup(cleanCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(serveCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(mcpCmd(), "utility"))
+	rootCmd.AddCommand(withGroup(webhooksCmd(), "utility"))
 
 	// Communication commands
 	rootCmd.AddCommand(withGroup(requestCmd(), "comm"))
@@ -89,23 +103,41 @@ Quick Start:
 	rootCmd.AddCommand(withGroup(msgsCmd(), "comm"))
 	rootCmd.AddCommand(withGroup(inboxCmd(), "comm"))
 	rootCmd.AddCommand(withGroup(replyCmd(), "comm"))
+	rootCmd.AddCommand(withGroup(threadCmd(), "comm"))
+	rootCmd.AddCommand(withGroup(sendCmd(), "comm"))
 	rootCmd.AddCommand(withGroup(broadcastCmd(), "comm"))
 
 	// Other commands (no group - shown in "Additional Commands")
 	rootCmd.AddCommand(versionCmd())
 	rootCmd.AddCommand(sessionCmd())
+	rootCmd.AddCommand(guardCmd())
 
-	if err := rootCmd.Execute(); err != nil {
+	// Not a PersistentPostRun: cobra skips it when RunE fails, and failing
+	// commands (check, merge, ...) have events to deliver too
+	err := rootCmd.Execute()
+	closeDatabase()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// closeDatabase waits for this command's hooks, delivers its notifications
+// and closes the DB
+func closeDatabase() {
+	if database == nil {
+		return
+	}
+	stopLifecycleHooks()
+	stopNotifications()
+	database.Close()
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
 		Short: "Print version",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("devhive v0.9.0")
+			fmt.Println("devhive v" + version)
 		},
 	}
 }
@@ -115,4 +147,3 @@ func withGroup(cmd *cobra.Command, groupID string) *cobra.Command {
 	cmd.GroupID = groupID
 	return cmd
 }
-
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+// validateNotifications checks the webhook URLs and formats
+func (c *ComposeConfig) validateNotifications() error {
+	for i, w := range c.Notifications.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifications.webhooks[%d]: invalid url: %q (expected http:// or https://)", i, w.URL)
+		}
+		if w.Format != "" && !containsString(notify.Formats, w.Format) {
+			return fmt.Errorf("notifications.webhooks[%d]: unknown format: %s (valid: %s)", i, w.Format, strings.Join(notify.Formats, ", "))
+		}
+	}
+	return nil
+}
+
+// webhooks converts the configured webhooks for the notifier
+func (c *ComposeConfig) webhooks() []notify.Webhook {
+	var webhooks []notify.Webhook
+	for _, w := range c.Notifications.Webhooks {
+		webhook := notify.Webhook{URL: w.URL, Format: w.Format}
+		for _, rule := range w.Events {
+			webhook.Events = append(webhook.Events, notify.Match{Type: rule.Type, Data: rule.Data})
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks
+}
+
+// notifier delivers webhooks for the running command (Notifier is nil without
+// webhooks, cancel is nil unless it delivers in the background)
+var notifier struct {
+	*notify.Notifier
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// longRunningCommands deliver every due webhook in the background, including
+// retries and events queued by other commands
+var longRunningCommands = []string{"serve", "watch", "top", "mcp"}
+
+// exitFlushTimeout bounds how long a command waits at exit for its own deliveries
+const exitFlushTimeout = 3 * time.Second
+
+// startNotifications queues events logged by this process for the project's
+// webhooks. Long-running commands deliver them in the background.
+func startNotifications(cmd *cobra.Command) {
+	configFile, err := FindProjectComposeFile()
+	if err != nil {
+		return
+	}
+	// Invalid config is reported by the commands that use it
+	config, err := LoadComposeFile(configFile)
+	if err != nil || len(config.Notifications.Webhooks) == 0 {
+		return
+	}
+
+	n := notify.New(database, db.GetProjectName(), config.webhooks())
+	n.UserAgent = "devhive/" + version
+	database.OnEvent(n.Enqueue)
+	notifier.Notifier = n
+	if !containsString(longRunningCommands, cmd.Name()) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier.cancel = cancel
+	notifier.done.Add(1)
+	go func() {
+		defer notifier.done.Done()
+		n.Run(ctx)
+	}()
+}
+
+// stopNotifications delivers the events this command logged, waiting at
+// most exitFlushTimeout. What is left and failed attempts stay queued for
+// the next long-running command.
+func stopNotifications() {
+	if notifier.Notifier == nil {
+		return
+	}
+	if notifier.cancel != nil {
+		notifier.cancel()
+		notifier.done.Wait()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exitFlushTimeout)
+	defer cancel()
+	notifier.FlushQueued(ctx)
+}
+
+// webhooksCmd shows webhook deliveries
+func webhooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Show webhook deliveries",
+		Long: `Show recent deliveries to the webhooks in notifications.webhooks of
+.devhive.yaml.
+
+Events are queued when they are logged and sent by the devhive command that
+logged them before it exits (or by a running serve/watch/top/mcp). Failed
+deliveries are retried with exponential backoff by a running
+serve/watch/top/mcp and marked failed after 5 attempts.
+
+Examples:
+  devhive webhooks                  # Recent deliveries
+  devhive webhooks --status failed  # Deliveries that gave up
+  devhive webhooks --retry          # Queue failed deliveries again
+  devhive webhooks --test           # Send a test payload to every webhook`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if test, _ := cmd.Flags().GetBool("test"); test {
+				return testWebhooks()
+			}
+
+			if retry, _ := cmd.Flags().GetBool("retry"); retry {
+				count, err := database.RetryFailedDeliveries()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("✓ Queued %d failed deliveries again\n", count)
+				return nil
+			}
+
+			status, _ := cmd.Flags().GetString("status")
+			switch status {
+			case "", "pending", "sending", "delivered", "failed":
+			default:
+				return fmt.Errorf("invalid status: %s (valid: pending, sending, delivered, failed)", status)
+			}
+			limit, _ := cmd.Flags().GetInt("limit")
+			deliveries, err := database.GetDeliveries(limit, status)
+			if err != nil {
+				return err
+			}
+
+			if ok, err := writeOutput(cmd, deliveries); err != nil || ok {
+				return err
+			}
+
+			if len(deliveries) == 0 {
+				fmt.Println("No deliveries.")
+				return nil
+			}
+
+			fmt.Printf("%-5s %-10s %-8s %-26s %-10s %s\n", "ID", "STATUS", "ATTEMPTS", "EVENT", "WORKER", "URL")
+			for _, d := range deliveries {
+				fmt.Printf("%-5d %-10s %-8d %-26s %-10s %s\n", d.ID, d.Status, d.Attempts, d.EventType, d.Worker, d.URL)
+				if d.LastError != "" && d.Status != "delivered" {
+					fmt.Printf("      └ %s\n", d.LastError)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("status", "", "Filter by status (pending, sending, delivered, failed)")
+	cmd.Flags().IntP("limit", "n", 20, "Number of deliveries to show")
+	cmd.Flags().Bool("retry", false, "Queue failed deliveries again")
+	cmd.Flags().Bool("test", false, "Send a test payload to every configured webhook")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// testWebhooks sends a test payload to every configured webhook
+func testWebhooks() error {
+	configFile, err := FindProjectComposeFile()
+	if err != nil {
+		return err
+	}
+	config, err := LoadComposeFile(configFile)
+	if err != nil {
+		return err
+	}
+	webhooks := config.webhooks()
+	if len(webhooks) == 0 {
+		return fmt.Errorf("no webhooks configured (notifications.webhooks in %s)", configFile)
+	}
+
+	n := notify.New(database, db.GetProjectName(), webhooks)
+	n.UserAgent = "devhive/" + version
+	failed := 0
+	for _, w := range webhooks {
+		if err := n.Test(w); err != nil {
+			fmt.Printf("✗ %s: %v\n", w.URL, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✓ %s\n", w.URL)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d webhooks failed", failed, len(webhooks))
+	}
+	return nil
+}
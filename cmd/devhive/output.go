@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// addOutputFlags adds --json and --format to a read command
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.Flags().String("format", "", "Format output using a Go template (e.g., '{{.Name}}\\t{{.Status}}'), or 'json' for one JSON object per line")
+}
+
+// outputFormatter renders values for --json / --format
+type outputFormatter struct {
+	jsonOutput bool
+	jsonLines  bool
+	tmpl       *template.Template
+}
+
+// templateFuncs are available in --format templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"pad": func(width int, s string) string {
+		return fmt.Sprintf("%-*s", width, s)
+	},
+}
+
+// getOutputFormatter returns the formatter requested by the flags,
+// or nil when the default text output should be used
+func getOutputFormatter(cmd *cobra.Command) (*outputFormatter, error) {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	format, _ := cmd.Flags().GetString("format")
+
+	if jsonOutput && format != "" {
+		return nil, fmt.Errorf("--json and --format cannot be used together")
+	}
+	switch {
+	case jsonOutput:
+		return &outputFormatter{jsonOutput: true}, nil
+	case format == "json":
+		return &outputFormatter{jsonLines: true}, nil
+	case format != "":
+		tmpl, err := template.New("format").Funcs(templateFuncs).Parse(unescapeFormat(format))
+		if err != nil {
+			return nil, fmt.Errorf("invalid format: %w", err)
+		}
+		return &outputFormatter{tmpl: tmpl}, nil
+	}
+	return nil, nil
+}
+
+// unescapeFormat turns literal \t and \n typed on the command line into tabs and newlines
+func unescapeFormat(format string) string {
+	return strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+}
+
+// Write renders v. With --json, v is written as one indented document;
+// with --format, slices are rendered one item per line
+func (f *outputFormatter) Write(w io.Writer, v interface{}) error {
+	if f.jsonOutput {
+		// Encode nil slices as [] rather than null
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+			v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if err := f.WriteItem(w, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.WriteItem(w, v)
+}
+
+// WriteItem renders a single item on its own line (used for streaming, e.g. logs -f)
+func (f *outputFormatter) WriteItem(w io.Writer, item interface{}) error {
+	if f.tmpl == nil {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	if err := f.tmpl.Execute(w, item); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// writeOutput renders v to stdout if --json/--format was given
+// Returns false when the caller should print its default text output
+func writeOutput(cmd *cobra.Command, v interface{}) (bool, error) {
+	f, err := getOutputFormatter(cmd)
+	if err != nil || f == nil {
+		return false, err
+	}
+	return true, f.Write(os.Stdout, v)
+}
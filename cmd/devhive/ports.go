@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// DefaultPortRange is used when defaults.port_range is not set
+const DefaultPortRange = "4000-4999"
+
+// portName matches a port name usable in an environment variable name
+var portName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// GetWorkerPortNames returns defaults.ports followed by the worker's own
+// ports, without duplicates
+func (c *ComposeConfig) GetWorkerPortNames(name string) []string {
+	var names []string
+	for _, p := range append(append([]string{}, c.Defaults.Ports...), c.Workers[name].Ports...) {
+		if !containsString(names, p) {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// GetPortRange returns the bounds of defaults.port_range (e.g. 4000-4999)
+func (c *ComposeConfig) GetPortRange() (int, int, error) {
+	r := c.Defaults.PortRange
+	if r == "" {
+		r = DefaultPortRange
+	}
+	lo, hi, ok := strings.Cut(r, "-")
+	min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if !ok || err1 != nil || err2 != nil || min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid port_range: %s (expected e.g. %s)", r, DefaultPortRange)
+	}
+	return min, max, nil
+}
+
+// portEnvName returns the environment variable of a port (web -> DEVHIVE_PORT_WEB)
+func portEnvName(name string) string {
+	return "DEVHIVE_PORT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// allocateWorkerPorts assigns the worker's ports from the port range,
+// keeping ports it already has. Workers without ports release theirs.
+func allocateWorkerPorts(config *ComposeConfig, name string) ([]db.WorkerPort, error) {
+	min, max, err := config.GetPortRange()
+	if err != nil {
+		return nil, err
+	}
+	return database.AllocatePorts(name, config.GetWorkerPortNames(name), min, max)
+}
+
+// formatPorts formats assigned ports as name=port, by port
+func formatPorts(ports map[string]int) string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return ports[names[i]] < ports[names[j]] })
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, ports[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// portsSection describes the worker's ports for CONTEXT.md ("" if none)
+func portsSection(workerName string) string {
+	if database == nil {
+		return ""
+	}
+	ports, err := database.GetWorkerPorts(workerName)
+	if err != nil || len(ports) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Ports\n\n")
+	sb.WriteString("このワーカー専用のポートです。開発サーバー等は他のワーカーと衝突しないようこれらを使用してください:\n\n")
+	for _, p := range ports {
+		sb.WriteString(fmt.Sprintf("- **%s**: %d (`$%s`)\n", p.Name, p.Port, portEnvName(p.Name)))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
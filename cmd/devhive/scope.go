@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchScopePattern reports whether a slash-separated path matches a
+// gitignore-style glob. "**" matches any number of directories, a pattern
+// without "/" matches at any depth, and a trailing "/" matches a directory.
+func matchScopePattern(pattern, name string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+	if pattern == "" {
+		return false
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Trailing "**" matches everything below
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchAnyPattern reports whether name matches the patterns. As in
+// .gitignore, a "!" pattern re-includes what earlier patterns matched and
+// the last matching pattern wins.
+func matchAnyPattern(patterns []string, name string) bool {
+	matched := false
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if negated, ok := strings.CutPrefix(p, "!"); ok {
+			if matchScopePattern(negated, name) {
+				matched = false
+			}
+		} else if matchScopePattern(p, name) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// IsWritable reports whether a worker with this scope may modify the path
+func (s ComposeScope) IsWritable(name string) bool {
+	if matchAnyPattern(s.Exclude, name) {
+		return false
+	}
+	if len(s.Write) == 0 {
+		return true
+	}
+	return matchAnyPattern(s.Write, name)
+}
+
+// OutOfScope returns the paths that the scope does not allow writing
+func (s ComposeScope) OutOfScope(paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		if p != "" && !s.IsWritable(p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// applyWorktreeScope applies sparse-checkout and the write guard to a worktree
+func applyWorktreeScope(workerName, repoPath, worktreePath string, scope ComposeScope) error {
+	if err := applySparseCheckout(worktreePath, scope.Exclude); err != nil {
+		return err
+	}
+	return installScopeGuard(workerName, repoPath, worktreePath, scope)
+}
+
+// applySparseCheckout removes excluded paths from the worktree
+func applySparseCheckout(worktreePath string, exclude []string) error {
+	if len(exclude) == 0 {
+		// Only disable if a previous run enabled it
+		out, _ := exec.Command("git", "-C", worktreePath, "config", "--worktree", "core.sparseCheckout").Output()
+		if strings.TrimSpace(string(out)) != "true" {
+			return nil
+		}
+		if output, err := exec.Command("git", "-C", worktreePath, "sparse-checkout", "disable").CombinedOutput(); err != nil {
+			return fmt.Errorf("git sparse-checkout disable failed: %s\n%s", err, string(output))
+		}
+		return nil
+	}
+
+	args := []string{"-C", worktreePath, "sparse-checkout", "set", "--no-cone", "/*"}
+	for _, pattern := range exclude {
+		// "!pattern" re-includes excluded paths
+		if negated, ok := strings.CutPrefix(strings.TrimSpace(pattern), "!"); ok {
+			args = append(args, negated)
+		} else {
+			args = append(args, "!"+pattern)
+		}
+	}
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git sparse-checkout failed: %s\n%s", err, string(output))
+	}
+	return nil
+}
+
+// installScopeGuard installs a pre-commit hook that rejects commits outside the write scope
+// Hooks live in .devhive/hooks/<worker>/ and are activated via per-worktree core.hooksPath
+func installScopeGuard(workerName, repoPath, worktreePath string, scope ComposeScope) error {
+	if scope.IsEmpty() {
+		return nil
+	}
+
+	// Resolve the repository's own hooks dir before overriding it for this worktree
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return fmt.Errorf("failed to resolve git hooks path: %w", err)
+	}
+	repoHooks := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(repoHooks) {
+		repoHooks = filepath.Join(repoPath, repoHooks)
+	}
+
+	hooksDir, err := filepath.Abs(filepath.Join(repoPath, ".devhive", "hooks", workerName))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+
+	// Keep the repository's other hooks working
+	if entries, err := os.ReadDir(repoHooks); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || name == "pre-commit" || strings.HasSuffix(name, ".sample") {
+				continue
+			}
+			link := filepath.Join(hooksDir, name)
+			os.Remove(link)
+			os.Symlink(filepath.Join(repoHooks, name), link)
+		}
+	}
+
+	devhiveBin, err := os.Executable()
+	if err != nil {
+		devhiveBin = "devhive"
+	}
+
+	// The hook runs inside the worktree, so point it at the project's compose file
+	configFile := ""
+	for _, filename := range DefaultComposeFiles {
+		candidate := filepath.Join(repoPath, filename)
+		if _, err := os.Stat(candidate); err == nil {
+			configFile, _ = filepath.Abs(candidate)
+			break
+		}
+	}
+	if configFile == "" {
+		return fmt.Errorf("compose file not found in %s", repoPath)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+# Generated by devhive: rejects commits outside the write scope of %[1]s
+%[2]q guard %[1]q --file %[3]q || exit 1
+if [ -x %[4]q ]; then
+  exec %[4]q "$@"
+fi
+`, workerName, devhiveBin, configFile, filepath.Join(repoHooks, "pre-commit"))
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-commit"), []byte(script), 0755); err != nil {
+		return err
+	}
+
+	if output, err := exec.Command("git", "-C", repoPath, "config", "extensions.worktreeConfig", "true").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to enable worktree config: %s\n%s", err, string(output))
+	}
+	if output, err := exec.Command("git", "-C", worktreePath, "config", "--worktree", "core.hooksPath", hooksDir).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set hooks path: %s\n%s", err, string(output))
+	}
+	return nil
+}
+
+// stagedFiles returns the paths staged for commit in a worktree
+func stagedFiles(dir string) ([]string, error) {
+	out, err := exec.Command("git", "-C", dir, "diff", "--cached", "--name-only").Output()
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(string(out)), nil
+}
+
+// scopeViolations returns committed files on branch (since base) outside the write scope
+func scopeViolations(repoDir, base, branch string, scope ComposeScope) []string {
+	if scope.IsEmpty() || branch == "" {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", repoDir, "diff", "--name-only", base+"..."+branch).Output()
+	if err != nil {
+		return nil
+	}
+	return scope.OutOfScope(splitNonEmptyLines(string(out)))
+}
+
+// splitNonEmptyLines splits output into trimmed, non-empty lines
+func splitNonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
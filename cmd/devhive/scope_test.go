@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchScopePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		// Patterns without "/" match at any depth
+		{"*.env", ".env", true},
+		{"*.env", "config/prod/.env", true},
+		{"*.env", "config/env.go", false},
+		// "**" matches zero or more directories
+		{"packages/web/**", "packages/web/src/app.ts", true},
+		{"packages/web/**", "packages/web", true},
+		{"packages/web/**", "packages/webapp/index.ts", false},
+		{"packages/**/test", "packages/test", true},
+		{"packages/**/test", "packages/a/b/test", true},
+		{"packages/**/test", "packages/a/b/test/x.go", false},
+		{"**/fixtures/*.json", "a/fixtures/b.json", true},
+		// A trailing "/" matches everything below the directory
+		{"docs/", "docs/guide/intro.md", true},
+		{"docs/", "docs.md", false},
+		{"docs/", "src/docs/readme.md", false},
+		// A leading "/" anchors to the root; segments match exactly
+		{"/README.md", "README.md", true},
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "src/cmd/main.go", false},
+		{"", "anything", false},
+	}
+	for _, tt := range tests {
+		if got := matchScopePattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchScopePattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAnyPatternNegation(t *testing.T) {
+	patterns := []string{"packages/backend/**", "!packages/backend/api/*.ts", "packages/backend/api/secret.ts"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"packages/backend/db/schema.sql", true},
+		{"packages/backend/api/types.ts", false}, // re-included by "!"
+		{"packages/backend/api/secret.ts", true}, // the last matching pattern wins
+		{"packages/web/app.ts", false},
+	}
+	for _, tt := range tests {
+		if got := matchAnyPattern(patterns, tt.name); got != tt.want {
+			t.Errorf("matchAnyPattern(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if matchAnyPattern([]string{"!*.md"}, "README.md") {
+		t.Error("A negation alone should not match")
+	}
+}
+
+func TestScopeOutOfScope(t *testing.T) {
+	scope := ComposeScope{
+		Exclude: []string{"**/*.env", "!web/.env.example"},
+		Write:   []string{"web/", "tests/web/**"},
+	}
+	paths := []string{"web/app.ts", "web/.env", "web/.env.example", "tests/web/a_test.ts", "api/main.go", "README.md"}
+	want := []string{"web/.env", "api/main.go", "README.md"}
+	if got := scope.OutOfScope(paths); !reflect.DeepEqual(got, want) {
+		t.Errorf("OutOfScope = %v, want %v", got, want)
+	}
+	if got := (ComposeScope{}).OutOfScope(paths); len(got) != 0 {
+		t.Errorf("Expected an empty scope to allow everything, got %v", got)
+	}
+}
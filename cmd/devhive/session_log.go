@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sessionLogHeaderPrefix starts the marker line written when capture begins
+const sessionLogHeaderPrefix = "=== devhive session"
+
+// ansiPattern matches terminal escape sequences in captured pane output
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[()][A-Za-z0-9]|\x1b[=>]`)
+
+// sessionLogPath returns the session log path for a worker
+// Logs are stored in <project>/.devhive/logs/session-<worker>.log
+func sessionLogPath(projectRoot, workerName string) string {
+	return filepath.Join(projectRoot, ".devhive", "logs", "session-"+workerName+".log")
+}
+
+// archivedSessionLogPath returns where a past sprint's session log is kept
+func archivedSessionLogPath(projectRoot, sprintID, workerName string) string {
+	return filepath.Join(projectRoot, ".devhive", "logs", sprintID, "session-"+workerName+".log")
+}
+
+// prepareSessionLog rotates the worker's session log if it belongs to another sprint
+// and appends a start marker. Returns the absolute log path.
+func prepareSessionLog(projectRoot, workerName, sprintID string) (string, error) {
+	logPath, err := filepath.Abs(sessionLogPath(projectRoot, workerName))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return "", err
+	}
+
+	// Rotate: move the previous sprint's log to .devhive/logs/<sprint>/
+	if previous := sessionLogSprint(logPath); previous != "" && previous != sprintID {
+		archived := archivedSessionLogPath(projectRoot, previous, workerName)
+		if err := os.MkdirAll(filepath.Dir(archived), 0755); err != nil {
+			return "", err
+		}
+		if err := os.Rename(logPath, archived); err != nil {
+			return "", fmt.Errorf("failed to rotate session log: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s worker=%s sprint=%s started=%s ===\n",
+		sessionLogHeaderPrefix, workerName, sprintID, time.Now().Format(time.RFC3339))
+	return logPath, err
+}
+
+// sessionLogSprint returns the sprint ID recorded in the log's first marker line
+func sessionLogSprint(logPath string) string {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	if !strings.HasPrefix(line, sessionLogHeaderPrefix) {
+		return ""
+	}
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "sprint=") {
+			return strings.TrimPrefix(field, "sprint=")
+		}
+	}
+	return ""
+}
+
+// startPaneCapture pipes a tmux pane's output into the log file
+func startPaneCapture(paneTarget, logPath string) error {
+	return exec.Command("tmux", "pipe-pane", "-o", "-t", paneTarget, "cat >> "+shellQuote(logPath)).Run()
+}
+
+// cleanTerminalOutput strips escape sequences and carriage returns from captured output
+func cleanTerminalOutput(s string) string {
+	s = ansiPattern.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "")
+}
+
+// shellQuote quotes s for use in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// showSessionLog prints the tail of a worker's session log, optionally
+// filtered by a regular expression, and keeps following it if requested
+func showSessionLog(logPath string, tail int, follow bool, grep string) error {
+	var pattern *regexp.Regexp
+	if grep != "" {
+		var err error
+		pattern, err = regexp.Compile(grep)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no session log: %s (start workers with 'devhive tmux')", logPath)
+		}
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(cleanTerminalOutput(string(data)), "\n"), "\n")
+	if pattern != nil {
+		// Search mode: show matching lines with line numbers
+		var matched []string
+		for i, line := range lines {
+			if pattern.MatchString(line) {
+				matched = append(matched, fmt.Sprintf("%6d: %s", i+1, line))
+			}
+		}
+		lines = matched
+	}
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	if !follow {
+		return nil
+	}
+
+	// Follow appended output
+	offset := int64(len(data))
+	var partial string
+	for {
+		time.Sleep(500 * time.Millisecond)
+
+		info, err := os.Stat(logPath)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			// Rotated or truncated
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		f, err := os.Open(logPath)
+		if err != nil {
+			continue
+		}
+		buf := make([]byte, info.Size()-offset)
+		n, _ := f.ReadAt(buf, offset)
+		f.Close()
+		offset += int64(n)
+
+		chunk := partial + cleanTerminalOutput(string(buf[:n]))
+		newLines := strings.Split(chunk, "\n")
+		partial = newLines[len(newLines)-1]
+		for _, line := range newLines[:len(newLines)-1] {
+			if pattern == nil || pattern.MatchString(line) {
+				fmt.Println(line)
+			}
+		}
+	}
+}
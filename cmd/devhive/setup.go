@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/checks"
+)
+
+// setupStep is one step of preparing a worker's worktree: copying or
+// linking a file from the project root, or running a command
+type setupStep struct {
+	Kind string // copy, link or run
+	Arg  string // Path relative to the project root, or a shell command
+}
+
+// String is the step's key in worktree_setup_steps (e.g. "copy .env")
+func (s setupStep) String() string {
+	return s.Kind + " " + s.Arg
+}
+
+// GetWorkerSetup returns a worker's setup steps: copy_files, then
+// link_files, then setup commands, each defaults first
+func (c *ComposeConfig) GetWorkerSetup(name string) []setupStep {
+	worker := c.Workers[name]
+	var steps []setupStep
+	add := func(kind string, args ...[]string) {
+		for _, list := range args {
+			for _, arg := range list {
+				step := setupStep{Kind: kind, Arg: arg}
+				if !containsStep(steps, step) {
+					steps = append(steps, step)
+				}
+			}
+		}
+	}
+	add("copy", c.Defaults.CopyFiles, worker.CopyFiles)
+	add("link", c.Defaults.LinkFiles, worker.LinkFiles)
+	add("run", c.Defaults.Setup, worker.Setup)
+	return steps
+}
+
+func containsStep(steps []setupStep, step setupStep) bool {
+	for _, s := range steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// setupWorktree runs the setup steps not yet done in a worker's worktree.
+// A new worktree (created) starts over. The first failing step stops the
+// setup; the next call resumes from it. Each call that has steps to run
+// logs a worktree_setup event. Progress is written to log.
+func setupWorktree(config *ComposeConfig, projectRoot, name, dir string, created bool, log io.Writer) error {
+	if created {
+		if err := database.ResetSetupSteps(name); err != nil {
+			return err
+		}
+	}
+
+	done, err := database.GetSetupSteps(name)
+	if err != nil {
+		return err
+	}
+	var pending []setupStep
+	for _, step := range config.GetWorkerSetup(name) {
+		if !containsString(done, step.String()) {
+			pending = append(pending, step)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	env, err := config.GetWorkerEnv(name, projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ran := []string{}
+	for _, step := range pending {
+		if err := runSetupStep(step, projectRoot, dir, env); err != nil {
+			if rerr := database.ReportWorktreeSetup(name, ran, step.String(), err.Error()); rerr != nil {
+				return rerr
+			}
+			return fmt.Errorf("%s: %w", step, err)
+		}
+		if err := database.MarkSetupStepDone(name, step.String()); err != nil {
+			return err
+		}
+		ran = append(ran, step.String())
+		fmt.Fprintf(log, "    ✓ Setup: %s\n", step)
+	}
+	return database.ReportWorktreeSetup(name, ran, "", "")
+}
+
+// runSetupStep applies one setup step in the worktree dir
+func runSetupStep(step setupStep, projectRoot, dir string, env []string) error {
+	switch step.Kind {
+	case "copy":
+		return copyPath(filepath.Join(projectRoot, step.Arg), filepath.Join(dir, step.Arg))
+	case "link":
+		return linkPath(filepath.Join(projectRoot, step.Arg), filepath.Join(dir, step.Arg))
+	}
+	r := checks.Run(dir, checks.Check{Name: "setup", Run: step.Arg}, env)
+	if !r.Passed {
+		msg := fmt.Sprintf("exit %d", r.ExitCode)
+		if output := checks.Tail(r.Output, 20); output != "" {
+			msg += "\n" + output
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// copyPath copies a file or a directory tree, keeping file modes and
+// symlinks. Existing files at dst are overwritten.
+func copyPath(src, dst string) error {
+	if _, err := os.Lstat(src); err != nil {
+		return fmt.Errorf("not found: %s", src)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chmod(target, info.Mode().Perm())
+	})
+}
+
+// linkPath creates a symlink at dst pointing to src. A link to src that
+// already exists is kept; anything else at dst is an error.
+func linkPath(src, dst string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("not found: %s", abs)
+	}
+	if existing, err := os.Readlink(dst); err == nil && existing == abs {
+		return nil
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists in the worktree", filepath.Base(dst))
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(abs, dst)
+}
+
+// isProjectPath reports whether p is a relative path inside the project
+func isProjectPath(p string) bool {
+	clean := filepath.Clean(p)
+	return p != "" && !filepath.IsAbs(p) && clean != "." && clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
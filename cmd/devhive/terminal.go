@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences used by full-screen commands
+const (
+	ansiAltScreen   = "\x1b[?1049h"
+	ansiMainScreen  = "\x1b[?1049l"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+	ansiClearScreen = "\x1b[H\x1b[2J"
+	ansiReset       = "\x1b[0m"
+	ansiBold        = "\x1b[1m"
+	ansiDim         = "\x1b[2m"
+	ansiReverse     = "\x1b[7m"
+	ansiRed         = "\x1b[31m"
+	ansiGreen       = "\x1b[32m"
+	ansiYellow      = "\x1b[33m"
+	ansiBlue        = "\x1b[34m"
+)
+
+// Keys reported by readKey besides printable characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyTab       = "tab"
+)
+
+// stty runs stty on the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// enterFullScreen switches the terminal to unbuffered input without echo
+// and the alternate screen. The returned function restores it.
+func enterFullScreen() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	// Keep ISIG so Ctrl+C still works
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+
+	return func() {
+		fmt.Print(ansiShowCursor + ansiMainScreen)
+		stty(saved)
+	}, nil
+}
+
+// terminalSize returns the terminal width and height (80x24 if unknown)
+func terminalSize() (int, int) {
+	out, err := stty("size")
+	if err == nil {
+		if fields := strings.Fields(out); len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return cols, rows
+			}
+		}
+	}
+	return 80, 24
+}
+
+// readKey reads one key press from stdin
+func readKey() (string, error) {
+	buf := make([]byte, 16)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return parseKey(buf[:n]), nil
+}
+
+// parseKey converts the bytes of one key press into a key name or character
+func parseKey(b []byte) string {
+	switch {
+	case len(b) == 0:
+		return ""
+	case len(b) >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O'):
+		switch b[2] {
+		case 'A':
+			return keyUp
+		case 'B':
+			return keyDown
+		case 'C':
+			return keyRight
+		case 'D':
+			return keyLeft
+		}
+		return ""
+	case b[0] == 0x1b:
+		return keyEscape
+	case b[0] == '\r' || b[0] == '\n':
+		return keyEnter
+	case b[0] == 0x7f || b[0] == 0x08:
+		return keyBackspace
+	case b[0] == '\t':
+		return keyTab
+	}
+	return string(b)
+}
+
+// truncateLine cuts a line with ANSI escapes to a visible width
+func truncateLine(s string, width int) string {
+	var out strings.Builder
+	visible := 0
+	for i := 0; i < len(s); {
+		// Copy escape sequences without counting them
+		if s[i] == 0x1b {
+			j := i + 1
+			if j < len(s) && s[j] == '[' {
+				j++
+				for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+					j++
+				}
+				j++
+			}
+			if j > len(s) {
+				j = len(s)
+			}
+			out.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		if visible >= width {
+			break
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible+runeWidth(r) > width {
+			break
+		}
+		out.WriteRune(r)
+		visible += runeWidth(r)
+		i += size
+	}
+	return out.String() + ansiReset
+}
+
+// runeWidth returns the number of terminal columns a rune occupies
+// (2 for CJK and most emoji)
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1faff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns a plain string occupies
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// padRight pads or cuts a plain string to exactly width columns
+func padRight(s string, width int) string {
+	var out strings.Builder
+	used := 0
+	for _, r := range s {
+		if used+runeWidth(r) > width {
+			break
+		}
+		out.WriteRune(r)
+		used += runeWidth(r)
+	}
+	return out.String() + strings.Repeat(" ", width-used)
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/templates"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// validateCmd checks the compose file
+func validateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the compose file for mistakes",
+		Long: `Check .devhive.yaml, merged with its include files and
+.devhive.override.yaml, and report every problem with its file and line:
+
+  - unknown keys (typos such as 'brnach:' are otherwise ignored)
+  - workers without a branch, or sharing a branch with another worker
+  - tools other than ` + strings.Join(SupportedTools, ", ") + `
+  - @role references that are not builtin, defined in roles, or in .devhive/roles/
+  - task, role and env files that do not exist
+  - worker names that are not safe as a path component
+  - invalid depends_on and notifications settings
+
+'devhive up' runs the same checks and refuses to start on errors.
+
+Examples:
+  devhive validate
+  devhive validate -f other.yaml
+  devhive validate --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFile, _ := cmd.Flags().GetString("file")
+
+			var err error
+			if configFile == "" {
+				configFile, err = FindComposeFile()
+				if err != nil {
+					return err
+				}
+			}
+
+			issues, err := ValidateComposeFile(configFile)
+			if err != nil {
+				return err
+			}
+			if issues == nil {
+				issues = []ValidationIssue{}
+			}
+			if ok, err := writeOutput(cmd, issues); err != nil {
+				return err
+			} else if !ok {
+				printValidationIssues(configFile, issues)
+				if len(issues) == 0 {
+					fmt.Printf("✓ %s is valid\n", configFile)
+				}
+			}
+
+			if len(issues) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d problem(s) in %s", len(issues), configFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "Compose file path")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// printValidationIssues prints issues as file:line: path: message
+// (file is used for issues without a file of their own)
+func printValidationIssues(file string, issues []ValidationIssue) {
+	for _, issue := range issues {
+		file := file
+		if issue.File != "" {
+			file = issue.File
+		}
+		if issue.Line > 0 {
+			fmt.Printf("%s:%d: %s\n", file, issue.Line, issue)
+		} else {
+			fmt.Printf("%s: %s\n", file, issue)
+		}
+	}
+}
+
+// ValidationIssue is a problem found in a compose file
+type ValidationIssue struct {
+	File    string `json:"file,omitempty"` // Included or override file ("" if unknown)
+	Line    int    `json:"line"`           // 0 if unknown
+	Path    string `json:"path"`           // e.g. workers.frontend.branch
+	Message string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// safeWorkerName matches names usable as a path component and tmux pane title
+var safeWorkerName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateComposeFile checks a compose file, merged with its includes and
+// override file, against the schema (unknown keys) and the project
+// (branches, tools, roles, task and role files).
+// Returns an error only if the file cannot be read.
+func ValidateComposeFile(path string) ([]ValidationIssue, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	doc, err := loadComposeDocument(path)
+	if err != nil {
+		// Unreadable includes, YAML syntax errors, include cycles
+		return []ValidationIssue{{Message: strings.TrimPrefix(err.Error(), "yaml: ")}}, nil
+	}
+
+	v := &validator{doc: doc, projectRoot: filepath.Dir(path)}
+	v.checkKeys(doc.root, reflect.TypeOf(ComposeConfig{}), "")
+
+	var config ComposeConfig
+	if err := doc.root.Decode(&config); err != nil {
+		// Type errors (e.g. a list where a mapping belongs) carry their own line numbers
+		v.add(nil, "", "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return v.sorted(), nil
+	}
+	config.WorkerOrder = extractWorkerOrder(doc.root)
+	v.config = &config
+
+	v.checkEnv(config.Defaults.Env, config.Defaults.EnvFile, "defaults")
+	v.checkPorts(config.Defaults.Ports, "defaults")
+	v.checkSetupFiles(config.Defaults.CopyFiles, config.Defaults.LinkFiles, "defaults")
+	if _, _, err := config.GetPortRange(); err != nil {
+		v.add(v.at("defaults", "port_range"), "defaults.port_range", "%s", err.Error())
+	}
+	v.checkWorkers()
+	v.checkRoles()
+	if err := config.validateDependencies(); err != nil {
+		v.add(v.at("workers"), "workers", "%s", err.Error())
+	}
+	if err := config.validateNotifications(); err != nil {
+		v.add(v.at("notifications"), "notifications", "%s", err.Error())
+	}
+	return v.sorted(), nil
+}
+
+type validator struct {
+	doc         *composeDocument
+	config      *ComposeConfig
+	projectRoot string
+	issues      []ValidationIssue
+}
+
+// add records an issue at the position of node (nil: unknown position)
+func (v *validator) add(node *yaml.Node, path, format string, args ...interface{}) {
+	issue := ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.File, issue.Line = v.doc.owner[node], node.Line
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// sorted returns the issues in merge order of their files, then by line
+func (v *validator) sorted() []ValidationIssue {
+	fileIndex := func(file string) int {
+		for i, f := range v.doc.files {
+			if f == file {
+				return i
+			}
+		}
+		return -1
+	}
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		a, b := v.issues[i], v.issues[j]
+		if fa, fb := fileIndex(a.File), fileIndex(b.File); fa != fb {
+			return fa < fb
+		}
+		return a.Line < b.Line
+	})
+	return v.issues
+}
+
+// node returns the value node at a key path, or nil
+func (v *validator) node(keys ...string) *yaml.Node {
+	n := v.doc.root
+	for _, key := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+			}
+		}
+		n = next
+	}
+	return n
+}
+
+// at returns the value node at a key path, falling back to its closest
+// parent that exists
+func (v *validator) at(keys ...string) *yaml.Node {
+	for i := len(keys); i > 0; i-- {
+		if n := v.node(keys[:i]...); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// Types whose YAML shape is handled by a custom UnmarshalYAML
+var (
+	checksType       = reflect.TypeOf(ComposeChecks{})
+	dependenciesType = reflect.TypeOf(ComposeDependencies{})
+	eventRuleType    = reflect.TypeOf(ComposeEventRule{})
+)
+
+// checkKeys reports mapping keys that do not match a field of t
+func (v *validator) checkKeys(node *yaml.Node, t reflect.Type, path string) {
+	if node == nil || node.Kind == yaml.AliasNode {
+		return
+	}
+
+	switch t {
+	case checksType:
+		return // Names are free-form
+	case dependenciesType:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				v.checkKeys(node.Content[i+1], reflect.TypeOf(struct {
+					Condition string `yaml:"condition"`
+				}{}), joinPath(path, node.Content[i].Value))
+			}
+		}
+		return
+	case eventRuleType:
+		v.checkKeys(node, reflect.TypeOf(struct {
+			Type string            `yaml:"type"`
+			Data map[string]string `yaml:"data"`
+		}{}), path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.checkKeys(node, t.Elem(), path)
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if suggestion := closestKey(key.Value, fields); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				v.add(key, path, "%s", msg)
+				continue
+			}
+			v.checkKeys(value, field, joinPath(path, key.Value))
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			v.checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// closestKey returns the known key within edit distance 2 of key, if any
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// checkWorkers checks names, branches, tools, roles and task files
+func (v *validator) checkWorkers() {
+	branches := map[string]string{}
+	// Disabled workers and workers of inactive profiles are checked too
+	for _, name := range v.config.orderWorkerNames(v.config.Workers) {
+		w := v.config.Workers[name]
+		path := "workers." + name
+
+		if !safeWorkerName.MatchString(name) {
+			v.add(v.workerKey(name), path, "worker name must start with a letter or digit and contain only letters, digits, '.', '_' and '-' (it is used in paths)")
+		}
+
+		if w.Branch == "" {
+			v.add(v.workerKey(name), path, "branch is required")
+		} else if other, ok := branches[w.Branch]; ok {
+			v.add(v.at("workers", name, "branch"), path+".branch", "branch %s is also used by worker %s", w.Branch, other)
+		} else {
+			branches[w.Branch] = name
+		}
+
+		if w.Tool != "" && !containsString(SupportedTools, w.Tool) {
+			v.add(v.at("workers", name, "tool"), path+".tool", "unknown tool %s (supported: %s)", w.Tool, strings.Join(SupportedTools, ", "))
+		}
+
+		if msg := v.checkRoleRef(w.Role); msg != "" {
+			v.add(v.at("workers", name, "role"), path+".role", "%s", msg)
+		}
+
+		if looksLikePath(w.Task) && !v.exists(w.Task) {
+			v.add(v.at("workers", name, "task"), path+".task", "task file not found: %s", w.Task)
+		}
+
+		v.checkEnv(w.Env, w.EnvFile, "workers", name)
+		v.checkPorts(w.Ports, "workers", name)
+		v.checkSetupFiles(w.CopyFiles, w.LinkFiles, "workers", name)
+	}
+}
+
+// checkSetupFiles checks that copy_files and link_files stay inside the project
+// keys is the path of the mapping holding them
+func (v *validator) checkSetupFiles(copyFiles, linkFiles []string, keys ...string) {
+	for key, files := range map[string][]string{"copy_files": copyFiles, "link_files": linkFiles} {
+		for _, file := range files {
+			if !isProjectPath(file) {
+				path := strings.Join(append(keys, key), ".")
+				v.add(v.at(append(keys, key)...), path, "%s must be a relative path inside the project: %q", key, file)
+			}
+		}
+	}
+}
+
+// checkPorts checks port names and that no two map to the same variable
+// keys is the path of the mapping holding ports
+func (v *validator) checkPorts(ports []string, keys ...string) {
+	path := strings.Join(keys, ".") + ".ports"
+	at := v.at(append(keys, "ports")...)
+	seen := make(map[string]string)
+	for _, name := range ports {
+		if !portName.MatchString(name) {
+			v.add(at, path, "invalid port name %q (use letters, digits, - and _)", name)
+			continue
+		}
+		env := portEnvName(name)
+		if other, ok := seen[env]; ok && other != name {
+			v.add(at, path, "ports %s and %s both map to %s", other, name, env)
+		}
+		seen[env] = name
+	}
+}
+
+// checkEnv checks variable names and that env files exist (and parse)
+// keys is the path of the mapping holding env and env_file
+func (v *validator) checkEnv(env map[string]string, files ComposeEnvFiles, keys ...string) {
+	path := strings.Join(keys, ".")
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !envName.MatchString(name) {
+			v.add(v.at(append(keys, "env")...), path+".env", "invalid variable name %q", name)
+		}
+	}
+
+	for _, file := range files {
+		at := v.at(append(keys, "env_file")...)
+		if !v.exists(file) {
+			v.add(at, path+".env_file", "env file not found: %s", file)
+			continue
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(v.projectRoot, file)
+		}
+		if _, err := readEnvFile(file); err != nil {
+			v.add(at, path+".env_file", "%v", err)
+		}
+	}
+}
+
+// checkRoles checks the role definitions' files and builtin references
+func (v *validator) checkRoles() {
+	names := make([]string, 0, len(v.config.Roles))
+	for name := range v.config.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		role := v.config.Roles[name]
+		path := "roles." + name
+		if role.File != "" && !v.exists(role.File) {
+			v.add(v.at("roles", name, "file"), path+".file", "role file not found: %s", role.File)
+		}
+		if role.Extends != "" {
+			if !strings.HasPrefix(role.Extends, "@") || !templates.IsBuiltinRole(strings.TrimPrefix(role.Extends, "@")) {
+				v.add(v.at("roles", name, "extends"), path+".extends", "extends must name a builtin role (e.g. @frontend), got %s", role.Extends)
+			}
+		}
+	}
+}
+
+// checkRoleRef returns why a worker's role cannot be resolved ("" if it can)
+// Plain names are inline role descriptions and always valid
+func (v *validator) checkRoleRef(role string) string {
+	switch {
+	case role == "":
+		return ""
+	case strings.HasPrefix(role, "@"):
+		name := strings.TrimPrefix(role, "@")
+		if templates.IsBuiltinRole(name) {
+			return ""
+		}
+		if _, ok := v.config.Roles[name]; ok {
+			return ""
+		}
+		if v.exists(filepath.Join(".devhive", "roles", name+".md")) {
+			return ""
+		}
+		return fmt.Sprintf("unknown role %s: not a builtin role, not defined in roles, and .devhive/roles/%s.md does not exist", role, name)
+	case looksLikePath(role) && !v.exists(role):
+		return fmt.Sprintf("role file not found: %s", role)
+	}
+	return ""
+}
+
+// workerKey returns the key node of a worker's name
+func (v *validator) workerKey(name string) *yaml.Node {
+	if workers := v.node("workers"); workers != nil && workers.Kind == yaml.MappingNode {
+		if i := mappingIndex(workers, name); i >= 0 {
+			return workers.Content[i]
+		}
+	}
+	return v.at("workers")
+}
+
+// exists reports whether a path (relative to the project root) exists
+func (v *validator) exists(path string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(v.projectRoot, path)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// looksLikePath reports whether a task or role value is meant as a file path
+// (the same rule GetTaskContent uses, without inline text containing a slash)
+func looksLikePath(s string) bool {
+	return strings.HasSuffix(s, ".md") || (strings.Contains(s, "/") && !strings.ContainsAny(s, " \t\n"))
+}
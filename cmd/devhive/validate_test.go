@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeComposeFile writes a .devhive.yaml into a new project directory
+func writeComposeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".devhive.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestValidateComposeFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		line    int
+		path    string
+		message string
+	}{
+		{
+			name: "unknown key with suggestion",
+			yaml: `workers:
+  fe:
+    brnach: feat/fe
+    branch: feat/ui
+`,
+			line:    3,
+			path:    "workers.fe",
+			message: `unknown key "brnach" (did you mean "branch"?)`,
+		},
+		{
+			name: "unknown key without suggestion",
+			yaml: `version: "1"
+colour: blue
+workers:
+  fe:
+    branch: feat/fe
+`,
+			line:    2,
+			path:    "",
+			message: `unknown key "colour"`,
+		},
+		{
+			name: "duplicate branch",
+			yaml: `workers:
+  fe:
+    branch: feat/ui
+  be:
+    branch: feat/ui
+`,
+			line:    5,
+			path:    "workers.be.branch",
+			message: "branch feat/ui is also used by worker fe",
+		},
+		{
+			name: "unsafe name",
+			yaml: `workers:
+  ../fe:
+    branch: feat/fe
+`,
+			line:    2,
+			path:    "workers.../fe",
+			message: "worker name must start with a letter or digit and contain only letters, digits, '.', '_' and '-' (it is used in paths)",
+		},
+		{
+			name: "unknown role",
+			yaml: `workers:
+  fe:
+    branch: feat/fe
+    role: "@designer"
+`,
+			line:    4,
+			path:    "workers.fe.role",
+			message: "unknown role @designer: not a builtin role, not defined in roles, and .devhive/roles/designer.md does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := ValidateComposeFile(writeComposeFile(t, tt.yaml))
+			if err != nil {
+				t.Fatalf("ValidateComposeFile failed: %v", err)
+			}
+			if len(issues) != 1 {
+				t.Fatalf("Expected 1 issue, got %+v", issues)
+			}
+			issue := issues[0]
+			if issue.Line != tt.line || issue.Path != tt.path || issue.Message != tt.message {
+				t.Errorf("Expected line %d %s: %s, got line %d %s: %s", tt.line, tt.path, tt.message, issue.Line, issue.Path, issue.Message)
+			}
+		})
+	}
+}
+
+func TestValidateComposeFileValid(t *testing.T) {
+	path := writeComposeFile(t, `version: "1"
+roles:
+  designer:
+    description: UI design
+workers:
+  fe:
+    branch: feat/fe
+    role: "@designer"
+  be:
+    branch: feat/be
+    role: "@backend"
+`)
+	issues, err := ValidateComposeFile(path)
+	if err != nil {
+		t.Fatalf("ValidateComposeFile failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+}
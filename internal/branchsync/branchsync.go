@@ -0,0 +1,138 @@
+// Package branchsync brings worker worktrees up to date with the base branch.
+//
+// Each worktree is rebased onto (or merged with) the base branch in place.
+// Worktrees with uncommitted changes to tracked files are skipped, and an
+// operation that runs into conflicts is aborted, so a worktree is either
+// updated or left exactly as it was.
+package branchsync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/git"
+)
+
+// Strategies
+const (
+	StrategyRebase = "rebase"
+	StrategyMerge  = "merge"
+)
+
+// Result statuses
+const (
+	StatusUpToDate = "up_to_date"
+	StatusBehind   = "behind" // Check only: the worktree needs a sync
+	StatusSynced   = "synced"
+	StatusDirty    = "dirty"
+	StatusConflict = "conflict"
+	StatusError    = "error"
+)
+
+// Result is the state or outcome of one worktree
+type Result struct {
+	Worker    string   `json:"worker"`
+	Branch    string   `json:"branch"`
+	Base      string   `json:"base"`
+	Ahead     int      `json:"ahead"`  // Commits on the branch not on base
+	Behind    int      `json:"behind"` // Commits on base not on the branch (before a sync)
+	Dirty     bool     `json:"dirty"`
+	Status    string   `json:"status"`
+	Commit    string   `json:"commit,omitempty"`    // HEAD after the sync (synced)
+	Conflicts []string `json:"conflicts,omitempty"` // Conflicting files (conflict)
+	Error     string   `json:"error,omitempty"`     // What went wrong (error)
+}
+
+// Check reports how far the worktree's branch is ahead of and behind base,
+// without changing anything
+func Check(dir, base string) Result {
+	r := Result{Base: base}
+	branch, err := git.Output(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return fail(r, fmt.Errorf("HEAD is not on a branch"))
+	}
+	r.Branch = branch
+
+	if _, err := git.Output(dir, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+		return fail(r, fmt.Errorf("base branch not found: %s", base))
+	}
+	counts, err := git.Output(dir, "rev-list", "--left-right", "--count", "HEAD..."+base)
+	if err != nil {
+		return fail(r, err)
+	}
+	fields := strings.Fields(counts)
+	if len(fields) != 2 {
+		return fail(r, fmt.Errorf("unexpected rev-list output: %q", counts))
+	}
+	r.Ahead, _ = strconv.Atoi(fields[0])
+	r.Behind, _ = strconv.Atoi(fields[1])
+
+	// Untracked files (generated context files, build output) do not block a sync
+	status, err := git.Output(dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fail(r, err)
+	}
+	r.Dirty = status != ""
+
+	switch {
+	case r.Behind == 0:
+		r.Status = StatusUpToDate
+	case r.Dirty:
+		r.Status = StatusDirty
+	default:
+		r.Status = StatusBehind
+	}
+	return r
+}
+
+// Sync rebases the worktree's branch onto base, or merges base into it.
+// Dirty worktrees are skipped. On conflicts the operation is aborted and
+// the conflicting files are reported.
+func Sync(dir, base, strategy string) Result {
+	r := Check(dir, base)
+	if r.Status != StatusBehind {
+		return r
+	}
+
+	var args []string
+	switch strategy {
+	case StrategyRebase:
+		args = []string{"rebase", "--quiet", base}
+	case StrategyMerge:
+		args = []string{"merge", "--no-edit", "--quiet", base}
+	default:
+		return fail(r, fmt.Errorf("unknown strategy: %s (use rebase or merge)", strategy))
+	}
+
+	if _, err := git.Output(dir, args...); err != nil {
+		conflicts, _ := git.Output(dir, "diff", "--name-only", "--diff-filter=U")
+		abort(dir, strategy)
+		if conflicts == "" {
+			return fail(r, err)
+		}
+		r.Status, r.Conflicts = StatusConflict, strings.Split(conflicts, "\n")
+		return r
+	}
+
+	commit, err := git.Output(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return fail(r, err)
+	}
+	r.Status, r.Commit = StatusSynced, commit
+	return r
+}
+
+// abort stops an interrupted rebase or merge, restoring the worktree
+func abort(dir, strategy string) {
+	if strategy == StrategyRebase {
+		git.Output(dir, "rebase", "--abort")
+		return
+	}
+	git.Output(dir, "merge", "--abort")
+}
+
+func fail(r Result, err error) Result {
+	r.Status, r.Error = StatusError, err.Error()
+	return r
+}
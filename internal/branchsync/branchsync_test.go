@@ -0,0 +1,110 @@
+package branchsync
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/iguchi/devhive/internal/git/gittest"
+)
+
+// setupTestRepo creates a repository where main has moved on since feat/a,
+// feat/b and feat/c branched off. feat/c conflicts with main in shared.txt.
+// Each branch is checked out in its own worktree under wt/.
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := gittest.NewRepo(t)
+	gittest.Write(t, dir, "shared.txt", "one\n")
+	gittest.Commit(t, dir, "initial")
+
+	for _, name := range []string{"a", "b", "c"} {
+		wt := filepath.Join(dir, "wt", name)
+		gittest.Run(t, dir, "worktree", "add", "-q", "-b", "feat/"+name, wt)
+		if name == "c" {
+			gittest.Write(t, wt, "shared.txt", "from c\n")
+		} else {
+			gittest.Write(t, wt, name+".txt", name+"\n")
+		}
+		gittest.Commit(t, wt, name)
+	}
+
+	gittest.Write(t, dir, "shared.txt", "from main\n")
+	gittest.Commit(t, dir, "main")
+
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	r := Check(filepath.Join(dir, "wt", "a"), "main")
+	if r.Status != StatusBehind || r.Branch != "feat/a" || r.Ahead != 1 || r.Behind != 1 || r.Dirty {
+		t.Errorf("Expected feat/a 1 ahead / 1 behind, got %+v", r)
+	}
+
+	if r := Check(filepath.Join(dir, "wt", "a"), "nope"); r.Status != StatusError || r.Error == "" {
+		t.Errorf("Expected an error for a missing base, got %+v", r)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := setupTestRepo(t)
+	wtA, wtB, wtC := filepath.Join(dir, "wt", "a"), filepath.Join(dir, "wt", "b"), filepath.Join(dir, "wt", "c")
+
+	// Rebase: main's commit is now below feat/a's
+	r := Sync(wtA, "main", StrategyRebase)
+	if r.Status != StatusSynced || r.Behind != 1 || r.Commit == "" {
+		t.Fatalf("Expected feat/a to be rebased, got %+v", r)
+	}
+	if after := Check(wtA, "main"); after.Status != StatusUpToDate || after.Ahead != 1 {
+		t.Errorf("Expected feat/a up to date and 1 ahead after rebase, got %+v", after)
+	}
+	if out := gittest.Run(t, wtA, "rev-list", "--count", "--merges", "HEAD"); out != "0" {
+		t.Errorf("Expected no merge commits after a rebase, got %s", out)
+	}
+
+	// Merge: a merge commit brings main in
+	if r := Sync(wtB, "main", StrategyMerge); r.Status != StatusSynced {
+		t.Fatalf("Expected feat/b to be merged with main, got %+v", r)
+	}
+	if out := gittest.Run(t, wtB, "rev-list", "--count", "--merges", "HEAD"); out != "1" {
+		t.Errorf("Expected a merge commit, got %s", out)
+	}
+
+	// Conflicts abort and leave the worktree as it was
+	head := gittest.Run(t, wtC, "rev-parse", "HEAD")
+	for _, strategy := range []string{StrategyRebase, StrategyMerge} {
+		r := Sync(wtC, "main", strategy)
+		if r.Status != StatusConflict || !reflect.DeepEqual(r.Conflicts, []string{"shared.txt"}) {
+			t.Errorf("%s: expected a conflict in shared.txt, got %+v", strategy, r)
+		}
+		if now := gittest.Run(t, wtC, "rev-parse", "HEAD"); now != head {
+			t.Errorf("%s: expected HEAD to be unchanged", strategy)
+		}
+		if status := gittest.Run(t, wtC, "status", "--porcelain"); status != "" {
+			t.Errorf("%s: expected a clean worktree after abort, got %q", strategy, status)
+		}
+	}
+}
+
+func TestSyncSkipsDirty(t *testing.T) {
+	dir := setupTestRepo(t)
+	wt := filepath.Join(dir, "wt", "a")
+	gittest.Write(t, wt, "a.txt", "edited\n")
+	gittest.Write(t, wt, "untracked.txt", "new\n")
+
+	head := gittest.Run(t, wt, "rev-parse", "HEAD")
+	r := Sync(wt, "main", StrategyRebase)
+	if r.Status != StatusDirty || !r.Dirty || r.Behind != 1 {
+		t.Errorf("Expected a dirty worktree to be skipped, got %+v", r)
+	}
+	if now := gittest.Run(t, wt, "rev-parse", "HEAD"); now != head {
+		t.Error("Expected HEAD to be unchanged")
+	}
+
+	// Untracked files alone do not block a sync
+	gittest.Run(t, wt, "checkout", "--", "a.txt")
+	if r := Sync(wt, "main", StrategyRebase); r.Status != StatusSynced {
+		t.Errorf("Expected untracked files not to block a sync, got %+v", r)
+	}
+}
@@ -0,0 +1,109 @@
+// Package checks runs verification commands (lint, test, build...) in a worktree.
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// MaxOutput is how much output (the end of it) is kept per check
+const MaxOutput = 64 * 1024
+
+// DefaultTimeout is how long a check may run when Check.Timeout is not set
+const DefaultTimeout = 10 * time.Minute
+
+// Check is a named shell command
+type Check struct {
+	Name    string
+	Run     string
+	Timeout time.Duration // Killed after this long (0: DefaultTimeout)
+	Stdin   []byte        // Written to the command's stdin (nil: no input)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name     string        `json:"name"`
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	ExitCode int           `json:"exit_code"` // -1 if the command could not be run or timed out
+	Output   string        `json:"output"`    // stdout and stderr, truncated to the last MaxOutput bytes
+	Duration time.Duration `json:"duration"`
+}
+
+// Run runs a check with the shell (sh -c, cmd /C on Windows) in dir.
+// env is added to the current environment. The command is killed after
+// the check's timeout.
+func Run(dir string, c Check, env []string) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", c.Run)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", c.Run)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if c.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(c.Stdin)
+	}
+	// Commands left running in the background (cmd &) keep the output open
+	cmd.WaitDelay = time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// Exited successfully, only the background process was cut off
+		err = nil
+	}
+	result := Result{
+		Name:     c.Name,
+		Command:  c.Run,
+		Passed:   err == nil,
+		Duration: time.Since(start).Round(time.Millisecond),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		result.ExitCode = -1
+		out.WriteString("killed after " + timeout.String() + "\n")
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.ExitCode = -1
+		out.WriteString(err.Error() + "\n")
+	}
+
+	output := out.String()
+	if len(output) > MaxOutput {
+		output = "...\n" + output[len(output)-MaxOutput:]
+	}
+	result.Output = output
+	return result
+}
+
+// Tail returns the last n lines of output
+func Tail(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+package checks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.txt"), []byte("ok\n"), 0644)
+
+	result := Run(dir, Check{Name: "cat", Run: "cat go.txt && echo $CHECK_VAR"}, []string{"CHECK_VAR=set"})
+	if !result.Passed || result.ExitCode != 0 || result.Output != "ok\nset\n" || result.Name != "cat" {
+		t.Errorf("Expected a passing check in dir with env, got %+v", result)
+	}
+
+	result = Run(dir, Check{Name: "fail", Run: "echo broken >&2; exit 3"}, nil)
+	if result.Passed || result.ExitCode != 3 || !strings.Contains(result.Output, "broken") {
+		t.Errorf("Expected exit code 3 with stderr, got %+v", result)
+	}
+
+	result = Run(dir, Check{Name: "stdin", Run: "cat", Stdin: []byte(`{"hook":"on_complete"}`)}, nil)
+	if !result.Passed || result.Output != `{"hook":"on_complete"}` {
+		t.Errorf("Expected the input on stdin, got %+v", result)
+	}
+
+	result = Run(filepath.Join(dir, "missing"), Check{Name: "nodir", Run: "true"}, nil)
+	if result.Passed || result.ExitCode != -1 || result.Output == "" {
+		t.Errorf("Expected a check that cannot start to fail, got %+v", result)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	start := time.Now()
+	result := Run(t.TempDir(), Check{Name: "slow", Run: "echo started; sleep 10", Timeout: 100 * time.Millisecond}, nil)
+	if result.Passed || result.ExitCode != -1 || !strings.Contains(result.Output, "started") || !strings.Contains(result.Output, "killed after 100ms") {
+		t.Errorf("Expected the check to be killed, got %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Run to return soon after the timeout, took %s", elapsed)
+	}
+
+	// A background process holding the output does not block Run
+	start = time.Now()
+	result = Run(t.TempDir(), Check{Name: "bg", Run: "sleep 10 & echo done"}, nil)
+	if !result.Passed || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected Run to return without waiting for the background process, got %+v after %s", result, time.Since(start))
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := Tail("a\nb\nc\nd\n", 2); got != "c\nd" {
+		t.Errorf("Tail = %q, want %q", got, "c\nd")
+	}
+	if got := Tail("a\n", 5); got != "a" {
+		t.Errorf("Tail = %q, want %q", got, "a")
+	}
+}
@@ -0,0 +1,271 @@
+// Package conflicts predicts merge conflicts between worker branches.
+//
+// Branches are merged in memory with `git merge-tree --write-tree` (git 2.38+),
+// so no working copy is touched. Besides textual conflicts, it reports paths
+// that two branches both modify but that still merge cleanly: the overlap is
+// where the next edit is likely to conflict.
+package conflicts
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/git"
+)
+
+// Branch is a worker's branch (Worker is empty for the base branch)
+type Branch struct {
+	Worker string `json:"worker,omitempty"`
+	Branch string `json:"branch"`
+}
+
+// Name returns the worker name, or the branch for the base branch
+func (b Branch) Name() string {
+	if b.Worker != "" {
+		return b.Worker
+	}
+	return b.Branch
+}
+
+// Hunk is a conflicting region of a file
+type Hunk struct {
+	Line   int      `json:"line"`   // First line of the region (counted on A's side of the merge)
+	Ours   []string `json:"ours"`   // A's lines
+	Theirs []string `json:"theirs"` // B's lines
+}
+
+// File is a path that will conflict
+type File struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"` // contents, modify/delete, add/add, rename/delete, binary, ...
+	Hunks []Hunk `json:"hunks,omitempty"`
+}
+
+// Conflict is what was found between two branches
+type Conflict struct {
+	A       Branch   `json:"a"`
+	B       Branch   `json:"b"`
+	Files   []File   `json:"files,omitempty"`   // Textual conflicts
+	Overlap []string `json:"overlap,omitempty"` // Paths both branches modify that merge cleanly
+}
+
+// HasConflicts reports whether the branches will not merge cleanly
+func (c Conflict) HasConflicts() bool {
+	return len(c.Files) > 0
+}
+
+// Paths returns the conflicting paths
+func (c Conflict) Paths() []string {
+	var paths []string
+	for _, f := range c.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// Predict checks every pair of branches and each branch against base
+// (skipped if base is empty). Only pairs with conflicts or overlapping paths
+// are returned. Branches that do not exist are skipped; a base that does not
+// exist is an error.
+func Predict(repo, base string, branches []Branch) ([]Conflict, error) {
+	if base != "" {
+		if _, err := git.Output(repo, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("base branch not found: %s", base)
+		}
+	}
+
+	var existing []Branch
+	for _, b := range branches {
+		if _, err := git.Output(repo, "rev-parse", "--verify", "--quiet", "refs/heads/"+b.Branch+"^{commit}"); err == nil {
+			existing = append(existing, b)
+		}
+	}
+
+	var results []Conflict
+	for i, a := range existing {
+		for _, b := range existing[i+1:] {
+			c, err := Check(repo, a, b, true)
+			if err != nil {
+				return results, err
+			}
+			if c.HasConflicts() || len(c.Overlap) > 0 {
+				results = append(results, c)
+			}
+		}
+	}
+
+	if base != "" {
+		baseBranch := Branch{Branch: base}
+		for _, a := range existing {
+			if a.Branch == base {
+				continue
+			}
+			c, err := Check(repo, a, baseBranch, false)
+			if err != nil {
+				return results, err
+			}
+			if c.HasConflicts() {
+				results = append(results, c)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Check merges b into a in memory and returns the conflicting files and,
+// with overlap, the other paths both branches modify
+func Check(repo string, a, b Branch, overlap bool) (Conflict, error) {
+	c := Conflict{A: a, B: b}
+
+	out, err := mergeTree(repo, a.Branch, b.Branch)
+	if err != nil {
+		return c, err
+	}
+	tree, types := parseMergeTree(out)
+	for _, path := range sortedKeys(types) {
+		f := File{Path: path, Type: types[path]}
+		if f.Type == "contents" {
+			if content, err := git.Output(repo, "cat-file", "-p", tree+":"+path); err == nil {
+				f.Hunks = ParseHunks(content)
+			}
+		}
+		c.Files = append(c.Files, f)
+	}
+
+	if overlap {
+		ours, err := changedPaths(repo, b.Branch, a.Branch)
+		if err != nil {
+			return c, err
+		}
+		theirs, err := changedPaths(repo, a.Branch, b.Branch)
+		if err != nil {
+			return c, err
+		}
+		for path := range ours {
+			if theirs[path] && types[path] == "" {
+				c.Overlap = append(c.Overlap, path)
+			}
+		}
+		sort.Strings(c.Overlap)
+	}
+	return c, nil
+}
+
+// mergeTree runs `git merge-tree --write-tree -z`. Exit status 1 means the
+// merge has conflicts, anything else non-zero is an error.
+func mergeTree(repo, a, b string) (string, error) {
+	cmd := exec.Command("git", "-C", repo, "merge-tree", "--write-tree", "-z", "--", a, b)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			return "", fmt.Errorf("git merge-tree %s %s: %s", a, b, msg)
+		}
+	}
+	return stdout.String(), nil
+}
+
+// parseMergeTree parses `git merge-tree --write-tree -z` output into the
+// result tree and the conflict type of each conflicting path
+func parseMergeTree(out string) (string, map[string]string) {
+	fields := strings.Split(out, "\x00")
+	tree := fields[0]
+	types := map[string]string{}
+
+	// Conflicted file info ("<mode> <object> <stage>\t<path>"), up to an empty field
+	i := 1
+	for ; i < len(fields) && fields[i] != ""; i++ {
+		if tab := strings.IndexByte(fields[i], '\t'); tab >= 0 {
+			types[fields[i][tab+1:]] = "contents"
+		}
+	}
+
+	// Informational messages: <count> <path>... <type> <message>
+	for i++; i < len(fields); {
+		var n int
+		if _, err := fmt.Sscanf(fields[i], "%d", &n); err != nil || i+n+2 >= len(fields) {
+			break
+		}
+		paths := fields[i+1 : i+1+n]
+		kind := fields[i+1+n]
+		i += n + 3
+		if !strings.HasPrefix(kind, "CONFLICT (") {
+			continue
+		}
+		kind = strings.TrimSuffix(strings.TrimPrefix(kind, "CONFLICT ("), ")")
+		for _, p := range paths {
+			// Keep the first (most specific) type reported for a path
+			if t, ok := types[p]; !ok || t == "contents" {
+				types[p] = kind
+			}
+		}
+	}
+	return tree, types
+}
+
+// ParseHunks returns the regions between conflict markers in merged content
+func ParseHunks(content string) []Hunk {
+	var hunks []Hunk
+	var current *Hunk
+	side := ""
+	line := 0
+	for _, text := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(text, "<<<<<<< ") || text == "<<<<<<<":
+			current, side = &Hunk{Line: line + 1}, "ours"
+			continue
+		case current != nil && (strings.HasPrefix(text, "||||||| ") || text == "|||||||"):
+			side = "base"
+			continue
+		case current != nil && text == "=======":
+			side = "theirs"
+			continue
+		case current != nil && (strings.HasPrefix(text, ">>>>>>> ") || text == ">>>>>>>"):
+			hunks = append(hunks, *current)
+			current, side = nil, ""
+			continue
+		}
+		switch side {
+		case "ours":
+			current.Ours = append(current.Ours, text)
+			line++
+		case "theirs":
+			current.Theirs = append(current.Theirs, text)
+		case "base":
+		default:
+			line++
+		}
+	}
+	return hunks
+}
+
+// changedPaths returns the paths branch modifies since it forked from other
+func changedPaths(repo, other, branch string) (map[string]bool, error) {
+	out, err := git.Output(repo, "diff", "--name-only", "--no-renames", other+"..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{}
+	for _, p := range strings.Split(out, "\n") {
+		if p != "" {
+			paths[p] = true
+		}
+	}
+	return paths, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package conflicts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iguchi/devhive/internal/git/gittest"
+)
+
+// setupTestRepo creates a repository where feat/a and feat/b conflict in
+// shared.txt and both touch notes.txt without conflicting, feat/c touches an
+// unrelated file and main has moved on in main.txt, conflicting with feat/c
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := gittest.NewRepo(t)
+	write := func(name, content string) { gittest.Write(t, dir, name, content) }
+
+	write("shared.txt", "one\ntwo\nthree\n")
+	write("notes.txt", "1\n2\n3\n4\n5\n6\n7\n8\n")
+	write("main.txt", "base\n")
+	gittest.Commit(t, dir, "initial")
+
+	gittest.Run(t, dir, "checkout", "-q", "-b", "feat/a", "main")
+	write("shared.txt", "one\nfrom a\nthree\n")
+	write("notes.txt", "1 a\n2\n3\n4\n5\n6\n7\n8\n")
+	gittest.Commit(t, dir, "a")
+
+	gittest.Run(t, dir, "checkout", "-q", "-b", "feat/b", "main")
+	write("shared.txt", "one\nfrom b\nthree\n")
+	write("notes.txt", "1\n2\n3\n4\n5\n6\n7\n8 b\n")
+	gittest.Commit(t, dir, "b")
+
+	gittest.Run(t, dir, "checkout", "-q", "-b", "feat/c", "main")
+	write("main.txt", "from c\n")
+	gittest.Commit(t, dir, "c")
+
+	gittest.Run(t, dir, "checkout", "-q", "main")
+	write("main.txt", "moved on\n")
+	gittest.Commit(t, dir, "main")
+
+	return dir
+}
+
+func TestPredict(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	results, err := Predict(dir, "main", []Branch{
+		{Worker: "a", Branch: "feat/a"},
+		{Worker: "b", Branch: "feat/b"},
+		{Worker: "c", Branch: "feat/c"},
+		{Worker: "missing", Branch: "feat/missing"},
+	})
+	if err != nil {
+		t.Fatalf("Predict failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+
+	ab := results[0]
+	if ab.A.Worker != "a" || ab.B.Worker != "b" {
+		t.Fatalf("Expected a/b first, got %s/%s", ab.A.Name(), ab.B.Name())
+	}
+	if !reflect.DeepEqual(ab.Paths(), []string{"shared.txt"}) {
+		t.Errorf("Expected shared.txt to conflict, got %v", ab.Paths())
+	}
+	if f := ab.Files[0]; f.Type != "contents" || len(f.Hunks) != 1 {
+		t.Errorf("Expected one content hunk, got %+v", f)
+	} else if h := f.Hunks[0]; h.Line != 2 || !reflect.DeepEqual(h.Ours, []string{"from a"}) || !reflect.DeepEqual(h.Theirs, []string{"from b"}) {
+		t.Errorf("Unexpected hunk: %+v", h)
+	}
+	if !reflect.DeepEqual(ab.Overlap, []string{"notes.txt"}) {
+		t.Errorf("Expected notes.txt to overlap, got %v", ab.Overlap)
+	}
+
+	cBase := results[1]
+	if cBase.A.Worker != "c" || cBase.B.Branch != "main" || cBase.B.Name() != "main" {
+		t.Fatalf("Expected c against main, got %s/%s", cBase.A.Name(), cBase.B.Name())
+	}
+	if !reflect.DeepEqual(cBase.Paths(), []string{"main.txt"}) {
+		t.Errorf("Expected main.txt to conflict with main, got %v", cBase.Paths())
+	}
+
+	if _, err := Predict(dir, "develop", []Branch{{Worker: "a", Branch: "feat/a"}}); err == nil || !strings.Contains(err.Error(), "develop") {
+		t.Errorf("Expected an error for a missing base branch, got %v", err)
+	}
+}
+
+func TestCheckModifyDelete(t *testing.T) {
+	dir := setupTestRepo(t)
+	gittest.Run(t, dir, "checkout", "-q", "-b", "feat/d", "main")
+	gittest.Run(t, dir, "rm", "-q", "shared.txt")
+	gittest.Commit(t, dir, "d")
+	gittest.Run(t, dir, "checkout", "-q", "main")
+
+	c, err := Check(dir, Branch{Worker: "a", Branch: "feat/a"}, Branch{Worker: "d", Branch: "feat/d"}, true)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(c.Files) != 1 || c.Files[0].Path != "shared.txt" || c.Files[0].Type != "modify/delete" {
+		t.Errorf("Expected a modify/delete conflict in shared.txt, got %+v", c.Files)
+	}
+	if len(c.Overlap) != 0 {
+		t.Errorf("Conflicting paths must not be reported as overlap, got %v", c.Overlap)
+	}
+}
+
+func TestParseHunks(t *testing.T) {
+	content := strings.Join([]string{
+		"a",
+		"<<<<<<< feat/a",
+		"ours 1",
+		"ours 2",
+		"||||||| base",
+		"base",
+		"=======",
+		"theirs",
+		">>>>>>> feat/b",
+		"b",
+		"<<<<<<< feat/a",
+		"=======",
+		"added",
+		">>>>>>> feat/b",
+	}, "\n")
+
+	hunks := ParseHunks(content)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+	if hunks[0].Line != 2 || len(hunks[0].Ours) != 2 || len(hunks[0].Theirs) != 1 {
+		t.Errorf("Unexpected first hunk: %+v", hunks[0])
+	}
+	if hunks[1].Line != 5 || len(hunks[1].Ours) != 0 || hunks[1].Theirs[0] != "added" {
+		t.Errorf("Unexpected second hunk: %+v", hunks[1])
+	}
+}
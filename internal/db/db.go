@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,7 +34,7 @@ func DetectProject() string {
 	}
 
 	// 3. Use project root directory name
-	if root := findProjectRoot(); root != "" {
+	if root := FindProjectRoot(); root != "" {
 		return filepath.Base(root)
 	}
 
@@ -47,12 +48,11 @@ var composeFiles = []string{".devhive.yaml", ".devhive.yml", "devhive.yaml", "de
 
 // findComposeProject searches for .devhive.yaml and extracts the project name
 func findComposeProject() string {
-	cwd, err := os.Getwd()
-	if err != nil {
+	dir := searchStart()
+	if dir == "" {
 		return ""
 	}
 
-	dir := cwd
 	for {
 		for _, filename := range composeFiles {
 			configFile := filepath.Join(dir, filename)
@@ -84,13 +84,11 @@ func findComposeProject() string {
 	return ""
 }
 
-
-
 // DefaultDBPath returns the default database path
 // DB is stored in <project>/.devhive/devhive.db
 func DefaultDBPath() string {
 	// Find project root (where .devhive.yaml is)
-	projectRoot := findProjectRoot()
+	projectRoot := FindProjectRoot()
 	if projectRoot != "" {
 		return filepath.Join(projectRoot, ".devhive", "devhive.db")
 	}
@@ -100,14 +98,29 @@ func DefaultDBPath() string {
 	return filepath.Join(cwd, ".devhive", "devhive.db")
 }
 
-// findProjectRoot finds the directory containing .devhive.yaml
-func findProjectRoot() string {
+// searchStart returns the directory to start searching for the project from.
+// Inside a worktree at <project>/.devhive/worktrees/<name> this is the main
+// checkout, so commands run there share the project's database even when the
+// worktree has its own copy of .devhive.yaml.
+func searchStart() string {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return ""
 	}
+	marker := string(filepath.Separator) + filepath.Join(".devhive", "worktrees") + string(filepath.Separator)
+	if i := strings.LastIndex(cwd+string(filepath.Separator), marker); i >= 0 {
+		return cwd[:i]
+	}
+	return cwd
+}
+
+// FindProjectRoot finds the directory containing .devhive.yaml
+func FindProjectRoot() string {
+	dir := searchStart()
+	if dir == "" {
+		return ""
+	}
 
-	dir := cwd
 	for {
 		for _, filename := range composeFiles {
 			configFile := filepath.Join(dir, filename)
@@ -133,6 +146,10 @@ func GetProjectName() string {
 // DB wraps the database connection
 type DB struct {
 	conn *sql.DB
+	path string
+
+	// eventHooks are called for every event this process logs
+	eventHooks []func(Event)
 }
 
 // Open opens or creates the database
@@ -156,7 +173,7 @@ func Open(path string) (*DB, error) {
 	conn.Exec("PRAGMA journal_mode=WAL")
 	conn.Exec("PRAGMA busy_timeout=5000")
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, path: path}
 	if err := db.init(); err != nil {
 		conn.Close()
 		return nil, err
@@ -203,6 +220,32 @@ func (db *DB) migrate() error {
 		}
 	}
 
+	// Migration: Add pending_reason column to workers if not exists
+	if !db.columnExists("workers", "pending_reason") {
+		_, err := db.conn.Exec(`ALTER TABLE workers ADD COLUMN pending_reason TEXT`)
+		if err != nil {
+			return fmt.Errorf("failed to add pending_reason column: %w", err)
+		}
+	}
+
+	// Migration: Add git monitoring columns to workers if not exists
+	gitColumns := []struct{ name, ddl string }{
+		{"last_commit_at", "TIMESTAMP"},
+		{"commits_ahead", "INTEGER DEFAULT 0"},
+		{"insertions", "INTEGER DEFAULT 0"},
+		{"deletions", "INTEGER DEFAULT 0"},
+		{"uncommitted", "INTEGER DEFAULT 0"},
+		{"monitored_at", "TIMESTAMP"},
+	}
+	for _, col := range gitColumns {
+		if !db.columnExists("workers", col.name) {
+			_, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE workers ADD COLUMN %s %s", col.name, col.ddl))
+			if err != nil {
+				return fmt.Errorf("failed to add %s column: %w", col.name, err)
+			}
+		}
+	}
+
 	// Migration: Add new message types for communication commands
 	db.conn.Exec(`INSERT OR IGNORE INTO message_types (name, description) VALUES
 		('help', 'Help request'),
@@ -217,6 +260,54 @@ func (db *DB) migrate() error {
 	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
 		('branch_merged', 'Branch was merged')`)
 
+	// Migration: Add goal column to sprints if not exists
+	if !db.columnExists("sprints", "goal") {
+		_, err := db.conn.Exec(`ALTER TABLE sprints ADD COLUMN goal TEXT`)
+		if err != nil {
+			return fmt.Errorf("failed to add goal column: %w", err)
+		}
+	}
+
+	// Migration: Add reply_to/thread_id columns to messages if not exists
+	if !db.columnExists("messages", "thread_id") {
+		for _, col := range []string{"reply_to", "thread_id"} {
+			if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE messages ADD COLUMN %s INTEGER", col)); err != nil {
+				return fmt.Errorf("failed to add %s column: %w", col, err)
+			}
+		}
+		// Existing messages each start their own thread
+		db.conn.Exec("UPDATE messages SET thread_id = id WHERE thread_id IS NULL")
+	}
+	db.conn.Exec("CREATE INDEX IF NOT EXISTS idx_messages_thread ON messages(thread_id)")
+
+	// Migration: Add sprint_aborted event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('sprint_aborted', 'Sprint was aborted')`)
+
+	// Migration: Add worker_stalled event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('worker_stalled', 'Worker was detected as stalled')`)
+
+	// Migration: Add merge_failed event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('merge_failed', 'Branch could not be merged')`)
+
+	// Migration: Add conflict_predicted event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('conflict_predicted', 'Conflict between worker branches was predicted')`)
+
+	// Migration: Add worker_checked event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('worker_checked', 'Worker checks were run')`)
+
+	// Migration: Add branch_synced event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('branch_synced', 'Worker branch was updated from the base branch')`)
+
+	// Migration: Add worktree_setup event type
+	db.conn.Exec(`INSERT OR IGNORE INTO event_types (name, description) VALUES
+		('worktree_setup', 'Worker worktree setup was run')`)
+
 	return nil
 }
 
@@ -254,47 +345,113 @@ func (db *DB) Close() error {
 
 // Sprint represents a sprint
 type Sprint struct {
-	ID          string
-	Status      string
-	StartedAt   time.Time
-	CompletedAt *time.Time
+	ID          string     `json:"id"`
+	Status      string     `json:"status"` // active/completed/aborted
+	Goal        string     `json:"goal"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
 }
 
 // Worker represents a worker
 // Note: branch, role, tool, task are defined in .devhive.yaml (not stored in DB)
 type Worker struct {
-	Name           string
-	SprintID       string
-	Status         string // pending/working/completed/blocked/error
-	SessionState   string // running/waiting_permission/idle/stopped
-	Progress       int    // 0-100 progress percentage
-	Activity       string // Current activity description
-	LastCommit     string
-	ErrorCount     int
-	LastError      string
-	UpdatedAt      time.Time
-	UnreadMessages int
+	Name           string    `json:"name"`
+	SprintID       string    `json:"sprint_id"`
+	Status         string    `json:"status"`         // pending/working/completed/blocked/error
+	SessionState   string    `json:"session_state"`  // running/waiting_permission/idle/stopped
+	Progress       int       `json:"progress"`       // 0-100 progress percentage
+	Activity       string    `json:"activity"`       // Current activity description
+	PendingReason  string    `json:"pending_reason"` // Why a pending worker is held back (e.g., unmet dependency)
+	LastCommit     string    `json:"last_commit"`
+	ErrorCount     int       `json:"error_count"`
+	LastError      string    `json:"last_error"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UnreadMessages int       `json:"unread_messages"`
+
+	// Observed git state (filled by the monitor, independent of self-reported progress)
+	LastCommitAt *time.Time `json:"last_commit_at"`
+	CommitsAhead int        `json:"commits_ahead"` // Commits ahead of the base branch
+	Insertions   int        `json:"insertions"`
+	Deletions    int        `json:"deletions"`
+	Uncommitted  int        `json:"uncommitted"` // Files with uncommitted changes
+	MonitoredAt  *time.Time `json:"monitored_at"`
+
+	Ports map[string]int `json:"ports,omitempty"` // Assigned ports by name
+}
+
+// GitState holds git facts observed in a worker's worktree
+type GitState struct {
+	LastCommit   string     `json:"last_commit"`
+	LastCommitAt *time.Time `json:"last_commit_at"`
+	CommitsAhead int        `json:"commits_ahead"`
+	Insertions   int        `json:"insertions"`
+	Deletions    int        `json:"deletions"`
+	Uncommitted  int        `json:"uncommitted"`
 }
 
 // Message represents a message
 type Message struct {
-	ID          int
-	FromWorker  string
-	ToWorker    string
-	MessageType string
-	Subject     string
-	Content     string
-	ReadAt      *time.Time
-	CreatedAt   time.Time
+	ID          int        `json:"id"`
+	FromWorker  string     `json:"from_worker"`
+	ToWorker    string     `json:"to_worker"`
+	MessageType string     `json:"message_type"`
+	Subject     string     `json:"subject"`
+	Content     string     `json:"content"`
+	ReplyTo     int        `json:"reply_to"`  // ID of the message this replies to (0: none)
+	ThreadID    int        `json:"thread_id"` // ID of the first message in the conversation
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ReplySubject derives the subject of a reply to the message
+func (m *Message) ReplySubject() string {
+	subject := m.Subject
+	if subject == "" {
+		subject = "💬 Reply"
+	}
+	if !strings.HasPrefix(subject, "Re: ") {
+		subject = "Re: " + subject
+	}
+	return subject
 }
 
 // Event represents an event
 type Event struct {
-	ID        int
-	EventType string
-	Worker    string
-	Data      string
-	CreatedAt time.Time
+	ID        int       `json:"id"`
+	EventType string    `json:"event_type"`
+	Worker    string    `json:"worker"`
+	Data      string    `json:"data"` // JSON-encoded details
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarshalJSON emits Data as a JSON object instead of an encoded string
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	data := json.RawMessage("null")
+	if e.Data != "" && json.Valid([]byte(e.Data)) {
+		data = json.RawMessage(e.Data)
+	}
+	return json.Marshal(struct {
+		event
+		Data json.RawMessage `json:"data"`
+	}{event(e), data})
+}
+
+// UnmarshalJSON is the inverse of MarshalJSON
+func (e *Event) UnmarshalJSON(b []byte) error {
+	type event Event
+	var v struct {
+		event
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*e = Event(v.event)
+	if len(v.Data) > 0 && string(v.Data) != "null" {
+		e.Data = string(v.Data)
+	}
+	return nil
 }
 
 // ============================================
@@ -316,20 +473,42 @@ func (db *DB) logEvent(eventType, worker string, data map[string]interface{}) er
 		s := string(b)
 		dataJSON = &s
 	}
-	_, err := db.conn.Exec(
-		"INSERT INTO events (event_type, worker, data) VALUES (?, ?, ?)",
-		eventType, nullString(worker), dataJSON,
-	)
-	return err
+	return db.insertEvent(eventType, worker, dataJSON)
 }
 
 // LogEvent logs an event with JSON string data (public)
 func (db *DB) LogEvent(eventType, worker, dataJSON string) error {
-	_, err := db.conn.Exec(
+	return db.insertEvent(eventType, worker, nullString(dataJSON))
+}
+
+// insertEvent inserts an event row, runs the event hooks and wakes up subscribers
+func (db *DB) insertEvent(eventType, worker string, dataJSON *string) error {
+	result, err := db.conn.Exec(
 		"INSERT INTO events (event_type, worker, data) VALUES (?, ?, ?)",
-		eventType, nullString(worker), nullString(dataJSON),
+		eventType, nullString(worker), dataJSON,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if len(db.eventHooks) > 0 {
+		id, _ := result.LastInsertId()
+		e := Event{ID: int(id), EventType: eventType, Worker: worker, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+		if dataJSON != nil {
+			e.Data = *dataJSON
+		}
+		for _, hook := range db.eventHooks {
+			hook(e)
+		}
+	}
+	db.notifySubscribers()
+	return nil
+}
+
+// OnEvent registers a function called synchronously for every event logged
+// through this DB (not for events logged by other processes)
+func (db *DB) OnEvent(hook func(Event)) {
+	db.eventHooks = append(db.eventHooks, hook)
 }
 
 // checkRowsAffected verifies that at least one row was affected by an update/delete
@@ -347,6 +526,11 @@ func checkRowsAffected(result sql.Result, entityType, name string) error {
 
 // CreateSprint creates a new sprint
 func (db *DB) CreateSprint(id string) error {
+	return db.CreateSprintWithGoal(id, "")
+}
+
+// CreateSprintWithGoal creates a new sprint with an optional goal
+func (db *DB) CreateSprintWithGoal(id, goal string) error {
 	// Check for existing active sprint
 	var existing string
 	err := db.conn.QueryRow("SELECT id FROM sprints WHERE status = 'active' LIMIT 1").Scan(&existing)
@@ -354,36 +538,121 @@ func (db *DB) CreateSprint(id string) error {
 		return fmt.Errorf("active sprint already exists: %s", existing)
 	}
 
-	_, err = db.conn.Exec("INSERT INTO sprints (id) VALUES (?)", id)
+	_, err = db.conn.Exec("INSERT INTO sprints (id, goal) VALUES (?, ?)", id, nullString(goal))
 	if err != nil {
 		return err
 	}
 
-	return db.logEvent("sprint_created", "", map[string]interface{}{"sprint_id": id})
+	data := map[string]interface{}{"sprint_id": id}
+	if goal != "" {
+		data["goal"] = goal
+	}
+	return db.logEvent("sprint_created", "", data)
+}
+
+// NewSprintID returns an unused sprint ID
+// With a preferred name, returns it (or name-2, name-3, ... if taken);
+// otherwise returns the date plus a counter (e.g., 20250118-01)
+func (db *DB) NewSprintID(preferred string) (string, error) {
+	exists := func(id string) (bool, error) {
+		var n int
+		err := db.conn.QueryRow("SELECT COUNT(*) FROM sprints WHERE id = ?", id).Scan(&n)
+		return n > 0, err
+	}
+
+	if preferred != "" {
+		for i := 1; ; i++ {
+			id := preferred
+			if i > 1 {
+				id = fmt.Sprintf("%s-%d", preferred, i)
+			}
+			taken, err := exists(id)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return id, nil
+			}
+		}
+	}
+
+	date := time.Now().Format("20060102")
+	for i := 1; ; i++ {
+		id := fmt.Sprintf("%s-%02d", date, i)
+		taken, err := exists(id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return id, nil
+		}
+	}
+}
+
+// sprintSelectColumns defines the standard columns for sprint queries
+const sprintSelectColumns = "id, status, COALESCE(goal, ''), started_at, completed_at"
+
+// scanSprint scans a sprint row into a Sprint struct
+func scanSprint(scanner interface{ Scan(...interface{}) error }) (Sprint, error) {
+	var s Sprint
+	var completedAt sql.NullTime
+	err := scanner.Scan(&s.ID, &s.Status, &s.Goal, &s.StartedAt, &completedAt)
+	if completedAt.Valid {
+		s.CompletedAt = &completedAt.Time
+	}
+	return s, err
 }
 
 // GetActiveSprint returns the active sprint
 func (db *DB) GetActiveSprint() (*Sprint, error) {
 	row := db.conn.QueryRow(`
-		SELECT id, status, started_at, completed_at
+		SELECT ` + sprintSelectColumns + `
 		FROM sprints WHERE status = 'active' ORDER BY started_at DESC LIMIT 1
 	`)
 
-	var s Sprint
-	var completedAt sql.NullTime
-	err := row.Scan(&s.ID, &s.Status, &s.StartedAt, &completedAt)
+	s, err := scanSprint(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	if completedAt.Valid {
-		s.CompletedAt = &completedAt.Time
+	return &s, nil
+}
+
+// GetSprint returns a sprint by ID (nil if not found)
+func (db *DB) GetSprint(id string) (*Sprint, error) {
+	row := db.conn.QueryRow("SELECT "+sprintSelectColumns+" FROM sprints WHERE id = ?", id)
+
+	s, err := scanSprint(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 	return &s, nil
 }
 
+// ListSprints returns all sprints, newest first
+func (db *DB) ListSprints() ([]Sprint, error) {
+	rows, err := db.conn.Query("SELECT " + sprintSelectColumns + " FROM sprints ORDER BY started_at DESC, rowid DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sprints []Sprint
+	for rows.Next() {
+		s, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, s)
+	}
+	return sprints, nil
+}
+
 // CompleteSprint completes the active sprint and all its workers
 func (db *DB) CompleteSprint() (string, error) {
 	sprint, err := db.GetActiveSprint()
@@ -400,12 +669,7 @@ func (db *DB) CompleteSprint() (string, error) {
 		return "", err
 	}
 
-	// Complete sprint
-	_, err = db.conn.Exec(
-		"UPDATE sprints SET status = 'completed', completed_at = CURRENT_TIMESTAMP WHERE id = ?",
-		sprint.ID,
-	)
-	if err != nil {
+	if err := db.endSprint(sprint.ID, "completed"); err != nil {
 		return "", err
 	}
 
@@ -413,6 +677,43 @@ func (db *DB) CompleteSprint() (string, error) {
 	return sprint.ID, nil
 }
 
+// AbortSprint ends the active sprint without completing its workers
+func (db *DB) AbortSprint() (string, error) {
+	sprint, err := db.GetActiveSprint()
+	if err != nil || sprint == nil {
+		return "", fmt.Errorf("no active sprint")
+	}
+
+	if err := db.endSprint(sprint.ID, "aborted"); err != nil {
+		return "", err
+	}
+
+	db.logEvent("sprint_aborted", "", map[string]interface{}{"sprint_id": sprint.ID})
+	return sprint.ID, nil
+}
+
+// sprintWorkerColumns are the worker columns kept in the sprint_workers snapshot
+const sprintWorkerColumns = `name, sprint_id, status, session_state, progress, activity, pending_reason,
+	last_commit, last_commit_at, commits_ahead, insertions, deletions, uncommitted, monitored_at,
+	error_count, last_error, updated_at`
+
+// endSprint snapshots the sprint's workers and marks the sprint as ended
+func (db *DB) endSprint(id, status string) error {
+	_, err := db.conn.Exec(
+		"INSERT OR REPLACE INTO sprint_workers ("+sprintWorkerColumns+") SELECT "+sprintWorkerColumns+" FROM workers WHERE sprint_id = ?",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sprint workers: %w", err)
+	}
+
+	_, err = db.conn.Exec(
+		"UPDATE sprints SET status = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ?",
+		status, id,
+	)
+	return err
+}
+
 // ============================================
 // Worker Operations
 // ============================================
@@ -420,16 +721,38 @@ func (db *DB) CompleteSprint() (string, error) {
 // workerSelectColumns defines the standard columns for worker queries
 const workerSelectColumns = `
 	w.name, w.sprint_id, w.status, COALESCE(w.session_state, 'stopped'),
-	COALESCE(w.progress, 0), COALESCE(w.activity, ''),
+	COALESCE(w.progress, 0), COALESCE(w.activity, ''), COALESCE(w.pending_reason, ''),
 	COALESCE(w.last_commit, ''), w.error_count, COALESCE(w.last_error, ''), w.updated_at,
-	(SELECT COUNT(*) FROM messages m WHERE m.to_worker = w.name AND m.read_at IS NULL)`
+	(SELECT COUNT(*) FROM messages m WHERE m.to_worker = w.name AND m.read_at IS NULL),
+	w.last_commit_at, COALESCE(w.commits_ahead, 0), COALESCE(w.insertions, 0),
+	COALESCE(w.deletions, 0), COALESCE(w.uncommitted, 0), w.monitored_at,
+	(SELECT COALESCE(GROUP_CONCAT(p.name || '=' || p.port), '') FROM worker_ports p WHERE p.worker = w.name)`
 
 // scanWorker scans a worker row into a Worker struct
 func scanWorker(scanner interface{ Scan(...interface{}) error }) (Worker, error) {
 	var w Worker
+	var lastCommitAt, monitoredAt sql.NullTime
+	var ports string
 	err := scanner.Scan(&w.Name, &w.SprintID, &w.Status, &w.SessionState,
-		&w.Progress, &w.Activity, &w.LastCommit, &w.ErrorCount, &w.LastError,
-		&w.UpdatedAt, &w.UnreadMessages)
+		&w.Progress, &w.Activity, &w.PendingReason, &w.LastCommit, &w.ErrorCount, &w.LastError,
+		&w.UpdatedAt, &w.UnreadMessages,
+		&lastCommitAt, &w.CommitsAhead, &w.Insertions, &w.Deletions, &w.Uncommitted, &monitoredAt,
+		&ports)
+	if lastCommitAt.Valid {
+		w.LastCommitAt = &lastCommitAt.Time
+	}
+	if monitoredAt.Valid {
+		w.MonitoredAt = &monitoredAt.Time
+	}
+	// name=port,name=port
+	for _, pair := range strings.Split(ports, ",") {
+		if name, port, ok := strings.Cut(pair, "="); ok {
+			if w.Ports == nil {
+				w.Ports = make(map[string]int)
+			}
+			w.Ports[name], _ = strconv.Atoi(port)
+		}
+	}
 	return w, err
 }
 
@@ -456,6 +779,11 @@ func (db *DB) UpdateWorkerStatus(name, status string, lastCommit *string) error
 	query := "UPDATE workers SET status = ?, updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{status}
 
+	// A worker that leaves pending is no longer held back
+	if status != "pending" {
+		query += ", pending_reason = NULL"
+	}
+
 	if lastCommit != nil {
 		query += ", last_commit = ?"
 		args = append(args, *lastCommit)
@@ -474,6 +802,18 @@ func (db *DB) UpdateWorkerStatus(name, status string, lastCommit *string) error
 	return db.logEvent("worker_status_changed", name, map[string]interface{}{"status": status})
 }
 
+// SetWorkerPendingReason records why a pending worker is held back (empty clears it)
+func (db *DB) SetWorkerPendingReason(name, reason string) error {
+	result, err := db.conn.Exec(
+		"UPDATE workers SET pending_reason = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?",
+		nullString(reason), name,
+	)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, "worker", name)
+}
+
 // ReportWorkerError reports an error and sets worker to error status
 func (db *DB) ReportWorkerError(name, message string) error {
 	result, err := db.conn.Exec(
@@ -522,6 +862,60 @@ func (db *DB) UpdateWorkerProgress(name string, progress int, activity string) e
 	return db.logEvent("worker_progress_updated", name, map[string]interface{}{"progress": progress, "activity": activity})
 }
 
+// ReportWorkerStalled records that a watch rule fired for a worker
+func (db *DB) ReportWorkerStalled(name, rule, message string) error {
+	return db.logEvent("worker_stalled", name, map[string]interface{}{"rule": rule, "message": message})
+}
+
+// ReportConflictPredicted records that a worker was warned about conflicts
+// (files) or overlapping edits (overlap) with another worker or the base branch
+func (db *DB) ReportConflictPredicted(name, with string, files, overlap []string) error {
+	return db.logEvent("conflict_predicted", name, map[string]interface{}{"with": with, "files": files, "overlap": overlap})
+}
+
+// ReportWorkerChecked records that a worker's checks were run
+func (db *DB) ReportWorkerChecked(name string, passed bool, failed []string) error {
+	return db.logEvent("worker_checked", name, map[string]interface{}{"passed": passed, "failed": failed})
+}
+
+// ReportBranchSynced records that a worker's branch was rebased onto or
+// merged with the base branch
+func (db *DB) ReportBranchSynced(name, base, strategy string, behind int, commit string) error {
+	return db.logEvent("branch_synced", name, map[string]interface{}{
+		"base": base, "strategy": strategy, "behind": behind, "commit": commit,
+	})
+}
+
+// ReportWorktreeSetup records a run of a worker's worktree setup: the steps
+// done in this run and, if it stopped, the failed step and its error
+func (db *DB) ReportWorktreeSetup(name string, steps []string, failed, message string) error {
+	data := map[string]interface{}{"passed": failed == "", "steps": steps}
+	if failed != "" {
+		data["failed"] = failed
+		data["error"] = message
+	}
+	return db.logEvent("worktree_setup", name, data)
+}
+
+// UpdateWorkerGitState stores git facts observed in a worker's worktree
+// Does not touch updated_at, which tracks self-reported changes
+func (db *DB) UpdateWorkerGitState(name string, state GitState) error {
+	var lastCommitAt interface{}
+	if state.LastCommitAt != nil {
+		lastCommitAt = state.LastCommitAt.UTC()
+	}
+	result, err := db.conn.Exec(`
+		UPDATE workers SET last_commit = ?, last_commit_at = ?, commits_ahead = ?,
+			insertions = ?, deletions = ?, uncommitted = ?, monitored_at = CURRENT_TIMESTAMP
+		WHERE name = ?
+	`, nullString(state.LastCommit), lastCommitAt, state.CommitsAhead,
+		state.Insertions, state.Deletions, state.Uncommitted, name)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, "worker", name)
+}
+
 // GetWorker returns a worker by name
 func (db *DB) GetWorker(name string) (*Worker, error) {
 	row := db.conn.QueryRow(`
@@ -543,7 +937,7 @@ func (db *DB) GetWorker(name string) (*Worker, error) {
 // GetAllWorkers returns all workers for active sprint
 func (db *DB) GetAllWorkers() ([]Worker, error) {
 	rows, err := db.conn.Query(`
-		SELECT `+workerSelectColumns+`
+		SELECT ` + workerSelectColumns + `
 		FROM workers w
 		WHERE w.sprint_id = (SELECT id FROM sprints WHERE status = 'active' ORDER BY started_at DESC LIMIT 1)
 		ORDER BY w.name
@@ -564,13 +958,67 @@ func (db *DB) GetAllWorkers() ([]Worker, error) {
 	return workers, nil
 }
 
-// DeleteWorker removes a worker from the database
+// GetSprintWorkers returns the workers of a sprint
+// Ended sprints use the snapshot taken when they ended
+func (db *DB) GetSprintWorkers(sprintID string) ([]Worker, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+workerSelectColumns+`
+		FROM sprint_workers w
+		WHERE w.sprint_id = ?
+		ORDER BY w.name
+	`, sprintID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workers []Worker
+	for rows.Next() {
+		w, err := scanWorker(rows)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, w)
+	}
+	if len(workers) > 0 {
+		return workers, nil
+	}
+
+	// Active sprint (or ended before snapshots existed)
+	rows, err = db.conn.Query(`
+		SELECT `+workerSelectColumns+`
+		FROM workers w
+		WHERE w.sprint_id = ?
+		ORDER BY w.name
+	`, sprintID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWorker(rows)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, w)
+	}
+	return workers, nil
+}
+
+// DeleteWorker removes a worker from the database, releasing its ports
 func (db *DB) DeleteWorker(name string) error {
 	result, err := db.conn.Exec("DELETE FROM workers WHERE name = ?", name)
 	if err != nil {
 		return err
 	}
-	return checkRowsAffected(result, "worker", name)
+	if err := checkRowsAffected(result, "worker", name); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec("DELETE FROM worker_ports WHERE worker = ?", name); err != nil {
+		return err
+	}
+	return db.ResetSetupSteps(name)
 }
 
 // GetAllWorkerNames returns all worker names for active sprint
@@ -599,68 +1047,132 @@ func (db *DB) GetAllWorkerNames() ([]string, error) {
 // Message Operations
 // ============================================
 
-// SendMessage sends a message to a specific worker
+// SendMessage sends a message to a specific worker (starting a new thread)
 func (db *DB) SendMessage(from, to, msgType, subject, content string) (int64, error) {
-	result, err := db.conn.Exec(`
-		INSERT INTO messages (from_worker, to_worker, message_type, subject, content)
-		VALUES (?, ?, ?, ?, ?)
-	`, from, to, msgType, nullString(subject), content)
+	id, err := db.insertMessage(from, to, msgType, subject, content, 0, 0)
 	if err != nil {
 		return 0, err
 	}
 
-	db.logEvent("message_sent", from, map[string]interface{}{"to": to, "type": msgType})
+	db.logEvent("message_sent", from, map[string]interface{}{"to": to, "type": msgType, "message_id": id})
 
-	return result.LastInsertId()
+	return id, nil
 }
 
-// BroadcastMessage sends a message to all workers (expanded to individual messages)
-func (db *DB) BroadcastMessage(from, msgType, subject, content string) (int, error) {
-	workers, err := db.GetAllWorkerNames()
-	if err != nil {
-		return 0, err
-	}
-
-	count := 0
-	for _, worker := range workers {
-		if worker == from {
-			continue // Don't send to self
-		}
-		_, err := db.conn.Exec(`
-			INSERT INTO messages (from_worker, to_worker, message_type, subject, content)
-			VALUES (?, ?, ?, ?, ?)
-		`, from, worker, msgType, nullString(subject), content)
+// SendMessageToMany sends the same message to each recipient and logs a
+// single message_sent event recording the target (e.g., "@backend") and the
+// actual recipients
+func (db *DB) SendMessageToMany(from, target string, recipients []string, msgType, subject, content string) ([]int64, error) {
+	var ids []int64
+	for _, to := range recipients {
+		id, err := db.insertMessage(from, to, msgType, subject, content, 0, 0)
 		if err != nil {
-			return count, err
+			return ids, err
 		}
-		count++
+		ids = append(ids, id)
 	}
 
-	db.logEvent("message_broadcast", from, map[string]interface{}{"type": msgType, "count": count})
+	db.logEvent("message_sent", from, map[string]interface{}{
+		"to": target, "recipients": recipients, "type": msgType,
+	})
 
-	return count, nil
+	return ids, nil
 }
 
-// GetUnreadMessages returns unread messages for a worker
-func (db *DB) GetUnreadMessages(worker string) ([]Message, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, from_worker, to_worker, message_type, COALESCE(subject, ''),
-		       content, read_at, created_at
-		FROM messages
-		WHERE to_worker = ? AND read_at IS NULL
-		ORDER BY created_at ASC
-	`, worker)
+// ReplyToMessage replies to a message, keeping it in the same thread
+// The reply is sent by the original recipient to the original sender,
+// and the original message is marked as read. Only the recipient (from)
+// may reply.
+func (db *DB) ReplyToMessage(id int, from, msgType, subject, content string) (*Message, error) {
+	parent, err := db.GetMessage(id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	if parent == nil {
+		return nil, fmt.Errorf("message not found: #%d", id)
+	}
+	if parent.ToWorker != from {
+		return nil, fmt.Errorf("message #%d was sent to %s, not %s", parent.ID, parent.ToWorker, from)
+	}
 
-	var messages []Message
-	for rows.Next() {
-		var m Message
+	replyID, err := db.insertMessage(parent.ToWorker, parent.FromWorker, msgType, subject, content, parent.ID, parent.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	db.MarkMessageRead(parent.ID)
+
+	db.logEvent("message_sent", parent.ToWorker, map[string]interface{}{
+		"to": parent.FromWorker, "type": msgType, "reply_to": parent.ID, "thread_id": parent.ThreadID, "message_id": replyID,
+	})
+
+	return db.GetMessage(int(replyID))
+}
+
+// insertMessage stores a message; threadID 0 starts a new thread
+func (db *DB) insertMessage(from, to, msgType, subject, content string, replyTo, threadID int) (int64, error) {
+	var replyToValue, threadValue interface{}
+	if replyTo > 0 {
+		replyToValue = replyTo
+	}
+	if threadID > 0 {
+		threadValue = threadID
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO messages (from_worker, to_worker, message_type, subject, content, reply_to, thread_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, from, to, msgType, nullString(subject), content, replyToValue, threadValue)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if threadID == 0 {
+		if _, err := db.conn.Exec("UPDATE messages SET thread_id = id WHERE id = ?", id); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// BroadcastMessage sends a message to all workers (expanded to individual messages)
+func (db *DB) BroadcastMessage(from, msgType, subject, content string) (int, error) {
+	workers, err := db.GetAllWorkerNames()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, worker := range workers {
+		if worker == from {
+			continue // Don't send to self
+		}
+		if _, err := db.insertMessage(from, worker, msgType, subject, content, 0, 0); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	db.logEvent("message_broadcast", from, map[string]interface{}{"type": msgType, "count": count})
+
+	return count, nil
+}
+
+// messageSelectColumns defines the standard columns for message queries
+const messageSelectColumns = `id, from_worker, to_worker, message_type, COALESCE(subject, ''),
+	content, COALESCE(reply_to, 0), COALESCE(thread_id, id), read_at, created_at`
+
+// scanMessages scans all rows into Message structs
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	var messages []Message
+	for rows.Next() {
+		var m Message
 		var readAt sql.NullTime
 		err := rows.Scan(&m.ID, &m.FromWorker, &m.ToWorker, &m.MessageType, &m.Subject,
-			&m.Content, &readAt, &m.CreatedAt)
+			&m.Content, &m.ReplyTo, &m.ThreadID, &readAt, &m.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -672,6 +1184,57 @@ func (db *DB) GetUnreadMessages(worker string) ([]Message, error) {
 	return messages, nil
 }
 
+// GetUnreadMessages returns unread messages for a worker
+func (db *DB) GetUnreadMessages(worker string) ([]Message, error) {
+	return db.GetMessages(worker, false)
+}
+
+// GetMessages returns messages for a worker, optionally including read ones
+func (db *DB) GetMessages(worker string, includeRead bool) ([]Message, error) {
+	query := "SELECT " + messageSelectColumns + " FROM messages WHERE to_worker = ?"
+	if !includeRead {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at ASC, id ASC"
+
+	rows, err := db.conn.Query(query, worker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// GetMessage returns a message by ID (nil if not found)
+func (db *DB) GetMessage(id int) (*Message, error) {
+	rows, err := db.conn.Query("SELECT "+messageSelectColumns+" FROM messages WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// GetThread returns all messages in a thread, oldest first
+func (db *DB) GetThread(threadID int) ([]Message, error) {
+	rows, err := db.conn.Query(
+		"SELECT "+messageSelectColumns+" FROM messages WHERE COALESCE(thread_id, id) = ? ORDER BY id ASC",
+		threadID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
 // MarkMessageRead marks a message as read
 func (db *DB) MarkMessageRead(id int) error {
 	_, err := db.conn.Exec(
@@ -727,7 +1290,7 @@ func (db *DB) GetRecentEvents(limit int, eventType, worker *string) ([]Event, er
 		args = append(args, *worker)
 	}
 
-	query += " ORDER BY created_at DESC LIMIT ?"
+	query += " ORDER BY id DESC LIMIT ?"
 	args = append(args, limit)
 
 	rows, err := db.conn.Query(query, args...)
@@ -739,17 +1302,69 @@ func (db *DB) GetRecentEvents(limit int, eventType, worker *string) ([]Event, er
 	return scanEvents(rows)
 }
 
-// GetEventsSince returns events since a given ID
-func (db *DB) GetEventsSince(lastID int, eventType *string) ([]Event, error) {
+// GetSprintEvents returns recent events that happened during a sprint
+// The range is bounded by the sprint's lifecycle events, falling back to its timestamps
+func (db *DB) GetSprintEvents(sprintID string, limit int, filter EventFilter) ([]Event, error) {
+	boundary := func(agg, types string) (sql.NullInt64, error) {
+		var id sql.NullInt64
+		err := db.conn.QueryRow(
+			"SELECT "+agg+"(id) FROM events WHERE event_type IN ("+types+") AND json_extract(data, '$.sprint_id') = ?",
+			sprintID,
+		).Scan(&id)
+		return id, err
+	}
+	firstID, err := boundary("MIN", "'sprint_created'")
+	if err != nil {
+		return nil, err
+	}
+	lastID, err := boundary("MAX", "'sprint_completed', 'sprint_aborted'")
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + eventSelectColumns + " FROM events WHERE 1=1"
+	args := []interface{}{}
+	if firstID.Valid {
+		query += " AND id >= ?"
+		args = append(args, firstID.Int64)
+	} else {
+		query += " AND created_at >= (SELECT started_at FROM sprints WHERE id = ?)"
+		args = append(args, sprintID)
+	}
+	if lastID.Valid {
+		query += " AND id <= ?"
+		args = append(args, lastID.Int64)
+	} else {
+		query += " AND created_at <= COALESCE((SELECT completed_at FROM sprints WHERE id = ?), CURRENT_TIMESTAMP)"
+		args = append(args, sprintID)
+	}
+
+	clause, filterArgs := filter.where()
+	query += clause
+	args = append(args, filterArgs...)
+
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// GetEventsSince returns events after a given ID that match the filter, in ID order
+func (db *DB) GetEventsSince(lastID int, filter EventFilter) ([]Event, error) {
 	query := "SELECT " + eventSelectColumns + " FROM events WHERE id > ?"
 	args := []interface{}{lastID}
 
-	if eventType != nil {
-		query += " AND event_type LIKE ?"
-		args = append(args, *eventType+"%")
-	}
+	clause, filterArgs := filter.where()
+	query += clause
+	args = append(args, filterArgs...)
 
-	query += " ORDER BY created_at ASC"
+	query += " ORDER BY id ASC"
 
 	rows, err := db.conn.Query(query, args...)
 	if err != nil {
@@ -760,6 +1375,57 @@ func (db *DB) GetEventsSince(lastID int, eventType *string) ([]Event, error) {
 	return scanEvents(rows)
 }
 
+// GetLastWorkerEventTime returns the time of a worker's latest event,
+// ignoring the given event types. Returns nil if there is none.
+func (db *DB) GetLastWorkerEventTime(worker string, excludeTypes ...string) (*time.Time, error) {
+	query := "SELECT created_at FROM events WHERE worker = ?"
+	args := []interface{}{worker}
+	for _, t := range excludeTypes {
+		query += " AND event_type != ?"
+		args = append(args, t)
+	}
+	query += " ORDER BY id DESC LIMIT 1"
+
+	var t time.Time
+	err := db.conn.QueryRow(query, args...).Scan(&t)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CountWorkerEventsSince counts a worker's events of a type created at or after since
+func (db *DB) CountWorkerEventsSince(worker, eventType string, since time.Time) (int, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM events WHERE worker = ? AND event_type = ? AND created_at >= ?",
+		worker, eventType, since.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&count)
+	return count, err
+}
+
+// GetEventTypes returns the names of all event types
+func (db *DB) GetEventTypes() ([]string, error) {
+	rows, err := db.conn.Query("SELECT name FROM event_types ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		types = append(types, name)
+	}
+	return types, nil
+}
+
 // GetLastEventID returns the ID of the most recent event
 func (db *DB) GetLastEventID() (int, error) {
 	var id int
@@ -846,3 +1512,415 @@ func (db *DB) CleanupOldMessages(days int, dryRun bool) (int, error) {
 
 	return count, nil
 }
+
+// ============================================
+// Webhook Deliveries
+// ============================================
+
+// Delivery is a webhook POST of an event
+type Delivery struct {
+	ID            int        `json:"id"`
+	EventID       int        `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Worker        string     `json:"worker"`
+	URL           string     `json:"url"`
+	Payload       string     `json:"-"`
+	Status        string     `json:"status"` // pending/sending/delivered/failed
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+}
+
+// DeliveryClaimTimeout is how long a delivery may stay 'sending' before
+// another process takes it over (the sender is assumed to have died)
+var DeliveryClaimTimeout = 2 * time.Minute
+
+const deliverySelectColumns = `
+	d.id, d.event_id, e.event_type, COALESCE(e.worker, ''), d.url, d.payload, d.status,
+	d.attempts, COALESCE(d.last_error, ''), d.next_attempt_at, d.created_at, d.delivered_at`
+
+func scanDelivery(scanner interface{ Scan(...interface{}) error }) (Delivery, error) {
+	var d Delivery
+	var nextAttemptAt, deliveredAt sql.NullTime
+	err := scanner.Scan(&d.ID, &d.EventID, &d.EventType, &d.Worker, &d.URL, &d.Payload, &d.Status,
+		&d.Attempts, &d.LastError, &nextAttemptAt, &d.CreatedAt, &deliveredAt)
+	if nextAttemptAt.Valid {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, err
+}
+
+// EnqueueDelivery queues a webhook POST of an event, due immediately
+func (db *DB) EnqueueDelivery(eventID int, url, payload string) (int64, error) {
+	result, err := db.conn.Exec(
+		"INSERT INTO webhook_deliveries (event_id, url, payload) VALUES (?, ?, ?)",
+		eventID, url, payload,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ClaimDueDeliveries marks up to limit due deliveries as 'sending' and returns
+// them. A delivery is claimed by one process only.
+func (db *DB) ClaimDueDeliveries(limit int) ([]Delivery, error) {
+	stale := time.Now().Add(-DeliveryClaimTimeout).UTC().Format("2006-01-02 15:04:05")
+	rows, err := db.conn.Query(`
+		SELECT `+deliverySelectColumns+`
+		FROM webhook_deliveries d JOIN events e ON e.id = d.event_id
+		WHERE (d.status = 'pending' AND d.next_attempt_at <= datetime('now'))
+			OR (d.status = 'sending' AND d.updated_at <= ?)
+		ORDER BY d.id
+		LIMIT ?
+	`, stale, limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+
+	var claimed []Delivery
+	for _, d := range due {
+		ok, err := db.claimDelivery(d.ID, stale)
+		if err != nil {
+			return claimed, err
+		}
+		if ok {
+			d.Status = "sending"
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// ClaimDelivery marks a delivery as 'sending' and returns it, if it is due
+// and no other process has claimed it (nil otherwise)
+func (db *DB) ClaimDelivery(id int) (*Delivery, error) {
+	stale := time.Now().Add(-DeliveryClaimTimeout).UTC().Format("2006-01-02 15:04:05")
+	ok, err := db.claimDelivery(id, stale)
+	if err != nil || !ok {
+		return nil, err
+	}
+	d, err := scanDelivery(db.conn.QueryRow(`
+		SELECT `+deliverySelectColumns+`
+		FROM webhook_deliveries d JOIN events e ON e.id = d.event_id
+		WHERE d.id = ?
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// claimDelivery marks a due delivery as 'sending'. Reports whether this call claimed it.
+func (db *DB) claimDelivery(id int, stale string) (bool, error) {
+	result, err := db.conn.Exec(`
+		UPDATE webhook_deliveries SET status = 'sending', updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND ((status = 'pending' AND next_attempt_at <= datetime('now'))
+			OR (status = 'sending' AND updated_at <= ?))
+	`, id, stale)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+// CompleteDelivery records a successful attempt
+func (db *DB) CompleteDelivery(id int) error {
+	result, err := db.conn.Exec(`
+		UPDATE webhook_deliveries SET status = 'delivered', attempts = attempts + 1, last_error = NULL,
+			next_attempt_at = NULL, delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, "delivery", fmt.Sprint(id))
+}
+
+// FailDelivery records a failed attempt. The delivery is retried at retryAt,
+// or marked 'failed' for good if retryAt is nil.
+func (db *DB) FailDelivery(id int, message string, retryAt *time.Time) error {
+	status, next := "failed", interface{}(nil)
+	if retryAt != nil {
+		status, next = "pending", retryAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	result, err := db.conn.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = attempts + 1, last_error = ?,
+			next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, message, next, id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, "delivery", fmt.Sprint(id))
+}
+
+// RetryFailedDeliveries queues failed deliveries again. Returns the count.
+func (db *DB) RetryFailedDeliveries() (int64, error) {
+	result, err := db.conn.Exec(`
+		UPDATE webhook_deliveries SET status = 'pending', attempts = 0,
+			next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'failed'
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetDeliveries returns recent deliveries, newest first (status "" for all)
+func (db *DB) GetDeliveries(limit int, status string) ([]Delivery, error) {
+	query := `SELECT ` + deliverySelectColumns + `
+		FROM webhook_deliveries d JOIN events e ON e.id = d.event_id`
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE d.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY d.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ============================================
+// Check Results
+// ============================================
+
+// CheckResult is the outcome of a check run in a worker's worktree
+type CheckResult struct {
+	ID         int       `json:"id"`
+	Worker     string    `json:"worker"`
+	SprintID   string    `json:"sprint_id"`
+	Name       string    `json:"name"`
+	Command    string    `json:"command"`
+	Passed     bool      `json:"passed"`
+	ExitCode   int       `json:"exit_code"`
+	Output     string    `json:"output"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SaveCheckResult stores a check result under the worker's current sprint
+func (db *DB) SaveCheckResult(r CheckResult) (int64, error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO check_results (worker, sprint_id, name, command, passed, exit_code, output, duration_ms)
+		SELECT name, sprint_id, ?, ?, ?, ?, ?, ? FROM workers WHERE name = ?
+	`, r.Name, r.Command, r.Passed, r.ExitCode, r.Output, r.DurationMs, r.Worker)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkRowsAffected(result, "worker", r.Worker); err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetCheckResults returns the latest result of each check of a worker in
+// its current sprint, in the order they were run
+func (db *DB) GetCheckResults(worker string) ([]CheckResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, worker, COALESCE(sprint_id, ''), name, command, passed, COALESCE(exit_code, 0),
+			COALESCE(output, ''), COALESCE(duration_ms, 0), created_at
+		FROM check_results
+		WHERE id IN (
+			SELECT MAX(c.id) FROM check_results c JOIN workers w ON w.name = c.worker AND w.sprint_id = c.sprint_id
+			WHERE c.worker = ? GROUP BY c.name
+		)
+		ORDER BY id
+	`, worker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []CheckResult
+	for rows.Next() {
+		var r CheckResult
+		if err := rows.Scan(&r.ID, &r.Worker, &r.SprintID, &r.Name, &r.Command, &r.Passed, &r.ExitCode,
+			&r.Output, &r.DurationMs, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// ============================================
+// Worker Ports
+// ============================================
+
+// WorkerPort is a port assigned to a worker
+type WorkerPort struct {
+	Worker string `json:"worker"`
+	Name   string `json:"name"`
+	Port   int    `json:"port"`
+}
+
+// AllocatePorts assigns a port in [min, max] to each name of a worker.
+// Ports already assigned (and still in range) are kept; new ones are taken
+// as one contiguous block of the lowest free ports. Ports of names no
+// longer listed are released. Returns the ports in the order of names.
+func (db *DB) AllocatePorts(worker string, names []string, min, max int) ([]WorkerPort, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT worker, name, port FROM worker_ports")
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[int]bool)
+	assigned := make(map[string]int)
+	for rows.Next() {
+		var p WorkerPort
+		if err := rows.Scan(&p.Worker, &p.Name, &p.Port); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if p.Worker == worker {
+			assigned[p.Name] = p.Port
+			continue
+		}
+		used[p.Port] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM worker_ports WHERE worker = ?", worker); err != nil {
+		return nil, err
+	}
+
+	ports := make([]WorkerPort, len(names))
+	var missing []int
+	for i, name := range names {
+		ports[i] = WorkerPort{Worker: worker, Name: name}
+		if port, ok := assigned[name]; ok && port >= min && port <= max && !used[port] {
+			ports[i].Port = port
+			used[port] = true
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	if len(missing) > 0 {
+		start := -1
+		for s := min; s+len(missing)-1 <= max; s++ {
+			free := true
+			for p := s; p < s+len(missing); p++ {
+				if used[p] {
+					free, s = false, p // Continue after the used port
+					break
+				}
+			}
+			if free {
+				start = s
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("no %d free port(s) left in %d-%d", len(missing), min, max)
+		}
+		for j, i := range missing {
+			ports[i].Port = start + j
+		}
+	}
+
+	for _, p := range ports {
+		if _, err := tx.Exec("INSERT INTO worker_ports (worker, name, port) VALUES (?, ?, ?)", p.Worker, p.Name, p.Port); err != nil {
+			return nil, err
+		}
+	}
+	return ports, tx.Commit()
+}
+
+// GetWorkerPorts returns the ports assigned to a worker, by port
+func (db *DB) GetWorkerPorts(worker string) ([]WorkerPort, error) {
+	rows, err := db.conn.Query("SELECT worker, name, port FROM worker_ports WHERE worker = ? ORDER BY port", worker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ports []WorkerPort
+	for rows.Next() {
+		var p WorkerPort
+		if err := rows.Scan(&p.Worker, &p.Name, &p.Port); err != nil {
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+	return ports, rows.Err()
+}
+
+// ============================================
+// Worktree Setup Operations
+// ============================================
+
+// GetSetupSteps returns the setup steps already done in a worker's worktree
+func (db *DB) GetSetupSteps(worker string) ([]string, error) {
+	rows, err := db.conn.Query("SELECT step FROM worktree_setup_steps WHERE worker = ? ORDER BY rowid", worker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []string
+	for rows.Next() {
+		var step string
+		if err := rows.Scan(&step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// MarkSetupStepDone records that a setup step succeeded in a worker's worktree
+func (db *DB) MarkSetupStepDone(worker, step string) error {
+	_, err := db.conn.Exec(
+		"INSERT OR REPLACE INTO worktree_setup_steps (worker, step) VALUES (?, ?)",
+		worker, step,
+	)
+	return err
+}
+
+// ResetSetupSteps forgets the setup steps of a worker (e.g. its worktree was recreated)
+func (db *DB) ResetSetupSteps(worker string) error {
+	_, err := db.conn.Exec("DELETE FROM worktree_setup_steps WHERE worker = ?", worker)
+	return err
+}
@@ -1,8 +1,11 @@
 package db
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -281,7 +284,7 @@ func TestEventOperations(t *testing.T) {
 	}
 
 	// Test GetEventsSince
-	events, err = db.GetEventsSince(0, nil)
+	events, err = db.GetEventsSince(0, EventFilter{})
 	if err != nil {
 		t.Fatalf("GetEventsSince failed: %v", err)
 	}
@@ -367,6 +370,34 @@ func TestProjectDetection(t *testing.T) {
 	}
 }
 
+func TestFindProjectRootFromWorktree(t *testing.T) {
+	root, err := os.MkdirTemp("", "devhive-root-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	// The worktree has its own copy of .devhive.yaml (committed to the repo)
+	worktree := filepath.Join(root, ".devhive", "worktrees", "fe")
+	os.MkdirAll(filepath.Join(worktree, "src"), 0755)
+	os.WriteFile(filepath.Join(root, ".devhive.yaml"), []byte("project: app\n"), 0644)
+	os.WriteFile(filepath.Join(worktree, ".devhive.yaml"), []byte("project: app\n"), 0644)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+
+	for _, dir := range []string{root, worktree, filepath.Join(worktree, "src")} {
+		os.Chdir(dir)
+		if got := FindProjectRoot(); got != root {
+			t.Errorf("From %s: expected project root %s, got %s", dir, root, got)
+		}
+	}
+	if got := DefaultDBPath(); got != filepath.Join(root, ".devhive", "devhive.db") {
+		t.Errorf("Expected the project database, got %s", got)
+	}
+}
+
 func TestWorkerUnreadMessages(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -415,3 +446,561 @@ func TestConcurrentAccess(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerGitState(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	commitAt := time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Second)
+	err := db.UpdateWorkerGitState("fe", GitState{
+		LastCommit:   "abc123",
+		LastCommitAt: &commitAt,
+		CommitsAhead: 3,
+		Insertions:   120,
+		Deletions:    4,
+		Uncommitted:  2,
+	})
+	if err != nil {
+		t.Fatalf("UpdateWorkerGitState failed: %v", err)
+	}
+
+	worker, _ := db.GetWorker("fe")
+	if worker.LastCommit != "abc123" {
+		t.Errorf("Expected last commit 'abc123', got '%s'", worker.LastCommit)
+	}
+	if worker.LastCommitAt == nil || !worker.LastCommitAt.Equal(commitAt) {
+		t.Errorf("Expected last commit at %v, got %v", commitAt, worker.LastCommitAt)
+	}
+	if worker.CommitsAhead != 3 || worker.Insertions != 120 || worker.Deletions != 4 || worker.Uncommitted != 2 {
+		t.Errorf("Unexpected git state: %+v", worker)
+	}
+	if worker.MonitoredAt == nil {
+		t.Error("Expected monitored_at to be set")
+	}
+
+	// Unknown worker
+	if err := db.UpdateWorkerGitState("nobody", GitState{}); err == nil {
+		t.Error("Expected error for unknown worker")
+	}
+}
+
+func TestWorkerStalledEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+	db.ReportWorkerError("fe", "build failed")
+	db.ReportWorkerError("fe", "build failed again")
+
+	count, err := db.CountWorkerEventsSince("fe", "worker_error", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CountWorkerEventsSince failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 errors, got %d", count)
+	}
+
+	if err := db.ReportWorkerStalled("fe", "stalled", "no activity for 30 minutes"); err != nil {
+		t.Fatalf("ReportWorkerStalled failed: %v", err)
+	}
+
+	last, err := db.GetLastWorkerEventTime("fe", "worker_stalled")
+	if err != nil {
+		t.Fatalf("GetLastWorkerEventTime failed: %v", err)
+	}
+	if last == nil {
+		t.Fatal("Expected last activity time")
+	}
+
+	eventType := "worker_stalled"
+	worker := "fe"
+	events, _ := db.GetRecentEvents(10, &eventType, &worker)
+	if len(events) != 1 {
+		t.Errorf("Expected 1 worker_stalled event, got %d", len(events))
+	}
+
+	// Unknown worker has no activity
+	if last, _ := db.GetLastWorkerEventTime("nobody"); last != nil {
+		t.Errorf("Expected nil for unknown worker, got %v", last)
+	}
+}
+
+func TestConflictPredictedEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	if err := db.ReportConflictPredicted("fe", "be", []string{"shared.txt"}, nil); err != nil {
+		t.Fatalf("ReportConflictPredicted failed: %v", err)
+	}
+
+	eventType := "conflict_predicted"
+	worker := "fe"
+	events, _ := db.GetRecentEvents(10, &eventType, &worker)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 conflict_predicted event, got %d", len(events))
+	}
+	var data struct {
+		With  string   `json:"with"`
+		Files []string `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(events[0].Data), &data); err != nil {
+		t.Fatalf("Invalid event data: %v", err)
+	}
+	if data.With != "be" || len(data.Files) != 1 || data.Files[0] != "shared.txt" {
+		t.Errorf("Unexpected event data: %s", events[0].Data)
+	}
+}
+
+func TestCheckResults(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	db.SaveCheckResult(CheckResult{Worker: "fe", Name: "lint", Command: "make lint", Passed: true})
+	db.SaveCheckResult(CheckResult{Worker: "fe", Name: "test", Command: "make test", ExitCode: 2, Output: "FAIL"})
+	db.SaveCheckResult(CheckResult{Worker: "fe", Name: "test", Command: "make test", Passed: true, DurationMs: 1500})
+	if _, err := db.SaveCheckResult(CheckResult{Worker: "ghost", Name: "lint", Command: "true"}); err == nil {
+		t.Error("Expected an error for an unknown worker")
+	}
+
+	results, err := db.GetCheckResults("fe")
+	if err != nil {
+		t.Fatalf("GetCheckResults failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "lint" || results[1].Name != "test" {
+		t.Fatalf("Expected the latest lint and test results, got %+v", results)
+	}
+	if !results[1].Passed || results[1].DurationMs != 1500 || results[1].SprintID != "sprint-01" {
+		t.Errorf("Expected the passing test run, got %+v", results[1])
+	}
+
+	// A new sprint starts without results
+	db.CompleteSprint()
+	db.CreateSprint("sprint-02")
+	db.RegisterWorker("fe", "sprint-02")
+	if results, _ := db.GetCheckResults("fe"); len(results) != 0 {
+		t.Errorf("Expected no results in the new sprint, got %+v", results)
+	}
+
+	if err := db.ReportWorkerChecked("fe", false, []string{"test"}); err != nil {
+		t.Fatalf("ReportWorkerChecked failed: %v", err)
+	}
+	eventType := "worker_checked"
+	if events, _ := db.GetRecentEvents(1, &eventType, nil); len(events) != 1 || events[0].Data != `{"failed":["test"],"passed":false}` {
+		t.Errorf("Expected a worker_checked event, got %+v", events)
+	}
+}
+
+func TestBranchSyncedEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	if err := db.ReportBranchSynced("fe", "main", "rebase", 2, "abc123"); err != nil {
+		t.Fatalf("ReportBranchSynced failed: %v", err)
+	}
+	eventType := "branch_synced"
+	events, _ := db.GetRecentEvents(1, &eventType, nil)
+	if len(events) != 1 || events[0].Worker != "fe" || events[0].Data != `{"base":"main","behind":2,"commit":"abc123","strategy":"rebase"}` {
+		t.Errorf("Expected a branch_synced event, got %+v", events)
+	}
+}
+
+func TestWorkerPendingReason(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	if err := db.SetWorkerPendingReason("fe", "waiting for be (completed)"); err != nil {
+		t.Fatalf("SetWorkerPendingReason failed: %v", err)
+	}
+	worker, _ := db.GetWorker("fe")
+	if worker.PendingReason != "waiting for be (completed)" {
+		t.Errorf("Expected pending reason, got '%s'", worker.PendingReason)
+	}
+
+	// Leaving pending clears the reason
+	db.UpdateWorkerStatus("fe", "working", nil)
+	worker, _ = db.GetWorker("fe")
+	if worker.PendingReason != "" {
+		t.Errorf("Expected pending reason to be cleared, got '%s'", worker.PendingReason)
+	}
+
+	if err := db.SetWorkerPendingReason("nobody", "x"); err == nil {
+		t.Error("Expected error for unknown worker")
+	}
+}
+
+func TestSprintLifecycle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Generated IDs are unique per day
+	id, err := db.NewSprintID("")
+	if err != nil {
+		t.Fatalf("NewSprintID failed: %v", err)
+	}
+	expected := time.Now().Format("20060102") + "-01"
+	if id != expected {
+		t.Errorf("Expected sprint ID '%s', got '%s'", expected, id)
+	}
+	if err := db.CreateSprintWithGoal(id, "Ship login"); err != nil {
+		t.Fatalf("CreateSprintWithGoal failed: %v", err)
+	}
+	db.RegisterWorker("fe", id)
+	db.UpdateWorkerProgress("fe", 80, "polishing")
+
+	if _, err := db.CompleteSprint(); err != nil {
+		t.Fatalf("CompleteSprint failed: %v", err)
+	}
+
+	next, _ := db.NewSprintID("")
+	if next != time.Now().Format("20060102")+"-02" {
+		t.Errorf("Expected second sprint ID, got '%s'", next)
+	}
+	if err := db.CreateSprint(next); err != nil {
+		t.Fatalf("CreateSprint after completion failed: %v", err)
+	}
+
+	// Reusing the worker in the new sprint keeps the old sprint's snapshot
+	db.RegisterWorker("fe", next)
+	workers, err := db.GetSprintWorkers(id)
+	if err != nil {
+		t.Fatalf("GetSprintWorkers failed: %v", err)
+	}
+	if len(workers) != 1 || workers[0].Status != "completed" || workers[0].Progress != 80 {
+		t.Errorf("Unexpected snapshot: %+v", workers)
+	}
+
+	// Abort keeps worker statuses
+	aborted, err := db.AbortSprint()
+	if err != nil {
+		t.Fatalf("AbortSprint failed: %v", err)
+	}
+	sprint, _ := db.GetSprint(aborted)
+	if sprint == nil || sprint.Status != "aborted" || sprint.CompletedAt == nil {
+		t.Errorf("Expected aborted sprint, got %+v", sprint)
+	}
+	workers, _ = db.GetSprintWorkers(aborted)
+	if len(workers) != 1 || workers[0].Status != "pending" {
+		t.Errorf("Unexpected snapshot after abort: %+v", workers)
+	}
+
+	sprints, err := db.ListSprints()
+	if err != nil {
+		t.Fatalf("ListSprints failed: %v", err)
+	}
+	if len(sprints) != 2 || sprints[0].ID != next || sprints[1].Goal != "Ship login" {
+		t.Errorf("Unexpected sprints: %+v", sprints)
+	}
+
+	// Preferred names get a suffix when taken
+	if id, _ := db.NewSprintID("sprint"); id != "sprint" {
+		t.Errorf("Expected 'sprint', got '%s'", id)
+	}
+	if id, _ := db.NewSprintID(next); id != next+"-2" {
+		t.Errorf("Expected '%s-2', got '%s'", next, id)
+	}
+
+	// Events are scoped to the sprint's time range
+	events, err := db.GetSprintEvents(id, 100, EventFilter{})
+	if err != nil {
+		t.Fatalf("GetSprintEvents failed: %v", err)
+	}
+	if len(events) == 0 {
+		t.Error("Expected events for the first sprint")
+	}
+	for _, e := range events {
+		if e.EventType == "sprint_aborted" || strings.Contains(e.Data, next) {
+			t.Errorf("Unexpected event from a later sprint: %+v", e)
+		}
+	}
+
+	if s, _ := db.GetSprint("missing"); s != nil {
+		t.Errorf("Expected nil for unknown sprint, got %+v", s)
+	}
+}
+
+func TestMessageThreads(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	id, err := db.SendMessage("fe", "pm", "help", "Help", "Stuck on auth")
+	if err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	root, _ := db.GetMessage(int(id))
+	if root == nil || root.ThreadID != int(id) || root.ReplyTo != 0 {
+		t.Fatalf("Expected message to start its own thread, got %+v", root)
+	}
+
+	// PM replies to the message, fe answers the reply
+	reply, err := db.ReplyToMessage(int(id), "pm", "reply", "Re: Help", "Use the session API")
+	if err != nil {
+		t.Fatalf("ReplyToMessage failed: %v", err)
+	}
+	if reply.FromWorker != "pm" || reply.ToWorker != "fe" || reply.ReplyTo != int(id) || reply.ThreadID != int(id) {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+	if _, err := db.ReplyToMessage(reply.ID, "fe", "reply", "", "Thanks"); err != nil {
+		t.Fatalf("ReplyToMessage failed: %v", err)
+	}
+
+	// Replying marks the original as read
+	unread, _ := db.GetUnreadMessages("pm")
+	if len(unread) != 1 || unread[0].Content != "Thanks" {
+		t.Errorf("Expected only the answer to be unread, got %+v", unread)
+	}
+	all, _ := db.GetMessages("pm", true)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 messages for pm, got %d", len(all))
+	}
+
+	thread, err := db.GetThread(int(id))
+	if err != nil {
+		t.Fatalf("GetThread failed: %v", err)
+	}
+	if len(thread) != 3 {
+		t.Errorf("Expected 3 messages in thread, got %d", len(thread))
+	}
+
+	// Another message starts a separate thread
+	other, _ := db.SendMessage("fe", "pm", "report", "", "Done")
+	if thread, _ := db.GetThread(int(other)); len(thread) != 1 {
+		t.Errorf("Expected separate thread, got %d messages", len(thread))
+	}
+
+	if _, err := db.ReplyToMessage(9999, "pm", "reply", "", "x"); err == nil {
+		t.Error("Expected error for unknown message")
+	}
+
+	// Only the recipient can reply
+	if _, err := db.ReplyToMessage(int(other), "fe", "reply", "", "x"); err == nil {
+		t.Error("Expected error when replying to a message sent to someone else")
+	}
+	if unread, _ := db.GetUnreadMessages("fe"); len(unread) != 0 {
+		t.Errorf("Expected the refused reply not to be sent, got %+v", unread)
+	}
+	if unread, _ := db.GetUnreadMessages("pm"); len(unread) != 2 {
+		t.Errorf("Expected the refused reply not to mark the message read, got %+v", unread)
+	}
+}
+
+func TestMessageReplySubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Help", "Re: Help"},
+		{"Re: Help", "Re: Help"},
+		{"", "Re: 💬 Reply"},
+	}
+	for _, tt := range tests {
+		m := Message{Subject: tt.subject}
+		if got := m.ReplySubject(); got != tt.want {
+			t.Errorf("ReplySubject(%q) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestSendMessageToMany(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+	db.RegisterWorker("mobile", "sprint-01")
+
+	ids, err := db.SendMessageToMany("be", "@frontend", []string{"fe", "mobile"}, "info", "API", "Contract changed")
+	if err != nil {
+		t.Fatalf("SendMessageToMany failed: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(ids))
+	}
+
+	for _, name := range []string{"fe", "mobile"} {
+		messages, _ := db.GetUnreadMessages(name)
+		if len(messages) != 1 || messages[0].FromWorker != "be" {
+			t.Errorf("Expected 1 message from be for %s, got %+v", name, messages)
+		}
+	}
+
+	eventType := "message_sent"
+	events, _ := db.GetRecentEvents(10, &eventType, nil)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 message_sent event, got %d", len(events))
+	}
+	if !strings.Contains(events[0].Data, `"recipients":["fe","mobile"]`) || !strings.Contains(events[0].Data, `"to":"@frontend"`) {
+		t.Errorf("Expected recipients in event data, got %s", events[0].Data)
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	e := Event{ID: 1, EventType: "worker_error", Worker: "fe", Data: `{"message":"boom"}`}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(b), `"data":{"message":"boom"}`) {
+		t.Errorf("Expected data as object, got %s", b)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.ID != 1 || decoded.Worker != "fe" || decoded.Data != `{"message":"boom"}` {
+		t.Errorf("Round trip mismatch: %+v", decoded)
+	}
+
+	e.Data = ""
+	b, _ = json.Marshal(e)
+	if !strings.Contains(string(b), `"data":null`) || !strings.Contains(string(b), `"event_type":"worker_error"`) {
+		t.Errorf("Unexpected JSON: %s", b)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	var logged []Event
+	db.OnEvent(func(e Event) { logged = append(logged, e) })
+	db.UpdateWorkerProgress("fe", 50, "")
+	if len(logged) != 1 || logged[0].ID == 0 || logged[0].EventType != "worker_progress_updated" {
+		t.Fatalf("Expected the hook to see the event with its ID, got %+v", logged)
+	}
+
+	id, err := db.EnqueueDelivery(logged[0].ID, "http://example.com/hook", `{"text":"hi"}`)
+	if err != nil {
+		t.Fatalf("EnqueueDelivery failed: %v", err)
+	}
+
+	// A delivery is claimed only once
+	claimed, err := db.ClaimDueDeliveries(10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != int(id) || claimed[0].Worker != "fe" || claimed[0].Payload != `{"text":"hi"}` {
+		t.Fatalf("Expected to claim the delivery, got %+v (%v)", claimed, err)
+	}
+	if again, _ := db.ClaimDueDeliveries(10); len(again) != 0 {
+		t.Errorf("Expected a claimed delivery not to be claimed again, got %+v", again)
+	}
+
+	// A scheduled retry is not due yet
+	later := time.Now().Add(time.Hour)
+	db.FailDelivery(claimed[0].ID, "HTTP 500", &later)
+	if due, _ := db.ClaimDueDeliveries(10); len(due) != 0 {
+		t.Errorf("Expected no due deliveries before the retry time, got %+v", due)
+	}
+
+	db.FailDelivery(claimed[0].ID, "HTTP 500", nil)
+	failed, _ := db.GetDeliveries(10, "failed")
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].LastError != "HTTP 500" {
+		t.Fatalf("Expected one failed delivery after 2 attempts, got %+v", failed)
+	}
+
+	if n, _ := db.RetryFailedDeliveries(); n != 1 {
+		t.Errorf("Expected 1 delivery requeued, got %d", n)
+	}
+	claimed, _ = db.ClaimDueDeliveries(10)
+	if len(claimed) != 1 {
+		t.Fatalf("Expected the requeued delivery to be due, got %+v", claimed)
+	}
+	db.CompleteDelivery(claimed[0].ID)
+	delivered, _ := db.GetDeliveries(10, "delivered")
+	if len(delivered) != 1 || delivered[0].DeliveredAt == nil || delivered[0].LastError != "" {
+		t.Errorf("Expected a delivered delivery, got %+v", delivered)
+	}
+}
+
+func TestAllocatePorts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+	db.RegisterWorker("be", "sprint-01")
+
+	fe, err := db.AllocatePorts("fe", []string{"web", "api"}, 4000, 4009)
+	if err != nil {
+		t.Fatalf("AllocatePorts failed: %v", err)
+	}
+	if len(fe) != 2 || fe[0].Port != 4000 || fe[1].Port != 4001 {
+		t.Fatalf("Expected web=4000 api=4001, got %+v", fe)
+	}
+
+	// Other workers get the next block; re-allocating keeps ports
+	be, _ := db.AllocatePorts("be", []string{"web"}, 4000, 4009)
+	if len(be) != 1 || be[0].Port != 4002 {
+		t.Errorf("Expected be web=4002, got %+v", be)
+	}
+	again, _ := db.AllocatePorts("fe", []string{"api", "web"}, 4000, 4009)
+	if again[0].Port != 4001 || again[1].Port != 4000 {
+		t.Errorf("Expected fe to keep its ports, got %+v", again)
+	}
+
+	// Dropped names are released, and the ports show on the worker
+	db.AllocatePorts("fe", []string{"web"}, 4000, 4009)
+	if w, _ := db.GetWorker("fe"); !reflect.DeepEqual(w.Ports, map[string]int{"web": 4000}) {
+		t.Errorf("Expected fe ports {web: 4000}, got %v", w.Ports)
+	}
+	if ports, _ := db.AllocatePorts("fe", []string{"web", "db", "cache"}, 4000, 4009); ports[1].Port != 4003 || ports[2].Port != 4004 {
+		t.Errorf("Expected a new block after be's port, got %+v", ports)
+	}
+
+	// A full range is an error; deleting a worker releases its ports
+	if _, err := db.AllocatePorts("be", []string{"web", "a", "b", "c", "d", "e", "f"}, 4000, 4009); err == nil {
+		t.Error("Expected an error when the range is exhausted")
+	}
+	db.DeleteWorker("fe")
+	if ports, _ := db.GetWorkerPorts("fe"); len(ports) != 0 {
+		t.Errorf("Expected fe's ports to be released, got %+v", ports)
+	}
+}
+
+func TestSetupSteps(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+
+	db.MarkSetupStepDone("fe", "copy .env")
+	db.MarkSetupStepDone("fe", "run npm ci")
+	db.MarkSetupStepDone("fe", "copy .env")
+	if steps, _ := db.GetSetupSteps("fe"); !reflect.DeepEqual(steps, []string{"run npm ci", "copy .env"}) {
+		t.Errorf("Expected each step once, got %v", steps)
+	}
+
+	if err := db.ReportWorktreeSetup("fe", []string{"copy .env"}, "run npm ci", "exit 1"); err != nil {
+		t.Fatalf("ReportWorktreeSetup failed: %v", err)
+	}
+	events, _ := db.GetEventsSince(0, EventFilter{Workers: []string{"fe"}, Types: []string{"worktree_setup"}})
+	if len(events) != 1 || !strings.Contains(events[0].Data, `"failed":"run npm ci"`) {
+		t.Errorf("Expected a failed worktree_setup event, got %+v", events)
+	}
+
+	db.ResetSetupSteps("fe")
+	if steps, _ := db.GetSetupSteps("fe"); len(steps) != 0 {
+		t.Errorf("Expected no steps after reset, got %v", steps)
+	}
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Event notifications
+//
+// Every devhive process (CLI, hooks, dashboards) writes to the same SQLite
+// database. Instead of polling it, subscribers bind a unixgram socket in
+// .devhive/sub/ and writers send a wake-up datagram to each socket after
+// inserting an event. A slow poll remains as a fallback for notifications
+// that never arrive (e.g. platforms without unix sockets, or socket paths
+// that are too long).
+
+// SubscribeFallbackInterval is how often subscribers re-check the database
+// without a notification
+var SubscribeFallbackInterval = 5 * time.Second
+
+// subscriberSeq makes socket names unique within a process
+var subscriberSeq atomic.Int64
+
+// EventFilter selects events by worker and/or type (exact match).
+// An empty field matches everything.
+type EventFilter struct {
+	Workers []string
+	Types   []string
+}
+
+// Match reports whether an event passes the filter
+func (f EventFilter) Match(e Event) bool {
+	return matchAny(f.Workers, e.Worker) && matchAny(f.Types, e.EventType)
+}
+
+func matchAny(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// where returns the SQL conditions for the filter (starting with " AND")
+func (f EventFilter) where() (string, []interface{}) {
+	var clause string
+	var args []interface{}
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		clause += " AND " + column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")"
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	in("worker", f.Workers)
+	in("event_type", f.Types)
+	return clause, args
+}
+
+// subscriberDir returns the directory holding subscriber sockets
+func (db *DB) subscriberDir() string {
+	return filepath.Join(filepath.Dir(db.path), "sub")
+}
+
+// notifySubscribers wakes up every subscriber of this database.
+// Sockets left behind by dead processes are removed.
+func (db *DB) notifySubscribers() {
+	dir := db.subscriberDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".sock") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+		if err != nil {
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				os.Remove(path)
+			}
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+		conn.Write([]byte{1})
+		conn.Close()
+	}
+}
+
+// listenEvents binds a subscriber socket. The returned channel receives a
+// value whenever an event is written; it is nil if the socket can't be bound.
+func (db *DB) listenEvents() (<-chan struct{}, func()) {
+	dir := db.subscriberDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, func() {}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d-%d.sock", os.Getpid(), subscriberSeq.Add(1)))
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, func() {}
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			if _, _, err := conn.ReadFromUnix(buf); err != nil {
+				return
+			}
+			// Coalesce bursts: one pending wake-up is enough
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return wake, func() {
+		conn.Close()
+		os.Remove(path)
+	}
+}
+
+// Subscribe streams events with an ID greater than afterID that match the
+// filter, in ID order. The channel is closed when ctx is done.
+func (db *DB) Subscribe(ctx context.Context, afterID int, filter EventFilter) <-chan Event {
+	// Bind before the first query so no event falls between the two
+	wake, stop := db.listenEvents()
+	interval := SubscribeFallbackInterval
+	if wake == nil {
+		interval = time.Second
+	}
+
+	ch := make(chan Event, 64)
+	go func() {
+		defer close(ch)
+		defer stop()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastID := afterID
+		for {
+			// Errors (e.g. a busy database) are retried on the next wake-up
+			if events, err := db.GetEventsSince(lastID, filter); err == nil {
+				for _, e := range events {
+					lastID = e.ID
+					select {
+					case ch <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetEventsSinceFilter(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.LogEvent("worker_status_changed", "fe", "")
+	db.LogEvent("worker_registered", "fe", "")
+	db.LogEvent("worker_registered", "be", "")
+	db.LogEvent("worker_error", "fe", "")
+
+	// Type matches exactly (not as a prefix)
+	events, err := db.GetEventsSince(0, EventFilter{Types: []string{"worker_s"}})
+	if err != nil {
+		t.Fatalf("GetEventsSince failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events for a type prefix, got %+v", events)
+	}
+
+	events, err = db.GetEventsSince(0, EventFilter{Workers: []string{"fe"}, Types: []string{"worker_registered", "worker_error"}})
+	if err != nil {
+		t.Fatalf("GetEventsSince failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	// Ordered by ID, even within the same second
+	if events[0].ID >= events[1].ID {
+		t.Errorf("Expected events in ID order, got %d then %d", events[0].ID, events[1].ID)
+	}
+	for _, e := range events {
+		if e.Worker != "fe" {
+			t.Errorf("Expected worker 'fe', got '%s'", e.Worker)
+		}
+	}
+
+	events, err = db.GetEventsSince(events[0].ID, EventFilter{Workers: []string{"fe"}})
+	if err != nil {
+		t.Fatalf("GetEventsSince failed: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != "worker_error" {
+		t.Errorf("Expected only the later 'worker_error' event, got %+v", events)
+	}
+}
+
+func TestEventFilterMatch(t *testing.T) {
+	e := Event{EventType: "worker_error", Worker: "fe"}
+
+	tests := []struct {
+		filter EventFilter
+		want   bool
+	}{
+		{EventFilter{}, true},
+		{EventFilter{Workers: []string{"fe"}}, true},
+		{EventFilter{Workers: []string{"be"}}, false},
+		{EventFilter{Types: []string{"worker"}}, false},
+		{EventFilter{Workers: []string{"be", "fe"}, Types: []string{"worker_error"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(e); got != tt.want {
+			t.Errorf("%+v.Match() = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// A long fallback interval proves events arrive by notification
+	saved := SubscribeFallbackInterval
+	SubscribeFallbackInterval = time.Minute
+	defer func() { SubscribeFallbackInterval = saved }()
+
+	db.CreateSprint("sprint-01")
+	db.RegisterWorker("fe", "sprint-01")
+	lastID, _ := db.GetLastEventID()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := db.Subscribe(ctx, lastID, EventFilter{Workers: []string{"fe"}})
+
+	// Written through a separate connection, as another devhive process would
+	writer, err := Open(db.path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer writer.Close()
+
+	writer.RegisterWorker("be", "sprint-01")
+	writer.UpdateWorkerSessionState("fe", "running")
+	writer.UpdateWorkerProgress("fe", 50, "testing")
+
+	select {
+	case e := <-events:
+		if e.Worker != "fe" || e.EventType != "worker_session_changed" {
+			t.Errorf("Expected fe's worker_session_changed, got %s/%s", e.Worker, e.EventType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+
+	select {
+	case e := <-events:
+		if e.EventType != "worker_progress_updated" {
+			t.Errorf("Expected worker_progress_updated, got %s", e.EventType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -46,7 +46,14 @@ INSERT OR IGNORE INTO event_types (name, description) VALUES
     ('worker_error', 'Worker reported an error'),
     ('message_sent', 'Message was sent'),
     ('message_broadcast', 'Message was broadcast'),
-    ('branch_merged', 'Branch was merged');
+    ('branch_merged', 'Branch was merged'),
+    ('worker_stalled', 'Worker was detected as stalled'),
+    ('sprint_aborted', 'Sprint was aborted'),
+    ('merge_failed', 'Branch could not be merged'),
+    ('conflict_predicted', 'Conflict between worker branches was predicted'),
+    ('worker_checked', 'Worker checks were run'),
+    ('branch_synced', 'Worker branch was updated from the base branch'),
+    ('worktree_setup', 'Worker worktree setup was run');
 
 
 -- ============================================
@@ -57,6 +64,7 @@ INSERT OR IGNORE INTO event_types (name, description) VALUES
 CREATE TABLE IF NOT EXISTS sprints (
     id TEXT PRIMARY KEY,
     status TEXT DEFAULT 'active' CHECK(status IN ('active', 'completed', 'aborted')),
+    goal TEXT,
     started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     completed_at TIMESTAMP
 );
@@ -71,7 +79,14 @@ CREATE TABLE IF NOT EXISTS workers (
     session_state TEXT DEFAULT 'stopped' CHECK(session_state IN ('running', 'waiting_permission', 'idle', 'stopped')),
     progress INTEGER DEFAULT 0 CHECK(progress >= 0 AND progress <= 100),
     activity TEXT,
+    pending_reason TEXT,
     last_commit TEXT,
+    last_commit_at TIMESTAMP,
+    commits_ahead INTEGER DEFAULT 0,
+    insertions INTEGER DEFAULT 0,
+    deletions INTEGER DEFAULT 0,
+    uncommitted INTEGER DEFAULT 0,
+    monitored_at TIMESTAMP,
     error_count INTEGER DEFAULT 0,
     last_error TEXT,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -87,6 +102,8 @@ CREATE TABLE IF NOT EXISTS messages (
     message_type TEXT DEFAULT 'info',
     subject TEXT,
     content TEXT NOT NULL,
+    reply_to INTEGER,
+    thread_id INTEGER,
     read_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (message_type) REFERENCES message_types(name) ON DELETE RESTRICT
@@ -103,6 +120,82 @@ CREATE TABLE IF NOT EXISTS events (
     FOREIGN KEY (event_type) REFERENCES event_types(name) ON DELETE RESTRICT
 );
 
+-- Sprint workers table
+-- Snapshot of each worker when its sprint ends (workers are reused across sprints)
+CREATE TABLE IF NOT EXISTS sprint_workers (
+    sprint_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    status TEXT,
+    session_state TEXT,
+    progress INTEGER DEFAULT 0,
+    activity TEXT,
+    pending_reason TEXT,
+    last_commit TEXT,
+    last_commit_at TIMESTAMP,
+    commits_ahead INTEGER DEFAULT 0,
+    insertions INTEGER DEFAULT 0,
+    deletions INTEGER DEFAULT 0,
+    uncommitted INTEGER DEFAULT 0,
+    monitored_at TIMESTAMP,
+    error_count INTEGER DEFAULT 0,
+    last_error TEXT,
+    updated_at TIMESTAMP,
+    PRIMARY KEY (sprint_id, name),
+    FOREIGN KEY (sprint_id) REFERENCES sprints(id) ON DELETE CASCADE
+);
+
+-- Webhook deliveries table
+-- Outbox of webhook POSTs for events (retried with backoff until delivered or failed)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'sending', 'delivered', 'failed')),
+    attempts INTEGER DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+);
+
+-- Check results table
+-- Outcome of each check run in a worker's worktree ('devhive check', completion gate)
+CREATE TABLE IF NOT EXISTS check_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    worker TEXT NOT NULL,
+    sprint_id TEXT,
+    name TEXT NOT NULL,
+    command TEXT NOT NULL,
+    passed INTEGER NOT NULL,
+    exit_code INTEGER DEFAULT 0,
+    output TEXT,
+    duration_ms INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Worker ports table
+-- Ports assigned to workers from defaults.port_range (kept across sprints)
+CREATE TABLE IF NOT EXISTS worker_ports (
+    worker TEXT NOT NULL,
+    name TEXT NOT NULL,
+    port INTEGER NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (worker, name)
+);
+
+-- Worktree setup steps table
+-- copy_files / link_files / setup steps done in a worker's worktree, so
+-- 'devhive up' resumes where a failed setup stopped
+CREATE TABLE IF NOT EXISTS worktree_setup_steps (
+    worker TEXT NOT NULL,
+    step TEXT NOT NULL,
+    done_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (worker, step)
+);
+
 -- ============================================
 -- Indexes
 -- ============================================
@@ -115,3 +208,5 @@ CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages(to_worker, read_at) W
 CREATE INDEX IF NOT EXISTS idx_events_type ON events(event_type);
 CREATE INDEX IF NOT EXISTS idx_events_worker ON events(worker);
 CREATE INDEX IF NOT EXISTS idx_events_created ON events(created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_check_results_worker ON check_results(worker, name);
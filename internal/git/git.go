@@ -0,0 +1,23 @@
+// Package git runs git commands for the packages that inspect and update
+// the project's repository and worktrees.
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Output runs a git command in dir and returns its trimmed stdout. The error
+// of a failing command carries its stderr.
+func Output(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
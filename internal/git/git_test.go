@@ -0,0 +1,23 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Output(dir, "init", "-q", "-b", "main"); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+
+	out, err := Output(dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil || out != "main" {
+		t.Errorf("Expected trimmed output main, got %q (%v)", out, err)
+	}
+
+	_, err = Output(dir, "rev-parse", "--verify", "missing")
+	if err == nil || !strings.HasPrefix(err.Error(), "git rev-parse: ") {
+		t.Errorf("Expected an error with git's stderr, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Package gittest creates git repositories for the tests of the packages
+// that work on the project's repository and worktrees.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// NewRepo creates a repository with an empty main branch in a temporary
+// directory and sets a commit identity for the test. The test is skipped
+// when git is not installed.
+func NewRepo(t testing.TB) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	Run(t, dir, "init", "-q", "-b", "main")
+	return dir
+}
+
+// Run runs a git command in dir and returns its trimmed output. A failing
+// command fails the test.
+func Run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Write writes a file relative to dir, creating its parent directories
+func Write(t testing.TB, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// Commit stages all changes in dir and commits them
+func Commit(t testing.TB, dir, msg string) {
+	t.Helper()
+	Run(t, dir, "add", "-A")
+	Run(t, dir, "commit", "-q", "-m", msg)
+}
@@ -0,0 +1,63 @@
+// Package lifecycle runs the shell commands configured for worker state
+// transitions (hooks: in .devhive.yaml).
+//
+// Hooks run synchronously in the devhive process that caused the transition
+// (the CLI, serve or mcp), with checks.Run and the Payload as JSON on stdin.
+package lifecycle
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// Hook names
+const (
+	OnUp       = "on_up"
+	OnComplete = "on_complete"
+	OnError    = "on_error"
+	OnBlocked  = "on_blocked"
+	OnMerge    = "on_merge"
+)
+
+// Hooks are the supported hooks
+var Hooks = []string{OnUp, OnComplete, OnError, OnBlocked, OnMerge}
+
+// Timeout is how long a hook may run before it is killed
+const Timeout = 60 * time.Second
+
+// HookFor returns the hook run for an event ("" if none).
+// on_up has no event: devhive up runs it when it starts a worker.
+func HookFor(e db.Event) string {
+	switch e.EventType {
+	case "worker_error":
+		return OnError
+	case "branch_merged":
+		return OnMerge
+	case "worker_status_changed":
+		var data struct {
+			Status string `json:"status"`
+		}
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			return ""
+		}
+		switch data.Status {
+		case "completed":
+			return OnComplete
+		case "error":
+			return OnError
+		case "blocked":
+			return OnBlocked
+		}
+	}
+	return ""
+}
+
+// Payload is the JSON written to a hook's stdin
+type Payload struct {
+	Hook    string     `json:"hook"`
+	Project string     `json:"project"`
+	Worker  *db.Worker `json:"worker,omitempty"` // State after the transition
+	Event   *db.Event  `json:"event,omitempty"`  // Event that triggered the hook (nil for on_up)
+}
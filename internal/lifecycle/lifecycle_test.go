@@ -0,0 +1,28 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+func TestHookFor(t *testing.T) {
+	tests := []struct {
+		event db.Event
+		want  string
+	}{
+		{db.Event{EventType: "worker_status_changed", Data: `{"status":"completed"}`}, OnComplete},
+		{db.Event{EventType: "worker_status_changed", Data: `{"status":"blocked"}`}, OnBlocked},
+		{db.Event{EventType: "worker_status_changed", Data: `{"status":"error"}`}, OnError},
+		{db.Event{EventType: "worker_status_changed", Data: `{"status":"working"}`}, ""},
+		{db.Event{EventType: "worker_error", Data: `{"message":"boom"}`}, OnError},
+		{db.Event{EventType: "branch_merged", Data: `{"from":"feat/a","to":"main"}`}, OnMerge},
+		{db.Event{EventType: "merge_failed", Data: `{"reason":"conflict"}`}, ""},
+		{db.Event{EventType: "worker_progress_updated"}, ""},
+	}
+	for _, tt := range tests {
+		if got := HookFor(tt.event); got != tt.want {
+			t.Errorf("HookFor(%s %s) = %q, want %q", tt.event.EventType, tt.event.Data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,587 @@
+// Package mcp serves DevHive to the agent running as a worker over the
+// Model Context Protocol (JSON-RPC 2.0, one message per line on stdio).
+//
+// The tools go through the same internal/db operations as 'devhive progress',
+// 'devhive request', 'devhive report' etc., so the events they log are
+// identical to the CLI's.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// ProtocolVersion is the latest MCP revision the server implements
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the MCP revisions the server accepts, newest first
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// WorkerConfig is a worker's definition in .devhive.yaml
+type WorkerConfig struct {
+	Role   string
+	Branch string
+	Task   string // Task content (inline or from the task file)
+}
+
+// Server serves the tools of one worker
+type Server struct {
+	db     *db.DB
+	worker string
+
+	// Version is reported to the client in serverInfo
+	Version string
+
+	// AutoComplete marks the worker completed when its progress reaches 100
+	// (defaults.auto_complete in .devhive.yaml)
+	AutoComplete bool
+
+	// Complete is called instead of marking the worker completed directly
+	// when AutoComplete applies. It may refuse (e.g. failing checks) and set
+	// another status, returning false.
+	Complete func(worker string) (bool, error)
+
+	// Config returns a worker's definition, or nil if it has none.
+	// Called on every use so task file edits are picked up.
+	Config func(worker string) *WorkerConfig
+}
+
+// New creates a server acting as the given worker
+func New(database *db.DB, worker string) *Server {
+	return &Server{db: database, worker: worker, Version: "dev"}
+}
+
+// ============================================
+// JSON-RPC transport
+// ============================================
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func invalidParams(format string, args ...interface{}) error {
+	return &rpcError{codeInvalidParams, fmt.Sprintf(format, args...)}
+}
+
+// Serve reads requests from r and writes responses to w until r is closed
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if resp := s.handle(line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes one message. Returns nil for notifications.
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{codeInvalidRequest, "invalid request"}}
+	}
+
+	result, err := s.dispatch(req.Method, req.Params)
+	if req.ID == nil {
+		// Notifications get no response
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var re *rpcError
+		if !errors.As(err, &re) {
+			re = &rpcError{codeInternalError, err.Error()}
+		}
+		resp.Result, resp.Error = nil, re
+	}
+	return resp
+}
+
+// dispatch runs a method
+func (s *Server) dispatch(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(params)
+	}
+	if strings.HasPrefix(method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, "method not found: " + method}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, invalidParams("invalid initialize params: %v", err)
+		}
+	}
+
+	// Use the client's revision if supported, otherwise propose ours
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == req.ProtocolVersion {
+			version = v
+		}
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]string{"name": "devhive", "version": s.Version},
+		"instructions": fmt.Sprintf("You are DevHive worker %q. Use report_progress as you work, "+
+			"request_help when stuck, send_report for updates to the PM, and check read_messages regularly.", s.worker),
+	}, nil
+}
+
+// ============================================
+// Tools
+// ============================================
+
+// tool is an MCP tool definition and its handler
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+
+	call func(s *Server, args json.RawMessage) (string, error)
+}
+
+// object builds a JSON Schema for an object with the given properties
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func prop(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+// requestSubjects are the request types and their subjects, as 'devhive request' sends them
+var requestSubjects = map[string]string{
+	"help":    "🆘 Help Request",
+	"review":  "👀 Review Request",
+	"unblock": "🚫 Unblock Request",
+	"clarify": "❓ Clarification Request",
+}
+
+// tools are the tools offered to the agent
+var tools = []tool{
+	{
+		Name:        "report_progress",
+		Description: "Report your progress (0-100) and what you are currently doing. Report 100 when your task is done.",
+		InputSchema: object(map[string]interface{}{
+			"progress": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 100, "description": "Progress percentage"},
+			"activity": prop("string", "What you are doing now (e.g. \"writing tests\")"),
+		}, "progress"),
+		call: (*Server).reportProgress,
+	},
+	{
+		Name:        "request_help",
+		Description: "Ask the PM for help, a review, a clarification, or to unblock you (unblock also marks you blocked).",
+		InputSchema: object(map[string]interface{}{
+			"type":    map[string]interface{}{"type": "string", "enum": []string{"help", "review", "unblock", "clarify"}, "description": "Request type (default: help)"},
+			"message": prop("string", "What you need"),
+		}, "message"),
+		call: (*Server).requestHelp,
+	},
+	{
+		Name:        "send_report",
+		Description: "Send a progress report or other update to the PM.",
+		InputSchema: object(map[string]interface{}{
+			"message": prop("string", "The report"),
+		}, "message"),
+		call: (*Server).sendReport,
+	},
+	{
+		Name:        "read_messages",
+		Description: "Read messages sent to you by the PM or other workers. Returned messages are marked read.",
+		InputSchema: object(map[string]interface{}{
+			"all":       prop("boolean", "Include messages already read (default: false)"),
+			"mark_read": prop("boolean", "Mark the returned messages read (default: true)"),
+		}),
+		call: (*Server).readMessages,
+	},
+	{
+		Name:        "reply_message",
+		Description: "Reply to a message in the same thread.",
+		InputSchema: object(map[string]interface{}{
+			"id":      prop("integer", "ID of the message to reply to"),
+			"content": prop("string", "The reply"),
+		}, "id", "content"),
+		call: (*Server).replyMessage,
+	},
+	{
+		Name:        "get_task",
+		Description: "Get your task, role, branch and current status.",
+		InputSchema: object(map[string]interface{}{}),
+		call:        (*Server).getTask,
+	},
+	{
+		Name:        "list_peers",
+		Description: "List the other workers in your sprint with their role, status and progress.",
+		InputSchema: object(map[string]interface{}{}),
+		call:        (*Server).listPeers,
+	},
+}
+
+// callTool runs a tool. Tool failures are returned as results with isError
+// so the agent can see and correct them.
+func (s *Server) callTool(params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, invalidParams("invalid tools/call params: %v", err)
+	}
+
+	for _, t := range tools {
+		if t.Name != req.Name {
+			continue
+		}
+		text, err := t.call(s, req.Arguments)
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	}
+	return nil, invalidParams("unknown tool: %s", req.Name)
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// decodeArgs decodes tool arguments into v, rejecting unknown fields
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+// requireWorker returns the worker the server acts as
+func (s *Server) requireWorker() (*db.Worker, error) {
+	worker, err := s.db.GetWorker(s.worker)
+	if err != nil {
+		return nil, err
+	}
+	if worker == nil {
+		return nil, fmt.Errorf("worker %s is not registered (start it with 'devhive up')", s.worker)
+	}
+	return worker, nil
+}
+
+// toJSON formats v for a tool result
+func toJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
+
+func (s *Server) reportProgress(args json.RawMessage) (string, error) {
+	var req struct {
+		Progress *int   `json:"progress"`
+		Activity string `json:"activity"`
+	}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	if req.Progress == nil || *req.Progress < 0 || *req.Progress > 100 {
+		return "", fmt.Errorf("progress must be a number between 0 and 100")
+	}
+	if _, err := s.requireWorker(); err != nil {
+		return "", err
+	}
+
+	if err := s.db.UpdateWorkerProgress(s.worker, *req.Progress, req.Activity); err != nil {
+		return "", err
+	}
+	text := fmt.Sprintf("Progress of %s: %d%%", s.worker, *req.Progress)
+	if *req.Progress == 100 && s.AutoComplete {
+		completed := true
+		var err error
+		if s.Complete != nil {
+			completed, err = s.Complete(s.worker)
+		} else {
+			err = s.db.UpdateWorkerStatus(s.worker, "completed", nil)
+		}
+		if err != nil {
+			return "", err
+		}
+		if completed {
+			text += " (marked completed)"
+		} else {
+			reason := "refused"
+			if worker, _ := s.db.GetWorker(s.worker); worker != nil && worker.LastError != "" {
+				reason = worker.LastError
+			}
+			text += fmt.Sprintf(" (not completed: %s)", reason)
+		}
+	}
+	return text, nil
+}
+
+func (s *Server) requestHelp(args json.RawMessage) (string, error) {
+	var req struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	if req.Type == "" {
+		req.Type = "help"
+	}
+	subject, ok := requestSubjects[req.Type]
+	if !ok {
+		return "", fmt.Errorf("invalid request type: %s (valid: help, review, unblock, clarify)", req.Type)
+	}
+	if req.Message == "" {
+		return "", fmt.Errorf("message is required")
+	}
+
+	id, err := s.db.SendMessage(s.worker, "pm", req.Type, subject, req.Message)
+	if err != nil {
+		return "", err
+	}
+	if req.Type == "unblock" {
+		s.db.UpdateWorkerStatus(s.worker, "blocked", nil)
+	}
+	return fmt.Sprintf("%s #%d sent to PM", subject, id), nil
+}
+
+func (s *Server) sendReport(args json.RawMessage) (string, error) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	if req.Message == "" {
+		return "", fmt.Errorf("message is required")
+	}
+
+	id, err := s.db.SendMessage(s.worker, "pm", "report", "📋 Progress Report", req.Message)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Progress report #%d sent to PM", id), nil
+}
+
+func (s *Server) readMessages(args json.RawMessage) (string, error) {
+	var req struct {
+		All      bool  `json:"all"`
+		MarkRead *bool `json:"mark_read"`
+	}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+
+	messages, err := s.db.GetMessages(s.worker, req.All)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "No new messages.", nil
+	}
+
+	// Mark only what was returned, not messages that arrived since
+	if req.MarkRead == nil || *req.MarkRead {
+		for _, m := range messages {
+			if m.ReadAt == nil {
+				s.db.MarkMessageRead(m.ID)
+			}
+		}
+	}
+	return toJSON(messages)
+}
+
+func (s *Server) replyMessage(args json.RawMessage) (string, error) {
+	var req struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	if req.Content == "" {
+		return "", fmt.Errorf("content is required")
+	}
+	parent, err := s.db.GetMessage(req.ID)
+	if err != nil {
+		return "", err
+	}
+	if parent == nil {
+		return "", fmt.Errorf("message not found: #%d", req.ID)
+	}
+	// Agents can only answer their own messages
+	if parent.ToWorker != s.worker {
+		return "", fmt.Errorf("message #%d was not sent to %s", parent.ID, s.worker)
+	}
+
+	reply, err := s.db.ReplyToMessage(parent.ID, s.worker, "reply", parent.ReplySubject(), req.Content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Reply #%d sent to %s", reply.ID, reply.ToWorker), nil
+}
+
+// config returns the worker's definition (empty if unknown)
+func (s *Server) config(worker string) WorkerConfig {
+	if s.Config != nil {
+		if c := s.Config(worker); c != nil {
+			return *c
+		}
+	}
+	return WorkerConfig{}
+}
+
+func (s *Server) getTask(args json.RawMessage) (string, error) {
+	var req struct{}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	worker, err := s.requireWorker()
+	if err != nil {
+		return "", err
+	}
+	config := s.config(s.worker)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", s.worker)
+	if config.Role != "" {
+		fmt.Fprintf(&sb, "- Role: %s\n", config.Role)
+	}
+	if config.Branch != "" {
+		fmt.Fprintf(&sb, "- Branch: %s\n", config.Branch)
+	}
+	fmt.Fprintf(&sb, "- Sprint: %s\n", worker.SprintID)
+	fmt.Fprintf(&sb, "- Status: %s (%d%%)\n", worker.Status, worker.Progress)
+	if worker.PendingReason != "" {
+		fmt.Fprintf(&sb, "- Pending: %s\n", worker.PendingReason)
+	}
+	sb.WriteString("\n## Task\n\n")
+	if config.Task != "" {
+		sb.WriteString(config.Task)
+	} else {
+		sb.WriteString("No task defined.\n")
+	}
+	return sb.String(), nil
+}
+
+// peer is a worker as listed by list_peers
+type peer struct {
+	Name         string `json:"name"`
+	Role         string `json:"role,omitempty"`
+	Branch       string `json:"branch,omitempty"`
+	Status       string `json:"status"`
+	SessionState string `json:"session_state"`
+	Progress     int    `json:"progress"`
+	Activity     string `json:"activity,omitempty"`
+}
+
+func (s *Server) listPeers(args json.RawMessage) (string, error) {
+	var req struct{}
+	if err := decodeArgs(args, &req); err != nil {
+		return "", err
+	}
+	worker, err := s.requireWorker()
+	if err != nil {
+		return "", err
+	}
+
+	workers, err := s.db.GetSprintWorkers(worker.SprintID)
+	if err != nil {
+		return "", err
+	}
+	peers := []peer{}
+	for _, w := range workers {
+		if w.Name == s.worker {
+			continue
+		}
+		config := s.config(w.Name)
+		peers = append(peers, peer{
+			Name:         w.Name,
+			Role:         config.Role,
+			Branch:       config.Branch,
+			Status:       w.Status,
+			SessionState: w.SessionState,
+			Progress:     w.Progress,
+			Activity:     w.Activity,
+		})
+	}
+	return toJSON(peers)
+}
@@ -0,0 +1,250 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// setupTestServer creates a server acting as fe, backed by a temporary database
+func setupTestServer(t *testing.T) (*db.DB, *Server) {
+	t.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "devhive-mcp-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	database, err := db.Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	database.CreateSprint("sprint-01")
+	database.RegisterWorker("fe", "sprint-01")
+	database.RegisterWorker("be", "sprint-01")
+
+	s := New(database, "fe")
+	s.Config = func(worker string) *WorkerConfig {
+		switch worker {
+		case "fe":
+			return &WorkerConfig{Role: "@frontend", Branch: "feat/login", Task: "Build the login form\n"}
+		case "be":
+			return &WorkerConfig{Role: "@backend", Branch: "feat/api"}
+		}
+		return nil
+	}
+	return database, s
+}
+
+// rpc sends requests (one per line) and returns the decoded responses
+func rpc(t *testing.T, s *Server, lines ...string) []response {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var responses []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// call invokes a tool and returns its text and isError
+func call(t *testing.T, s *Server, name, args string) (string, bool) {
+	t.Helper()
+
+	responses := rpc(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`)
+	if len(responses) != 1 || responses[0].Error != nil {
+		t.Fatalf("%s: unexpected response %+v", name, responses)
+	}
+	var result struct {
+		Content []struct{ Text string }
+		IsError bool
+	}
+	data, _ := json.Marshal(responses[0].Result)
+	json.Unmarshal(data, &result)
+	if len(result.Content) != 1 {
+		t.Fatalf("%s: expected one content item, got %s", name, data)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestInitialize(t *testing.T) {
+	_, s := setupTestServer(t)
+
+	responses := rpc(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"x","method":"resources/list"}`,
+		`not json`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses (no response to the notification), got %d", len(responses))
+	}
+
+	result := responses[0].Result.(map[string]interface{})
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Errorf("Expected the client's protocol version, got %v", result["protocolVersion"])
+	}
+
+	list := responses[1].Result.(map[string]interface{})["tools"].([]interface{})
+	names := map[string]bool{}
+	for _, item := range list {
+		names[item.(map[string]interface{})["name"].(string)] = true
+	}
+	for _, name := range []string{"report_progress", "request_help", "read_messages", "send_report", "get_task", "list_peers"} {
+		if !names[name] {
+			t.Errorf("Expected tool %s in tools/list", name)
+		}
+	}
+
+	if responses[2].Error == nil || responses[2].Error.Code != codeMethodNotFound || string(responses[2].ID) != `"x"` {
+		t.Errorf("Expected method not found for id \"x\", got %+v", responses[2])
+	}
+	if responses[3].Error == nil || responses[3].Error.Code != codeParseError {
+		t.Errorf("Expected a parse error, got %+v", responses[3])
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	database, s := setupTestServer(t)
+	s.AutoComplete = true
+
+	if text, isError := call(t, s, "report_progress", `{"progress":40,"activity":"writing tests"}`); isError {
+		t.Fatalf("Unexpected error: %s", text)
+	}
+	worker, _ := database.GetWorker("fe")
+	if worker.Progress != 40 || worker.Activity != "writing tests" {
+		t.Errorf("Expected 40%% / 'writing tests', got %d%% / '%s'", worker.Progress, worker.Activity)
+	}
+
+	// Logged exactly as 'devhive progress' logs it
+	eventType := "worker_progress_updated"
+	if events, _ := database.GetRecentEvents(1, &eventType, nil); len(events) != 1 || events[0].Worker != "fe" {
+		t.Errorf("Expected a worker_progress_updated event for fe, got %+v", events)
+	}
+
+	call(t, s, "report_progress", `{"progress":100}`)
+	if worker, _ := database.GetWorker("fe"); worker.Status != "completed" {
+		t.Errorf("Expected auto-complete, got status %s", worker.Status)
+	}
+
+	// Complete may refuse, e.g. when checks fail
+	s.Complete = func(name string) (bool, error) {
+		return false, database.ReportWorkerError(name, "check test failed (exit 1)")
+	}
+	if text, _ := call(t, s, "report_progress", `{"progress":100}`); !strings.Contains(text, "not completed: check test failed") {
+		t.Errorf("Expected the refusal in the result, got %q", text)
+	}
+	if worker, _ := database.GetWorker("fe"); worker.Status != "error" {
+		t.Errorf("Expected status error, got %s", worker.Status)
+	}
+
+	// Mistakes come back as tool errors the agent can read
+	for _, args := range []string{`{"progress":101}`, `{}`, `{"percent":10}`, `{"progress":"50"}`} {
+		if text, isError := call(t, s, "report_progress", args); !isError || text == "" {
+			t.Errorf("%s: expected a tool error, got %q", args, text)
+		}
+	}
+
+	unknown := New(database, "nobody")
+	if text, isError := call(t, unknown, "report_progress", `{"progress":10}`); !isError || !strings.Contains(text, "not registered") {
+		t.Errorf("Expected an error for an unregistered worker, got %q", text)
+	}
+
+	responses := rpc(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope","arguments":{}}}`)
+	if responses[0].Error == nil || responses[0].Error.Code != codeInvalidParams {
+		t.Errorf("Expected invalid params for an unknown tool, got %+v", responses[0])
+	}
+}
+
+func TestMessaging(t *testing.T) {
+	database, s := setupTestServer(t)
+
+	if text, isError := call(t, s, "request_help", `{"type":"unblock","message":"Waiting for the API"}`); isError {
+		t.Fatalf("Unexpected error: %s", text)
+	}
+	call(t, s, "send_report", `{"message":"Login form done"}`)
+
+	inbox, _ := database.GetMessages("pm", false)
+	if len(inbox) != 2 || inbox[0].MessageType != "unblock" || inbox[1].MessageType != "report" {
+		t.Fatalf("Expected unblock and report messages in the PM inbox, got %+v", inbox)
+	}
+	if worker, _ := database.GetWorker("fe"); worker.Status != "blocked" {
+		t.Errorf("Expected unblock to mark fe blocked, got %s", worker.Status)
+	}
+	if _, isError := call(t, s, "request_help", `{"type":"panic","message":"?"}`); !isError {
+		t.Error("Expected an error for an invalid request type")
+	}
+
+	// Messages to fe are returned and marked read
+	database.ReplyToMessage(inbox[0].ID, "pm", "reply", "Re: Unblock", "API is merged")
+	text, _ := call(t, s, "read_messages", `{}`)
+	var messages []db.Message
+	if err := json.Unmarshal([]byte(text), &messages); err != nil || len(messages) != 1 || messages[0].Content != "API is merged" {
+		t.Fatalf("Expected the PM's reply, got %q", text)
+	}
+	if text, _ := call(t, s, "read_messages", `{}`); text != "No new messages." {
+		t.Errorf("Expected no unread messages after reading, got %q", text)
+	}
+
+	if text, isError := call(t, s, "reply_message", `{"id":`+itoa(messages[0].ID)+`,"content":"Thanks"}`); isError {
+		t.Fatalf("Unexpected error: %s", text)
+	}
+	thread, _ := database.GetThread(messages[0].ThreadID)
+	if len(thread) != 3 || thread[2].ToWorker != "pm" || thread[2].Subject != "Re: Unblock" {
+		t.Errorf("Expected the reply to the PM in the thread, got %+v", thread)
+	}
+
+	// Messages sent to someone else cannot be answered
+	other, _ := database.SendMessage("pm", "be", "info", "", "For be only")
+	if text, isError := call(t, s, "reply_message", `{"id":`+itoa(int(other))+`,"content":"Hijack"}`); !isError {
+		t.Errorf("Expected an error replying to be's message, got %q", text)
+	}
+	if thread, _ := database.GetThread(int(other)); len(thread) != 1 {
+		t.Errorf("Expected no reply in be's thread, got %+v", thread)
+	}
+}
+
+func TestTaskAndPeers(t *testing.T) {
+	database, s := setupTestServer(t)
+	database.UpdateWorkerProgress("be", 60, "endpoints")
+
+	text, isError := call(t, s, "get_task", `{}`)
+	if isError || !strings.Contains(text, "Build the login form") || !strings.Contains(text, "feat/login") {
+		t.Errorf("Expected fe's task and branch, got %q", text)
+	}
+
+	text, _ = call(t, s, "list_peers", `{}`)
+	var peers []peer
+	if err := json.Unmarshal([]byte(text), &peers); err != nil {
+		t.Fatalf("Invalid list_peers result %q: %v", text, err)
+	}
+	if len(peers) != 1 || peers[0].Name != "be" || peers[0].Role != "@backend" || peers[0].Progress != 60 {
+		t.Errorf("Expected be as the only peer, got %+v", peers)
+	}
+}
+
+func itoa(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
@@ -0,0 +1,239 @@
+// Package mergequeue merges worker branches into a target branch one at a
+// time, gated by checks.
+//
+// Each branch is merged in a temporary integration worktree on top of the
+// target (including the branches merged before it), the checks run there,
+// and the target is fast-forwarded only if they pass. The main working copy
+// is never checked out or modified, except to fast-forward the target when
+// it is the branch checked out there.
+package mergequeue
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iguchi/devhive/internal/checks"
+	"github.com/iguchi/devhive/internal/git"
+)
+
+// Result statuses
+const (
+	StatusMerged        = "merged"
+	StatusAlreadyMerged = "already_merged"
+	StatusConflict      = "conflict"
+	StatusCheckFailed   = "check_failed"
+	StatusError         = "error"
+)
+
+// Entry is a branch to merge
+type Entry struct {
+	Worker string
+	Branch string
+}
+
+// Result is the outcome of one entry
+type Result struct {
+	Entry
+	Status    string
+	Commit    string         // Target commit after the merge (merged, already_merged)
+	Conflicts []string       // Conflicting files (conflict)
+	Check     *checks.Result // The check that failed (check_failed)
+	Err       error          // What went wrong (error)
+}
+
+// Queue merges entries into Target
+type Queue struct {
+	// Repo is the repository (main working copy)
+	Repo string
+	// Target is the local branch to merge into
+	Target string
+	// Checks run in the integration worktree after each merge
+	Checks []checks.Check
+	// Env is added to the environment of the checks
+	Env []string
+	// NoFF creates a merge commit even when a fast-forward is possible
+	NoFF bool
+	// WorktreeDir is where the integration worktree is created
+	// (default: .devhive/merge in Repo)
+	WorktreeDir string
+	// Log receives progress output (nil: discarded)
+	Log io.Writer
+	// OnResult is called after each entry
+	OnResult func(Result)
+}
+
+// Run merges the entries in order. A failed entry is skipped and the queue
+// continues with the next one. Returns an error only if the queue cannot run
+// at all (e.g. the target does not exist or was moved by someone else).
+func (q *Queue) Run(entries []Entry) ([]Result, error) {
+	log := q.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	head, err := git.Output(q.Repo, "rev-parse", "--verify", "--quiet", "refs/heads/"+q.Target+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("target branch not found: %s", q.Target)
+	}
+
+	dir, err := q.addWorktree(head)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		git.Output(q.Repo, "worktree", "remove", "--force", dir)
+		os.RemoveAll(dir)
+		git.Output(q.Repo, "worktree", "prune")
+	}()
+	fmt.Fprintf(log, "Integration worktree: %s (%s at %s)\n", dir, q.Target, short(head))
+
+	var results []Result
+	for _, entry := range entries {
+		result, err := q.merge(dir, head, entry, log)
+		if result.Status == StatusMerged || result.Status == StatusAlreadyMerged {
+			head = result.Commit
+		}
+		results = append(results, result)
+		if q.OnResult != nil {
+			q.OnResult(result)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// addWorktree creates a detached integration worktree at commit
+func (q *Queue) addWorktree(commit string) (string, error) {
+	base := q.WorktreeDir
+	if base == "" {
+		base = filepath.Join(q.Repo, ".devhive", "merge")
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(base, "integration-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := git.Output(q.Repo, "worktree", "add", "--detach", dir, commit); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create integration worktree: %w", err)
+	}
+	return dir, nil
+}
+
+// merge merges one entry on top of head in dir and advances the target.
+// A non-nil error stops the queue.
+func (q *Queue) merge(dir, head string, entry Entry, log io.Writer) (Result, error) {
+	result := Result{Entry: entry}
+	fail := func(err error) (Result, error) {
+		result.Status, result.Err = StatusError, err
+		fmt.Fprintf(log, "✗ %s: %v\n", entry.Branch, err)
+		return result, nil
+	}
+
+	fmt.Fprintf(log, "\n→ %s (%s)\n", entry.Branch, entry.Worker)
+	commit, err := git.Output(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+entry.Branch+"^{commit}")
+	if err != nil {
+		return fail(fmt.Errorf("branch not found: %s", entry.Branch))
+	}
+	if _, err := git.Output(dir, "merge-base", "--is-ancestor", commit, head); err == nil {
+		result.Status, result.Commit = StatusAlreadyMerged, head
+		fmt.Fprintf(log, "✓ Already merged into %s\n", q.Target)
+		return result, nil
+	}
+
+	args := []string{"merge", "--no-edit", "-m", fmt.Sprintf("Merge branch '%s' into %s", entry.Branch, q.Target)}
+	if q.NoFF {
+		args = append(args, "--no-ff")
+	}
+	if _, err := git.Output(dir, append(args, commit)...); err != nil {
+		conflicts, _ := git.Output(dir, "diff", "--name-only", "--diff-filter=U")
+		git.Output(dir, "merge", "--abort")
+		git.Output(dir, "reset", "--hard", head)
+		if conflicts == "" {
+			return fail(err)
+		}
+		result.Status, result.Conflicts = StatusConflict, strings.Split(conflicts, "\n")
+		fmt.Fprintf(log, "✗ Conflicts: %s\n", strings.Join(result.Conflicts, ", "))
+		return result, nil
+	}
+	merged, err := git.Output(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, c := range q.Checks {
+		fmt.Fprintf(log, "  check %s: %s\n", c.Name, c.Run)
+		r := checks.Run(dir, c, q.Env)
+		if !r.Passed {
+			git.Output(dir, "reset", "--hard", head)
+			result.Status, result.Check = StatusCheckFailed, &r
+			fmt.Fprintf(log, "✗ Check %s failed (exit %d)\n", c.Name, r.ExitCode)
+			if output := checks.Tail(r.Output, 20); output != "" {
+				fmt.Fprintln(log, indent(output))
+			}
+			return result, nil
+		}
+		fmt.Fprintf(log, "  ✓ %s (%s)\n", c.Name, r.Duration)
+	}
+
+	if err := q.advance(head, merged); err != nil {
+		git.Output(dir, "reset", "--hard", head)
+		result.Status, result.Err = StatusError, err
+		fmt.Fprintf(log, "✗ %v\n", err)
+		return result, err
+	}
+	result.Status, result.Commit = StatusMerged, merged
+	fmt.Fprintf(log, "✓ Merged into %s (%s)\n", q.Target, short(merged))
+	return result, nil
+}
+
+// advance fast-forwards the target from old to commit. If the target is
+// checked out in a working copy, that working copy is fast-forwarded too.
+func (q *Queue) advance(old, commit string) error {
+	if wt := checkedOutIn(q.Repo, q.Target); wt != "" {
+		if _, err := git.Output(wt, "merge", "--ff-only", "--quiet", commit); err != nil {
+			return fmt.Errorf("failed to fast-forward %s in %s: %w", q.Target, wt, err)
+		}
+		return nil
+	}
+	if _, err := git.Output(q.Repo, "update-ref", "-m", "devhive merge queue", "refs/heads/"+q.Target, commit, old); err != nil {
+		return fmt.Errorf("failed to fast-forward %s (was it updated meanwhile?): %w", q.Target, err)
+	}
+	return nil
+}
+
+// checkedOutIn returns the working copy where branch is checked out, if any
+func checkedOutIn(repo, branch string) string {
+	out, err := git.Output(repo, "worktree", "list", "--porcelain")
+	if err != nil {
+		return ""
+	}
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			path = strings.TrimPrefix(line, "worktree ")
+		}
+		if line == "branch refs/heads/"+branch {
+			return path
+		}
+	}
+	return ""
+}
+
+func short(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
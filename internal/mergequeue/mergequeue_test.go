@@ -0,0 +1,127 @@
+package mergequeue
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/iguchi/devhive/internal/checks"
+	"github.com/iguchi/devhive/internal/git"
+	"github.com/iguchi/devhive/internal/git/gittest"
+)
+
+// setupTestRepo creates a repository with main and four worker branches:
+// feat/a and feat/d merge cleanly, feat/b conflicts with feat/a and feat/c
+// breaks the check
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("checks use sh")
+	}
+
+	dir := gittest.NewRepo(t)
+	branch := func(name, file, content string) {
+		gittest.Run(t, dir, "checkout", "-q", "-b", name, "main")
+		gittest.Write(t, dir, file, content)
+		gittest.Commit(t, dir, name)
+	}
+
+	gittest.Write(t, dir, "shared.txt", "base\n")
+	gittest.Write(t, dir, ".gitignore", ".devhive/\n")
+	gittest.Commit(t, dir, "initial")
+
+	branch("feat/a", "shared.txt", "from a\n")
+	branch("feat/b", "shared.txt", "from b\n")
+	branch("feat/c", "broken.txt", "oops\n")
+	branch("feat/d", "d.txt", "d\n")
+	gittest.Run(t, dir, "checkout", "-q", "main")
+
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	var seen []string
+	q := &Queue{
+		Repo:     dir,
+		Target:   "main",
+		Checks:   []checks.Check{{Name: "sane", Run: "test ! -f broken.txt"}},
+		OnResult: func(r Result) { seen = append(seen, r.Worker) },
+	}
+	results, err := q.Run([]Entry{
+		{Worker: "a", Branch: "feat/a"},
+		{Worker: "b", Branch: "feat/b"},
+		{Worker: "c", Branch: "feat/c"},
+		{Worker: "d", Branch: "feat/d"},
+		{Worker: "a2", Branch: "feat/a"},
+		{Worker: "x", Branch: "feat/missing"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []string{StatusMerged, StatusConflict, StatusCheckFailed, StatusMerged, StatusAlreadyMerged, StatusError}
+	if len(results) != len(want) || len(seen) != len(want) {
+		t.Fatalf("Expected %d results, got %+v", len(want), results)
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("%s: expected %s, got %s (%v)", results[i].Branch, status, results[i].Status, results[i].Err)
+		}
+	}
+	if len(results[1].Conflicts) != 1 || results[1].Conflicts[0] != "shared.txt" {
+		t.Errorf("Expected a conflict on shared.txt, got %v", results[1].Conflicts)
+	}
+	if results[2].Check == nil || results[2].Check.Name != "sane" {
+		t.Errorf("Expected the sane check to fail, got %+v", results[2].Check)
+	}
+
+	// main was fast-forwarded to feat/a and feat/d, and so was the working copy
+	head := gittest.Run(t, dir, "rev-parse", "main")
+	if results[3].Commit != head || results[4].Commit != head {
+		t.Errorf("Expected main at %s, got %s", results[3].Commit, head)
+	}
+	for _, branch := range []string{"feat/a", "feat/d"} {
+		if _, err := git.Output(dir, "merge-base", "--is-ancestor", branch, "main"); err != nil {
+			t.Errorf("Expected %s merged into main", branch)
+		}
+	}
+	if _, err := git.Output(dir, "merge-base", "--is-ancestor", "feat/c", "main"); err == nil {
+		t.Error("Expected feat/c not to be merged")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "d.txt")); string(data) != "d\n" {
+		t.Error("Expected the checked out main to be fast-forwarded")
+	}
+	if status := gittest.Run(t, dir, "status", "--porcelain"); status != "" {
+		t.Errorf("Expected a clean working copy, got %q", status)
+	}
+
+	// The integration worktree is removed
+	if list := gittest.Run(t, dir, "worktree", "list"); strings.Count(list, "\n") != 0 {
+		t.Errorf("Expected only the main worktree, got:\n%s", list)
+	}
+}
+
+func TestRunTargetNotCheckedOut(t *testing.T) {
+	dir := setupTestRepo(t)
+	gittest.Run(t, dir, "checkout", "-q", "-b", "develop")
+
+	q := &Queue{Repo: dir, Target: "main", NoFF: true}
+	results, err := q.Run([]Entry{{Worker: "d", Branch: "feat/d"}})
+	if err != nil || len(results) != 1 || results[0].Status != StatusMerged {
+		t.Fatalf("Expected feat/d merged, got %+v (%v)", results, err)
+	}
+	if parents := gittest.Run(t, dir, "rev-list", "--parents", "-n", "1", "main"); len(strings.Fields(parents)) != 3 {
+		t.Errorf("Expected a merge commit with --no-ff, got %s", parents)
+	}
+	if branch := gittest.Run(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "develop" {
+		t.Errorf("Expected the working copy to stay on develop, got %s", branch)
+	}
+
+	if _, err := (&Queue{Repo: dir, Target: "nope"}).Run(nil); err == nil {
+		t.Error("Expected an error for a missing target branch")
+	}
+}
@@ -0,0 +1,87 @@
+// Package monitor collects observable facts about workers from their worktrees,
+// independent of what the agents report themselves.
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+	"github.com/iguchi/devhive/internal/git"
+)
+
+// shortstatPattern matches the counts in `git diff --shortstat` output
+var shortstatPattern = regexp.MustCompile(`(\d+) (insertion|deletion)`)
+
+// CollectGitState reads the git state of a worktree relative to the base branch
+// Untracked files listed in ignore (e.g., generated context files) are not counted
+func CollectGitState(worktreePath, baseBranch string, ignore ...string) (db.GitState, error) {
+	var state db.GitState
+
+	// Last commit on the worker branch
+	out, err := git.Output(worktreePath, "log", "-1", "--format=%H %ct")
+	if err != nil {
+		return state, err
+	}
+	if fields := strings.Fields(out); len(fields) == 2 {
+		state.LastCommit = fields[0]
+		if ts, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			t := time.Unix(ts, 0).UTC()
+			state.LastCommitAt = &t
+		}
+	}
+
+	// Commits ahead of the base branch
+	out, err = git.Output(worktreePath, "rev-list", "--count", baseBranch+"..HEAD")
+	if err != nil {
+		return state, fmt.Errorf("failed to compare with %s: %w", baseBranch, err)
+	}
+	state.CommitsAhead, _ = strconv.Atoi(out)
+
+	// Committed changes since the branch point
+	out, err = git.Output(worktreePath, "diff", "--shortstat", baseBranch+"...HEAD")
+	if err != nil {
+		return state, err
+	}
+	state.Insertions, state.Deletions = ParseShortstat(out)
+
+	// Uncommitted changes (modified, staged and untracked files)
+	out, err = git.Output(worktreePath, "status", "--porcelain")
+	if err != nil {
+		return state, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" || (strings.HasPrefix(line, "?? ") && contains(ignore, line[3:])) {
+			continue
+		}
+		state.Uncommitted++
+	}
+
+	return state, nil
+}
+
+// ParseShortstat extracts insertions and deletions from `git diff --shortstat` output
+func ParseShortstat(s string) (insertions, deletions int) {
+	for _, m := range shortstatPattern.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "insertion" {
+			insertions = n
+		} else {
+			deletions = n
+		}
+	}
+	return insertions, deletions
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/iguchi/devhive/internal/git/gittest"
+)
+
+// setupTestRepo creates a git repository with a main branch and a worker branch
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := gittest.NewRepo(t)
+	write := func(name, content string) { gittest.Write(t, dir, name, content) }
+
+	write("a.txt", "one\ntwo\n")
+	gittest.Commit(t, dir, "initial")
+
+	gittest.Run(t, dir, "checkout", "-q", "-b", "feat/work")
+	write("a.txt", "one\n")
+	write("b.txt", "three\nfour\nfive\n")
+	gittest.Commit(t, dir, "work 1")
+	write("c.txt", "six\n")
+	gittest.Commit(t, dir, "work 2")
+
+	// Uncommitted changes
+	write("d.txt", "untracked\n")
+	write("CONTEXT.md", "generated\n")
+
+	return dir
+}
+
+func TestCollectGitState(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	state, err := CollectGitState(dir, "main", "CONTEXT.md")
+	if err != nil {
+		t.Fatalf("CollectGitState failed: %v", err)
+	}
+	if state.CommitsAhead != 2 {
+		t.Errorf("Expected 2 commits ahead, got %d", state.CommitsAhead)
+	}
+	if state.Insertions != 4 {
+		t.Errorf("Expected 4 insertions, got %d", state.Insertions)
+	}
+	if state.Deletions != 1 {
+		t.Errorf("Expected 1 deletion, got %d", state.Deletions)
+	}
+	if state.Uncommitted != 1 {
+		t.Errorf("Expected 1 uncommitted file, got %d", state.Uncommitted)
+	}
+	if state.LastCommit == "" || state.LastCommitAt == nil {
+		t.Error("Expected last commit to be set")
+	}
+
+	// Unknown base branch is an error
+	if _, err := CollectGitState(dir, "no-such-branch"); err == nil {
+		t.Error("Expected error for unknown base branch")
+	}
+}
+
+func TestParseShortstat(t *testing.T) {
+	tests := []struct {
+		input string
+		ins   int
+		del   int
+	}{
+		{" 3 files changed, 10 insertions(+), 2 deletions(-)", 10, 2},
+		{" 1 file changed, 1 insertion(+)", 1, 0},
+		{" 1 file changed, 5 deletions(-)", 0, 5},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		ins, del := ParseShortstat(tt.input)
+		if ins != tt.ins || del != tt.del {
+			t.Errorf("ParseShortstat(%q) = %d, %d; want %d, %d", tt.input, ins, del, tt.ins, tt.del)
+		}
+	}
+}
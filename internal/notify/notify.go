@@ -0,0 +1,364 @@
+// Package notify delivers events to outbound webhooks.
+//
+// Matching events are queued in the database (webhook_deliveries) when they
+// are logged. Long-running commands (serve, watch, top, mcp) POST every due
+// delivery in the background; the others only send their own before exit.
+// Failed deliveries are retried with exponential backoff.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// Payload formats
+const (
+	FormatGeneric = "generic"
+	FormatSlack   = "slack"
+)
+
+// Formats are the supported payload formats
+var Formats = []string{FormatGeneric, FormatSlack}
+
+// Match selects events by type and, optionally, by values in their data
+// (e.g. {"status": "completed"} for worker_status_changed)
+type Match struct {
+	Type string
+	Data map[string]string
+}
+
+// Matches reports whether an event matches
+func (m Match) Matches(e db.Event) bool {
+	if m.Type != e.EventType {
+		return false
+	}
+	if len(m.Data) == 0 {
+		return true
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+		return false
+	}
+	for key, want := range m.Data {
+		value, ok := data[key]
+		if !ok || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Webhook is an endpoint and the events sent to it
+type Webhook struct {
+	URL    string
+	Format string  // generic (default) or slack
+	Events []Match // empty: every event
+}
+
+// Matches reports whether an event should be sent to the webhook
+func (w Webhook) Matches(e db.Event) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, m := range w.Events {
+		if m.Matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier queues and delivers events to webhooks
+type Notifier struct {
+	db       *db.DB
+	project  string
+	webhooks []Webhook
+
+	// Client sends the requests
+	Client *http.Client
+	// MaxAttempts is how many times a delivery is tried before it fails for good
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for each further one
+	Backoff time.Duration
+	// PollInterval is how often Run looks for due retries
+	PollInterval time.Duration
+	// UserAgent is sent with every request
+	UserAgent string
+
+	kick chan struct{}
+
+	mu     sync.Mutex
+	queued []int // Deliveries queued by Enqueue, for FlushQueued
+}
+
+// New creates a notifier for a project's webhooks
+func New(database *db.DB, project string, webhooks []Webhook) *Notifier {
+	return &Notifier{
+		db:           database,
+		project:      project,
+		webhooks:     webhooks,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  5,
+		Backoff:      30 * time.Second,
+		PollInterval: 5 * time.Second,
+		UserAgent:    "devhive",
+		kick:         make(chan struct{}, 1),
+	}
+}
+
+// Enqueue queues an event for every matching webhook.
+// Register it with db.OnEvent to queue events as they are logged.
+func (n *Notifier) Enqueue(e db.Event) {
+	var message *db.Message
+	queued := false
+	for _, w := range n.webhooks {
+		if !w.Matches(e) {
+			continue
+		}
+		if message == nil && e.EventType == "message_sent" {
+			message = n.lookupMessage(e)
+		}
+		payload, err := Payload(w.Format, n.project, e, message)
+		if err != nil {
+			continue
+		}
+		if id, err := n.db.EnqueueDelivery(e.ID, w.URL, string(payload)); err == nil {
+			n.mu.Lock()
+			n.queued = append(n.queued, int(id))
+			n.mu.Unlock()
+			queued = true
+		}
+	}
+
+	if queued {
+		select {
+		case n.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// lookupMessage returns the message a message_sent event refers to, if recorded
+func (n *Notifier) lookupMessage(e db.Event) *db.Message {
+	var data struct {
+		MessageID int `json:"message_id"`
+	}
+	if json.Unmarshal([]byte(e.Data), &data) != nil || data.MessageID == 0 {
+		return nil
+	}
+	message, _ := n.db.GetMessage(data.MessageID)
+	return message
+}
+
+// Run delivers queued events until ctx is done: right after Enqueue, and
+// every PollInterval for retries and events queued by other processes.
+// A delivery in progress when ctx is done is finished first.
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// The flush covers everything queued so far
+		n.takeQueued()
+		n.flush(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.kick:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush attempts every delivery that is due. Returns the number delivered.
+func (n *Notifier) Flush() int {
+	return n.flush(context.Background())
+}
+
+// flush attempts due deliveries one at a time until none is left or ctx is done
+func (n *Notifier) flush(ctx context.Context) int {
+	delivered := 0
+	for ctx.Err() == nil {
+		due, err := n.db.ClaimDueDeliveries(1)
+		if err != nil || len(due) == 0 {
+			break
+		}
+		if n.deliver(context.Background(), due[0]) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// FlushQueued attempts the deliveries this notifier queued that are still
+// due, leaving retries and other processes' deliveries to Run. Requests are
+// cancelled when ctx is done; what is left stays queued. Returns the number
+// delivered.
+func (n *Notifier) FlushQueued(ctx context.Context) int {
+	delivered := 0
+	for _, id := range n.takeQueued() {
+		if ctx.Err() != nil {
+			break
+		}
+		d, err := n.db.ClaimDelivery(id)
+		if err != nil || d == nil {
+			continue
+		}
+		if n.deliver(ctx, *d) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// takeQueued returns and forgets the deliveries queued by Enqueue
+func (n *Notifier) takeQueued() []int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ids := n.queued
+	n.queued = nil
+	return ids
+}
+
+// deliver sends a claimed delivery and records the attempt. Reports success.
+func (n *Notifier) deliver(ctx context.Context, d db.Delivery) bool {
+	if err := n.send(ctx, d); err != nil {
+		n.db.FailDelivery(d.ID, err.Error(), n.retryAt(d.Attempts+1))
+		return false
+	}
+	n.db.CompleteDelivery(d.ID)
+	return true
+}
+
+// retryAt returns when to retry after the given number of attempts (nil: give up)
+func (n *Notifier) retryAt(attempts int) *time.Time {
+	if attempts >= n.MaxAttempts {
+		return nil
+	}
+	t := time.Now().Add(n.Backoff << (attempts - 1))
+	return &t
+}
+
+// send POSTs a delivery's payload
+func (n *Notifier) send(ctx context.Context, d db.Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", d.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", n.UserAgent)
+	req.Header.Set("X-DevHive-Event", d.EventType)
+	req.Header.Set("X-DevHive-Delivery", fmt.Sprint(d.ID))
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// ============================================
+// Payloads
+// ============================================
+
+// genericPayload is the body sent in the generic format
+type genericPayload struct {
+	Project string      `json:"project"`
+	Text    string      `json:"text"`
+	Event   db.Event    `json:"event"`
+	Message *db.Message `json:"message,omitempty"`
+}
+
+// Payload builds the request body for an event.
+// message is the message of a message_sent event (may be nil).
+func Payload(format, project string, e db.Event, message *db.Message) ([]byte, error) {
+	text := Summary(project, e, message)
+	switch format {
+	case "", FormatGeneric:
+		return json.Marshal(genericPayload{Project: project, Text: text, Event: e, Message: message})
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": text})
+	}
+	return nil, fmt.Errorf("unknown webhook format: %s (valid: %s)", format, strings.Join(Formats, ", "))
+}
+
+// Summary describes an event in one line (plus the message content, if any)
+func Summary(project string, e db.Event, message *db.Message) string {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(e.Data), &data)
+	str := func(key string) string {
+		if v, ok := data[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+
+	prefix := fmt.Sprintf("[%s] ", project)
+	switch e.EventType {
+	case "worker_error":
+		return prefix + fmt.Sprintf("❌ %s reported an error: %s", e.Worker, str("message"))
+	case "worker_status_changed":
+		return prefix + fmt.Sprintf("%s is now %s", e.Worker, str("status"))
+	case "worker_stalled":
+		return prefix + fmt.Sprintf("⚠️ %s: %s", e.Worker, str("message"))
+	case "worker_progress_updated":
+		text := prefix + fmt.Sprintf("%s progress: %s%%", e.Worker, str("progress"))
+		if activity := str("activity"); activity != "" {
+			text += " (" + activity + ")"
+		}
+		return text
+	case "message_sent":
+		text := prefix + fmt.Sprintf("💬 %s → %s (%s)", e.Worker, str("to"), str("type"))
+		if message != nil {
+			if message.Subject != "" {
+				text += ": " + message.Subject
+			}
+			text += "\n" + message.Content
+		}
+		return text
+	}
+
+	// Other events: type [worker] key:value,...
+	text := prefix + e.EventType
+	if e.Worker != "" {
+		text += " [" + e.Worker + "]"
+	}
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, key+":"+str(key))
+	}
+	if len(parts) > 0 {
+		text += " " + strings.Join(parts, ",")
+	}
+	return text
+}
+
+// Test sends a test payload directly to a webhook (not queued or retried)
+func (n *Notifier) Test(w Webhook) error {
+	e := db.Event{EventType: "test", Data: `{"message":"DevHive webhook test"}`, CreatedAt: time.Now().UTC()}
+	payload, err := Payload(w.Format, n.project, e, nil)
+	if err != nil {
+		return err
+	}
+	return n.send(context.Background(), db.Delivery{URL: w.URL, Payload: string(payload), EventType: e.EventType})
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// setupTestDB opens a temporary database with fe and be in sprint-01
+func setupTestDB(t *testing.T) *db.DB {
+	t.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "devhive-notify-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	database, err := db.Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	database.CreateSprint("sprint-01")
+	database.RegisterWorker("fe", "sprint-01")
+	database.RegisterWorker("be", "sprint-01")
+	return database
+}
+
+// stub is a webhook endpoint that records requests and answers with codes in turn
+type stub struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, string(body))
+	code := http.StatusOK
+	if len(s.codes) > 0 {
+		code, s.codes = s.codes[0], s.codes[1:]
+	}
+	w.WriteHeader(code)
+	io.WriteString(w, http.StatusText(code))
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		match Match
+		event db.Event
+		want  bool
+	}{
+		{Match{Type: "worker_error"}, db.Event{EventType: "worker_error", Data: `{"message":"boom"}`}, true},
+		{Match{Type: "worker_error"}, db.Event{EventType: "worker_registered"}, false},
+		{Match{Type: "worker_status_changed", Data: map[string]string{"status": "completed"}},
+			db.Event{EventType: "worker_status_changed", Data: `{"status":"completed"}`}, true},
+		{Match{Type: "worker_status_changed", Data: map[string]string{"status": "completed"}},
+			db.Event{EventType: "worker_status_changed", Data: `{"status":"working"}`}, false},
+		{Match{Type: "message_sent", Data: map[string]string{"to": "pm", "type": "help"}},
+			db.Event{EventType: "message_sent", Data: `{"to":"pm","type":"help","message_id":3}`}, true},
+		{Match{Type: "message_sent", Data: map[string]string{"to": "pm", "type": "help"}},
+			db.Event{EventType: "message_sent", Data: `{"to":"pm","type":"report"}`}, false},
+		{Match{Type: "worker_progress_updated", Data: map[string]string{"progress": "100"}},
+			db.Event{EventType: "worker_progress_updated", Data: `{"progress":100}`}, true},
+		{Match{Type: "worker_status_changed", Data: map[string]string{"status": "completed"}},
+			db.Event{EventType: "worker_status_changed"}, false},
+	}
+	for i, tt := range tests {
+		if got := tt.match.Matches(tt.event); got != tt.want {
+			t.Errorf("case %d: Matches(%+v) = %v, want %v", i, tt.event, got, tt.want)
+		}
+	}
+
+	if !(Webhook{}).Matches(db.Event{EventType: "anything"}) {
+		t.Error("Expected a webhook without events to match every event")
+	}
+}
+
+func TestDeliverHelpRequest(t *testing.T) {
+	database := setupTestDB(t)
+	endpoint := &stub{}
+	ts := httptest.NewServer(endpoint)
+	defer ts.Close()
+
+	n := New(database, "app", []Webhook{{
+		URL:    ts.URL,
+		Events: []Match{{Type: "message_sent", Data: map[string]string{"to": "pm", "type": "help"}}},
+	}})
+	database.OnEvent(n.Enqueue)
+
+	database.SendMessage("fe", "pm", "report", "📋 Progress Report", "halfway")
+	database.SendMessage("fe", "pm", "help", "🆘 Help Request", "Stuck on OAuth")
+	if delivered := n.Flush(); delivered != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", delivered)
+	}
+
+	if len(endpoint.bodies) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(endpoint.bodies))
+	}
+	if ct := endpoint.requests[0].Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json, got %s", ct)
+	}
+	if typ := endpoint.requests[0].Header.Get("X-DevHive-Event"); typ != "message_sent" {
+		t.Errorf("Expected X-DevHive-Event message_sent, got %s", typ)
+	}
+
+	var payload struct {
+		Project string
+		Text    string
+		Event   db.Event
+		Message *db.Message
+	}
+	if err := json.Unmarshal([]byte(endpoint.bodies[0]), &payload); err != nil {
+		t.Fatalf("Invalid payload %s: %v", endpoint.bodies[0], err)
+	}
+	if payload.Project != "app" || payload.Event.Worker != "fe" || payload.Message == nil || payload.Message.Content != "Stuck on OAuth" {
+		t.Errorf("Unexpected payload: %s", endpoint.bodies[0])
+	}
+	if !strings.Contains(payload.Text, "Stuck on OAuth") {
+		t.Errorf("Expected the message in the text, got %q", payload.Text)
+	}
+
+	deliveries, _ := database.GetDeliveries(10, "")
+	if len(deliveries) != 1 || deliveries[0].Status != "delivered" || deliveries[0].Attempts != 1 || deliveries[0].DeliveredAt == nil {
+		t.Errorf("Expected one delivered delivery, got %+v", deliveries)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	database := setupTestDB(t)
+	endpoint := &stub{codes: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	ts := httptest.NewServer(endpoint)
+	defer ts.Close()
+
+	n := New(database, "app", []Webhook{{URL: ts.URL, Format: FormatSlack, Events: []Match{{Type: "worker_error"}}}})
+	n.Backoff = 0
+	database.OnEvent(n.Enqueue)
+
+	database.ReportWorkerError("fe", "tests failing")
+	if delivered := n.Flush(); delivered != 1 {
+		t.Fatalf("Expected the third attempt to succeed, got %d delivered", delivered)
+	}
+	if len(endpoint.bodies) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(endpoint.bodies))
+	}
+	var slack map[string]string
+	if err := json.Unmarshal([]byte(endpoint.bodies[2]), &slack); err != nil || !strings.Contains(slack["text"], "tests failing") {
+		t.Errorf("Expected a Slack payload with the error, got %s", endpoint.bodies[2])
+	}
+	deliveries, _ := database.GetDeliveries(10, "delivered")
+	if len(deliveries) != 1 || deliveries[0].Attempts != 3 {
+		t.Errorf("Expected one delivery after 3 attempts, got %+v", deliveries)
+	}
+
+	// Gives up after MaxAttempts and keeps the last error
+	endpoint.codes = []int{500, 500, 500, 500, 500}
+	database.ReportWorkerError("be", "build broken")
+	n.Flush()
+	failed, _ := database.GetDeliveries(10, "failed")
+	if len(failed) != 1 || failed[0].Attempts != n.MaxAttempts || !strings.HasPrefix(failed[0].LastError, "HTTP 500") {
+		t.Fatalf("Expected one failed delivery after %d attempts, got %+v", n.MaxAttempts, failed)
+	}
+
+	// Failed deliveries can be queued again
+	if count, _ := database.RetryFailedDeliveries(); count != 1 {
+		t.Errorf("Expected 1 delivery requeued, got %d", count)
+	}
+	if delivered := n.Flush(); delivered != 1 {
+		t.Errorf("Expected the requeued delivery to be delivered, got %d", delivered)
+	}
+}
+
+func TestRetryIsScheduled(t *testing.T) {
+	database := setupTestDB(t)
+	endpoint := &stub{codes: []int{http.StatusServiceUnavailable}}
+	ts := httptest.NewServer(endpoint)
+	defer ts.Close()
+
+	n := New(database, "app", []Webhook{{URL: ts.URL}})
+	database.OnEvent(n.Enqueue)
+
+	database.UpdateWorkerStatus("fe", "completed", nil)
+	n.Flush()
+
+	// With the default backoff the retry is not due yet
+	pending, _ := database.GetDeliveries(10, "pending")
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].NextAttemptAt == nil {
+		t.Fatalf("Expected one pending delivery scheduled for retry, got %+v", pending)
+	}
+	if n.Flush() != 0 || len(endpoint.bodies) != 1 {
+		t.Errorf("Expected no attempt before the retry is due, got %d requests", len(endpoint.bodies))
+	}
+}
+
+func TestPayloadFormats(t *testing.T) {
+	e := db.Event{ID: 1, EventType: "worker_status_changed", Worker: "fe", Data: `{"status":"completed"}`}
+
+	body, err := Payload(FormatSlack, "app", e, nil)
+	if err != nil || string(body) != `{"text":"[app] fe is now completed"}` {
+		t.Errorf("Unexpected Slack payload %s (%v)", body, err)
+	}
+	if _, err := Payload("teams", "app", e, nil); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestFlushQueuedOnlyOwnDeliveries(t *testing.T) {
+	database := setupTestDB(t)
+	endpoint := &stub{}
+	ts := httptest.NewServer(endpoint)
+	defer ts.Close()
+
+	// Another process queued a delivery
+	database.UpdateWorkerStatus("fe", "working", nil)
+	events, _ := database.GetRecentEvents(1, nil, nil)
+	New(database, "app", []Webhook{{URL: ts.URL}}).Enqueue(events[0])
+
+	n := New(database, "app", []Webhook{{URL: ts.URL}})
+	database.OnEvent(n.Enqueue)
+	database.ReportWorkerError("fe", "tests failing")
+
+	if delivered := n.FlushQueued(context.Background()); delivered != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", delivered)
+	}
+	if len(endpoint.bodies) != 1 || !strings.Contains(endpoint.bodies[0], "tests failing") {
+		t.Errorf("Expected only this notifier's delivery, got %v", endpoint.bodies)
+	}
+	if pending, _ := database.GetDeliveries(10, "pending"); len(pending) != 1 {
+		t.Errorf("Expected the other delivery to stay pending, got %+v", pending)
+	}
+
+	// Nothing left once flushed; a done context sends nothing
+	if delivered := n.FlushQueued(context.Background()); delivered != 0 {
+		t.Errorf("Expected no delivery on the second flush, got %d", delivered)
+	}
+	database.ReportWorkerError("be", "build broken")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if delivered := n.FlushQueued(ctx); delivered != 0 || len(endpoint.bodies) != 1 {
+		t.Errorf("Expected no attempt after ctx is done, got %d", len(endpoint.bodies))
+	}
+}
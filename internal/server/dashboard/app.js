@@ -0,0 +1,279 @@
+// DevHive dashboard: renders /api data and follows /api/events/stream.
+"use strict";
+
+const STATUS_LABELS = {
+  pending: "⏳ pending",
+  working: "🔨 working",
+  completed: "✅ done",
+  blocked: "🚫 blocked",
+  error: "❌ error",
+};
+
+const SESSION_LABELS = {
+  running: "▶ running",
+  waiting_permission: "⏸ waiting permission",
+  idle: "○ idle",
+  stopped: "■ stopped",
+};
+
+const MAX_EVENTS = 200;
+
+const $ = (id) => document.getElementById(id);
+
+async function api(path, options) {
+  const resp = await fetch(path, options);
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function post(path, data) {
+  return api(path, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: data ? JSON.stringify(data) : undefined,
+  });
+}
+
+function formatTime(iso) {
+  return new Date(iso).toLocaleTimeString([], { hour: "2-digit", minute: "2-digit", second: "2-digit" });
+}
+
+function formatAgo(iso) {
+  const seconds = Math.max(0, (Date.now() - new Date(iso)) / 1000);
+  if (seconds < 60) return "just now";
+  if (seconds < 3600) return Math.floor(seconds / 60) + "m ago";
+  if (seconds < 86400) return Math.floor(seconds / 3600) + "h ago";
+  return Math.floor(seconds / 86400) + "d ago";
+}
+
+// ============================================
+// Sprint / workers
+// ============================================
+
+async function loadSprint() {
+  const el = $("sprint");
+  try {
+    const sprint = await api("/api/sprints/active");
+    el.replaceChildren("Sprint ");
+    const id = document.createElement("strong");
+    id.textContent = sprint.id;
+    el.append(id);
+    if (sprint.goal) {
+      el.append(" — " + sprint.goal);
+    }
+  } catch (e) {
+    el.textContent = "No active sprint";
+  }
+}
+
+async function loadWorkers() {
+  const workers = await api("/api/workers");
+  const container = $("workers");
+  const template = $("worker-template");
+
+  container.replaceChildren();
+  if (workers.length === 0) {
+    container.innerHTML = '<div class="empty">No workers registered</div>';
+  }
+
+  let total = 0;
+  const counts = {};
+  for (const w of workers) {
+    total += w.progress;
+    counts[w.status] = (counts[w.status] || 0) + 1;
+
+    const card = template.content.firstElementChild.cloneNode(true);
+    card.classList.add(w.status);
+    card.querySelector(".name").textContent = w.name;
+    card.querySelector(".status").textContent = STATUS_LABELS[w.status] || w.status;
+    card.querySelector(".session").textContent = SESSION_LABELS[w.session_state] || w.session_state;
+    card.querySelector(".unread").textContent = w.unread_messages > 0 ? w.unread_messages : "";
+    card.querySelector(".fill").style.width = w.progress + "%";
+    card.querySelector(".percent").textContent = w.progress + "%";
+    card.querySelector(".activity").textContent = w.activity;
+    if (w.status === "pending" && w.pending_reason) {
+      card.querySelector(".pending").textContent = "⏸ " + w.pending_reason;
+    }
+    if (w.error_count > 0) {
+      card.querySelector(".error").textContent = `❌ ${w.error_count} error(s): ${w.last_error}`;
+    }
+    renderDiffstat(card.querySelector(".diffstat"), w);
+    container.append(card);
+  }
+
+  const avg = workers.length ? Math.floor(total / workers.length) : 0;
+  $("overall-bar").style.width = avg + "%";
+  $("overall-text").textContent = avg + "%";
+  $("worker-summary").textContent = Object.entries(counts)
+    .map(([status, n]) => `${n} ${status}`)
+    .join(" · ");
+}
+
+function renderDiffstat(el, w) {
+  if (!w.monitored_at) {
+    el.textContent = "git: not monitored";
+    return;
+  }
+  const ins = document.createElement("span");
+  ins.className = "ins";
+  ins.textContent = "+" + w.insertions;
+  const del = document.createElement("span");
+  del.className = "del";
+  del.textContent = "-" + w.deletions;
+  el.replaceChildren(`${w.commits_ahead} commit(s) `, ins, " ", del, `, ${w.uncommitted} uncommitted`);
+  if (w.last_commit_at) {
+    el.append(", last commit " + formatAgo(w.last_commit_at));
+  }
+}
+
+// ============================================
+// Inbox
+// ============================================
+
+async function loadInbox() {
+  const all = $("inbox-all").checked;
+  const messages = await api("/api/messages?worker=pm" + (all ? "&all=true" : ""));
+  const container = $("inbox");
+  const template = $("message-template");
+
+  const unread = messages.filter((m) => !m.read_at).length;
+  $("inbox-count").textContent = unread > 0 ? unread : "";
+
+  container.replaceChildren();
+  if (messages.length === 0) {
+    container.innerHTML = '<div class="empty">No messages</div>';
+    return;
+  }
+
+  for (const m of messages) {
+    const el = template.content.firstElementChild.cloneNode(true);
+    if (m.read_at) el.classList.add("read");
+    if (m.reply_to) el.classList.add("reply-in-thread");
+    el.querySelector(".id").textContent = "#" + m.id;
+    el.querySelector(".subject").textContent = m.subject || m.message_type;
+    el.querySelector(".meta").textContent =
+      `from ${m.from_worker}` + (m.reply_to ? ` (re #${m.reply_to})` : "") + ` · ${formatAgo(m.created_at)}`;
+    el.querySelector(".content").textContent = m.content;
+
+    const form = el.querySelector(".reply");
+    form.addEventListener("submit", async (ev) => {
+      ev.preventDefault();
+      const input = form.elements.content;
+      if (!input.value.trim()) return;
+      try {
+        await post(`/api/messages/${m.id}/reply`, { content: input.value });
+        input.value = "";
+        loadInbox();
+      } catch (e) {
+        alert("Reply failed: " + e.message);
+      }
+    });
+    el.querySelector(".mark-read").addEventListener("click", async () => {
+      await post(`/api/messages/${m.id}/read`);
+      loadInbox();
+    });
+
+    container.append(el);
+  }
+}
+
+// ============================================
+// Events
+// ============================================
+
+function eventDetails(e) {
+  if (!e.data) return "";
+  return Object.entries(e.data)
+    .filter(([, v]) => v !== "" && v !== null)
+    .map(([k, v]) => `${k}=${Array.isArray(v) ? v.join(",") : v}`)
+    .join(" ");
+}
+
+function addEvent(e, fresh) {
+  const li = document.createElement("li");
+  if (fresh) {
+    li.className = "fresh";
+    setTimeout(() => li.classList.remove("fresh"), 3000);
+  }
+  const time = document.createElement("span");
+  time.className = "time";
+  time.textContent = formatTime(e.created_at) + " ";
+  li.append(time, e.event_type);
+  if (e.worker) {
+    const worker = document.createElement("span");
+    worker.className = "worker-name";
+    worker.textContent = ` [${e.worker}]`;
+    li.append(worker);
+  }
+  const details = eventDetails(e);
+  if (details) li.append(" " + details);
+
+  const list = $("events");
+  list.prepend(li);
+  while (list.children.length > MAX_EVENTS) {
+    list.lastElementChild.remove();
+  }
+}
+
+// Refresh views affected by events, coalescing bursts
+let refreshTimer = null;
+const pending = new Set();
+
+function scheduleRefresh(...views) {
+  views.forEach((v) => pending.add(v));
+  clearTimeout(refreshTimer);
+  refreshTimer = setTimeout(() => {
+    if (pending.has("workers")) loadWorkers().catch(console.error);
+    if (pending.has("inbox")) loadInbox().catch(console.error);
+    if (pending.has("sprint")) loadSprint().catch(console.error);
+    pending.clear();
+  }, 300);
+}
+
+async function followEvents() {
+  const [events, types] = await Promise.all([api("/api/events?limit=50"), api("/api/event-types")]);
+  for (const e of events) addEvent(e, false);
+  const lastID = events.length ? events[events.length - 1].id : undefined;
+
+  const onEvent = (msg) => {
+    const e = JSON.parse(msg.data);
+    addEvent(e, true);
+    if (e.event_type.startsWith("message_")) {
+      scheduleRefresh("workers", "inbox");
+    } else if (e.event_type.startsWith("sprint_")) {
+      scheduleRefresh("workers", "sprint");
+    } else {
+      scheduleRefresh("workers");
+    }
+  };
+
+  // EventSource resumes from Last-Event-ID on reconnect
+  const source = new EventSource("/api/events/stream" + (lastID !== undefined ? "?since=" + lastID : ""));
+  source.onopen = () => {
+    $("connection").className = "connection online";
+  };
+  source.onerror = () => {
+    $("connection").className = "connection offline";
+  };
+  // Events are sent with their type as the SSE event name
+  for (const type of types) {
+    source.addEventListener(type, onEvent);
+  }
+}
+
+// ============================================
+// Startup
+// ============================================
+
+$("inbox-all").addEventListener("change", () => loadInbox().catch(console.error));
+
+loadSprint();
+loadWorkers().catch(console.error);
+loadInbox().catch(console.error);
+followEvents().catch(console.error);
+
+// Git state is refreshed by the server in the background
+setInterval(() => loadWorkers().catch(console.error), 30000);
@@ -0,0 +1,617 @@
+// Package server exposes the DevHive database as a local REST/JSON API
+// with a Server-Sent Events stream of events, and serves the browser
+// dashboard built on it.
+//
+// Writes go through the same internal/db operations as the CLI, so the
+// events they log are identical to the CLI's.
+package server
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// dashboard holds the single-page dashboard served at /
+//
+//go:embed dashboard
+var dashboard embed.FS
+
+// dashboardFS is the dashboard directory as the web root
+var dashboardFS, _ = fs.Sub(dashboard, "dashboard")
+
+// HeartbeatInterval is how often an idle event stream sends a keep-alive comment
+var HeartbeatInterval = 15 * time.Second
+
+// Valid values, as accepted by the CLI
+var (
+	validStatuses      = []string{"pending", "working", "completed", "blocked", "error"}
+	validSessionStates = []string{"running", "waiting_permission", "idle", "stopped"}
+)
+
+// Server serves the API for a database
+type Server struct {
+	db  *db.DB
+	mux *http.ServeMux
+
+	// AutoComplete marks a worker completed when its progress reaches 100
+	// (defaults.auto_complete in .devhive.yaml)
+	AutoComplete bool
+
+	// Complete is called instead of marking the worker completed directly
+	// when AutoComplete applies. It may refuse (e.g. failing checks) and set
+	// another status, returning false.
+	Complete func(worker string) (bool, error)
+}
+
+// New creates a server for a database
+func New(database *db.DB) *Server {
+	s := &Server{db: database, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /api/workers", s.listWorkers)
+	s.mux.HandleFunc("GET /api/workers/{name}", s.getWorker)
+	s.mux.HandleFunc("POST /api/workers/{name}/progress", s.updateProgress)
+	s.mux.HandleFunc("POST /api/workers/{name}/status", s.updateStatus)
+	s.mux.HandleFunc("POST /api/workers/{name}/session", s.updateSession)
+
+	s.mux.HandleFunc("GET /api/messages", s.listMessages)
+	s.mux.HandleFunc("POST /api/messages", s.sendMessage)
+	s.mux.HandleFunc("GET /api/messages/{id}", s.getMessage)
+	s.mux.HandleFunc("POST /api/messages/{id}/reply", s.replyMessage)
+	s.mux.HandleFunc("POST /api/messages/{id}/read", s.markRead)
+	s.mux.HandleFunc("GET /api/threads/{id}", s.getThread)
+
+	s.mux.HandleFunc("GET /api/events", s.listEvents)
+	s.mux.HandleFunc("GET /api/events/stream", s.streamEvents)
+	s.mux.HandleFunc("GET /api/event-types", s.listEventTypes)
+
+	s.mux.HandleFunc("GET /api/sprints", s.listSprints)
+	s.mux.HandleFunc("GET /api/sprints/active", s.getActiveSprint)
+	s.mux.HandleFunc("GET /api/sprints/{id}", s.getSprint)
+	s.mux.HandleFunc("GET /api/sprints/{id}/workers", s.getSprintWorkers)
+
+	s.mux.Handle("GET /", http.FileServerFS(dashboardFS))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkRequest(r); err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// crossOrigin rejects POSTs from browser pages of other origins
+var crossOrigin = http.NewCrossOriginProtection()
+
+// checkRequest rejects requests a browser may send on behalf of another
+// site: a Host other than the server's own (DNS rebinding), and
+// cross-origin or non-JSON POSTs (CSRF)
+func checkRequest(r *http.Request) error {
+	if !allowedHost(r) {
+		return &httpError{http.StatusForbidden, fmt.Sprintf("host not allowed: %s (use localhost or the listen address)", r.Host)}
+	}
+	if err := crossOrigin.Check(r); err != nil {
+		return &httpError{http.StatusForbidden, err.Error()}
+	}
+	if r.Method == http.MethodPost {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			return &httpError{http.StatusUnsupportedMediaType, "Content-Type must be application/json"}
+		}
+	}
+	return nil
+}
+
+// allowedHost reports whether the Host header is localhost or the address
+// the request was received on
+func allowedHost(r *http.Request) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return false
+	}
+	localHost, _, err := net.SplitHostPort(local.String())
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.Equal(net.ParseIP(localHost))
+}
+
+// ============================================
+// Helpers
+// ============================================
+
+// httpError is an error with an HTTP status code
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &httpError{http.StatusNotFound, fmt.Sprintf(format, args...)}
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeResult writes v, or err as {"error": "..."}
+func writeResult(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		code := http.StatusInternalServerError
+		var he *httpError
+		if errors.As(err, &he) {
+			code = he.code
+		}
+		writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// decodeBody decodes a JSON request body into v
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// pathID parses a numeric path parameter
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		return 0, badRequest("invalid %s: %s", name, r.PathValue(name))
+	}
+	return id, nil
+}
+
+// queryInt parses an optional numeric query parameter
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, badRequest("invalid %s: %s", name, v)
+	}
+	return n, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNil returns an empty slice instead of nil so lists encode as []
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// ============================================
+// Workers
+// ============================================
+
+// requireWorker returns the worker or a 404 error
+func (s *Server) requireWorker(name string) (*db.Worker, error) {
+	worker, err := s.db.GetWorker(name)
+	if err != nil {
+		return nil, err
+	}
+	if worker == nil {
+		return nil, notFound("worker not found: %s", name)
+	}
+	return worker, nil
+}
+
+func (s *Server) listWorkers(w http.ResponseWriter, r *http.Request) {
+	var workers []db.Worker
+	var err error
+	if sprintID := r.URL.Query().Get("sprint"); sprintID != "" {
+		workers, err = s.db.GetSprintWorkers(sprintID)
+	} else {
+		workers, err = s.db.GetAllWorkers()
+	}
+	writeResult(w, nonNil(workers), err)
+}
+
+func (s *Server) getWorker(w http.ResponseWriter, r *http.Request) {
+	worker, err := s.requireWorker(r.PathValue("name"))
+	writeResult(w, worker, err)
+}
+
+func (s *Server) updateProgress(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var req struct {
+		Progress *int   `json:"progress"`
+		Activity string `json:"activity"`
+	}
+	err := decodeBody(r, &req)
+	if err == nil && (req.Progress == nil || *req.Progress < 0 || *req.Progress > 100) {
+		err = badRequest("progress must be a number between 0 and 100")
+	}
+	if err == nil {
+		_, err = s.requireWorker(name)
+	}
+	if err == nil {
+		err = s.db.UpdateWorkerProgress(name, *req.Progress, req.Activity)
+	}
+	if err == nil && *req.Progress == 100 && s.AutoComplete {
+		_, err = s.complete(name)
+	}
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	s.getWorker(w, r)
+}
+
+// complete marks a worker completed, through Complete if set
+func (s *Server) complete(name string) (bool, error) {
+	if s.Complete != nil {
+		return s.Complete(name)
+	}
+	return true, s.db.UpdateWorkerStatus(name, "completed", nil)
+}
+
+func (s *Server) updateStatus(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var req struct {
+		Status string `json:"status"`
+	}
+	err := decodeBody(r, &req)
+	if err == nil && !contains(validStatuses, req.Status) {
+		err = badRequest("invalid status: %s (valid: %s)", req.Status, strings.Join(validStatuses, ", "))
+	}
+	if err == nil {
+		_, err = s.requireWorker(name)
+	}
+	if err == nil {
+		err = s.db.UpdateWorkerStatus(name, req.Status, nil)
+	}
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	s.getWorker(w, r)
+}
+
+func (s *Server) updateSession(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var req struct {
+		State string `json:"state"`
+	}
+	err := decodeBody(r, &req)
+	if err == nil && !contains(validSessionStates, req.State) {
+		err = badRequest("invalid state: %s (valid: %s)", req.State, strings.Join(validSessionStates, ", "))
+	}
+	if err == nil {
+		_, err = s.requireWorker(name)
+	}
+	if err == nil {
+		err = s.db.UpdateWorkerSessionState(name, req.State)
+	}
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	s.getWorker(w, r)
+}
+
+// ============================================
+// Messages
+// ============================================
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	worker := r.URL.Query().Get("worker")
+	if worker == "" {
+		writeResult(w, nil, badRequest("worker is required (use 'pm' for the PM inbox)"))
+		return
+	}
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+	messages, err := s.db.GetMessages(worker, all)
+	writeResult(w, nonNil(messages), err)
+}
+
+// sendMessage sends a message from the PM. The API has no authentication,
+// so the sender is not taken from the request.
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To      string `json:"to"`
+		Type    string `json:"type"`
+		Subject string `json:"subject"`
+		Content string `json:"content"`
+	}
+	err := decodeBody(r, &req)
+	if err == nil && (req.To == "" || req.Content == "") {
+		err = badRequest("to and content are required")
+	}
+	if err == nil {
+		_, err = s.requireWorker(req.To)
+	}
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	if req.Type == "" {
+		req.Type = "info"
+	}
+
+	id, err := s.db.SendMessage("pm", req.To, req.Type, req.Subject, req.Content)
+	if err != nil {
+		writeResult(w, nil, badRequest("failed to send message: %v", err))
+		return
+	}
+	message, err := s.db.GetMessage(int(id))
+	writeResult(w, message, err)
+}
+
+// requireMessage returns the message with the ID in the path, or a 404 error
+func (s *Server) requireMessage(r *http.Request) (*db.Message, error) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	message, err := s.db.GetMessage(id)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, notFound("message not found: #%d", id)
+	}
+	return message, nil
+}
+
+func (s *Server) getMessage(w http.ResponseWriter, r *http.Request) {
+	message, err := s.requireMessage(r)
+	writeResult(w, message, err)
+}
+
+// replyMessage replies to a message in the PM inbox. Replies are sent as
+// the parent's recipient, so other inboxes are off limits.
+func (s *Server) replyMessage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type    string `json:"type"`
+		Subject string `json:"subject"`
+		Content string `json:"content"`
+	}
+	err := decodeBody(r, &req)
+	if err == nil && req.Content == "" {
+		err = badRequest("content is required")
+	}
+	var parent *db.Message
+	if err == nil {
+		parent, err = s.requireMessage(r)
+	}
+	if err == nil && parent.ToWorker != "pm" {
+		err = &httpError{http.StatusForbidden, fmt.Sprintf("message #%d is not in the PM inbox", parent.ID)}
+	}
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	if req.Type == "" {
+		req.Type = "reply"
+	}
+	if req.Subject == "" {
+		req.Subject = parent.ReplySubject()
+	}
+
+	reply, err := s.db.ReplyToMessage(parent.ID, "pm", req.Type, req.Subject, req.Content)
+	writeResult(w, reply, err)
+}
+
+func (s *Server) markRead(w http.ResponseWriter, r *http.Request) {
+	message, err := s.requireMessage(r)
+	if err == nil {
+		err = s.db.MarkMessageRead(message.ID)
+	}
+	if err == nil {
+		message, err = s.db.GetMessage(message.ID)
+	}
+	writeResult(w, message, err)
+}
+
+func (s *Server) getThread(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	messages, err := s.db.GetThread(id)
+	if err == nil && len(messages) == 0 {
+		err = notFound("thread not found: #%d", id)
+	}
+	writeResult(w, messages, err)
+}
+
+// ============================================
+// Events
+// ============================================
+
+// eventFilter builds a filter from repeated worker/type query parameters
+func eventFilter(r *http.Request) db.EventFilter {
+	q := r.URL.Query()
+	return db.EventFilter{Workers: q["worker"], Types: q["type"]}
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	filter := eventFilter(r)
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+
+	var events []db.Event
+	switch {
+	case r.URL.Query().Has("since"):
+		// Incremental polling: everything after an event ID, oldest first
+		var since int
+		if since, err = queryInt(r, "since", 0); err == nil {
+			events, err = s.db.GetEventsSince(since, filter)
+		}
+		writeResult(w, nonNil(events), err)
+		return
+	case r.URL.Query().Get("sprint") != "":
+		events, err = s.db.GetSprintEvents(r.URL.Query().Get("sprint"), limit, filter)
+	case len(filter.Workers) > 1 || len(filter.Types) > 1:
+		err = badRequest("multiple worker/type values require since, sprint or the stream endpoint")
+	default:
+		var worker, eventType *string
+		if len(filter.Workers) == 1 {
+			worker = &filter.Workers[0]
+		}
+		if len(filter.Types) == 1 {
+			eventType = &filter.Types[0]
+		}
+		events, err = s.db.GetRecentEvents(limit, eventType, worker)
+	}
+
+	// Oldest first, like 'devhive logs'
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	writeResult(w, nonNil(events), err)
+}
+
+func (s *Server) listEventTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := s.db.GetEventTypes()
+	writeResult(w, nonNil(types), err)
+}
+
+// streamEvents sends new events as Server-Sent Events. Clients resume
+// with the Last-Event-ID header (sent automatically by EventSource) or ?since=.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeResult(w, nil, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	lastID := -1
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastID, _ = strconv.Atoi(v)
+	} else if r.URL.Query().Has("since") {
+		since, err := queryInt(r, "since", 0)
+		if err != nil {
+			writeResult(w, nil, err)
+			return
+		}
+		lastID = since
+	}
+	if lastID < 0 {
+		id, err := s.db.GetLastEventID()
+		if err != nil {
+			writeResult(w, nil, err)
+			return
+		}
+		lastID = id
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := s.db.Subscribe(r.Context(), lastID, eventFilter(r))
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.EventType, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// ============================================
+// Sprints
+// ============================================
+
+func (s *Server) listSprints(w http.ResponseWriter, r *http.Request) {
+	sprints, err := s.db.ListSprints()
+	writeResult(w, nonNil(sprints), err)
+}
+
+func (s *Server) getActiveSprint(w http.ResponseWriter, r *http.Request) {
+	sprint, err := s.db.GetActiveSprint()
+	if err == nil && sprint == nil {
+		err = notFound("no active sprint")
+	}
+	writeResult(w, sprint, err)
+}
+
+// requireSprint returns the sprint with the ID in the path, or a 404 error
+func (s *Server) requireSprint(r *http.Request) (*db.Sprint, error) {
+	sprint, err := s.db.GetSprint(r.PathValue("id"))
+	if err == nil && sprint == nil {
+		err = notFound("sprint not found: %s", r.PathValue("id"))
+	}
+	return sprint, err
+}
+
+func (s *Server) getSprint(w http.ResponseWriter, r *http.Request) {
+	sprint, err := s.requireSprint(r)
+	writeResult(w, sprint, err)
+}
+
+func (s *Server) getSprintWorkers(w http.ResponseWriter, r *http.Request) {
+	sprint, err := s.requireSprint(r)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	workers, err := s.db.GetSprintWorkers(sprint.ID)
+	writeResult(w, nonNil(workers), err)
+}
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iguchi/devhive/internal/db"
+)
+
+// setupTestServer creates a server backed by a temporary database
+func setupTestServer(t *testing.T) (*db.DB, *httptest.Server) {
+	t.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "devhive-server-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	database, err := db.Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	database.CreateSprint("sprint-01")
+	database.RegisterWorker("fe", "sprint-01")
+	database.RegisterWorker("be", "sprint-01")
+
+	ts := httptest.NewServer(New(database))
+	t.Cleanup(func() {
+		ts.Close()
+		database.Close()
+		os.RemoveAll(tmpDir)
+	})
+	return database, ts
+}
+
+// do sends a request and decodes the JSON response into v
+func do(t *testing.T, ts *httptest.Server, method, path, body string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: invalid JSON response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestWorkers(t *testing.T) {
+	database, ts := setupTestServer(t)
+
+	var workers []db.Worker
+	if code := do(t, ts, "GET", "/api/workers", "", &workers); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(workers) != 2 {
+		t.Errorf("Expected 2 workers, got %d", len(workers))
+	}
+
+	var worker db.Worker
+	if code := do(t, ts, "POST", "/api/workers/fe/progress", `{"progress": 40, "activity": "writing tests"}`, &worker); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if worker.Progress != 40 || worker.Activity != "writing tests" {
+		t.Errorf("Expected progress 40 / 'writing tests', got %d / '%s'", worker.Progress, worker.Activity)
+	}
+
+	// Logged exactly as 'devhive progress' logs it
+	eventType := "worker_progress_updated"
+	events, _ := database.GetRecentEvents(1, &eventType, nil)
+	if len(events) != 1 || events[0].Worker != "fe" {
+		t.Errorf("Expected a worker_progress_updated event for fe, got %+v", events)
+	}
+
+	if code := do(t, ts, "POST", "/api/workers/fe/status", `{"status": "working"}`, &worker); code != http.StatusOK || worker.Status != "working" {
+		t.Errorf("Expected 200/working, got %d/%s", code, worker.Status)
+	}
+	if code := do(t, ts, "POST", "/api/workers/fe/session", `{"state": "idle"}`, &worker); code != http.StatusOK || worker.SessionState != "idle" {
+		t.Errorf("Expected 200/idle, got %d/%s", code, worker.SessionState)
+	}
+
+	var errResp map[string]string
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/api/workers/nobody", "", http.StatusNotFound},
+		{"POST", "/api/workers/nobody/progress", `{"progress": 10}`, http.StatusNotFound},
+		{"POST", "/api/workers/fe/progress", `{"progress": 101}`, http.StatusBadRequest},
+		{"POST", "/api/workers/fe/progress", `{}`, http.StatusBadRequest},
+		{"POST", "/api/workers/fe/progress", `{"percent": 10}`, http.StatusBadRequest},
+		{"POST", "/api/workers/fe/status", `{"status": "done"}`, http.StatusBadRequest},
+		{"POST", "/api/workers/fe/session", `{"state": "busy"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code := do(t, ts, tt.method, tt.path, tt.body, &errResp); code != tt.want {
+			t.Errorf("%s %s %s: expected %d, got %d", tt.method, tt.path, tt.body, tt.want, code)
+		}
+		if errResp["error"] == "" {
+			t.Errorf("%s %s: expected an error message", tt.method, tt.path)
+		}
+	}
+}
+
+func TestAutoComplete(t *testing.T) {
+	database, ts := setupTestServer(t)
+	s := ts.Config.Handler.(*Server)
+	s.AutoComplete = true
+	s.Complete = func(name string) (bool, error) {
+		if name == "be" {
+			return false, database.ReportWorkerError(name, "check test failed (exit 1)")
+		}
+		return true, database.UpdateWorkerStatus(name, "completed", nil)
+	}
+
+	var worker db.Worker
+	if code := do(t, ts, "POST", "/api/workers/fe/progress", `{"progress": 100}`, &worker); code != http.StatusOK || worker.Status != "completed" {
+		t.Errorf("Expected 200/completed, got %d/%s", code, worker.Status)
+	}
+	if code := do(t, ts, "POST", "/api/workers/be/progress", `{"progress": 100}`, &worker); code != http.StatusOK || worker.Status != "error" || worker.LastError == "" {
+		t.Errorf("Expected 200/error with last_error when Complete refuses, got %d/%s (%q)", code, worker.Status, worker.LastError)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	database, ts := setupTestServer(t)
+
+	id, err := database.SendMessage("fe", "pm", "question", "API?", "REST or gRPC?")
+	if err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	sent, _ := database.GetMessage(int(id))
+
+	var inbox []db.Message
+	do(t, ts, "GET", "/api/messages?worker=pm", "", &inbox)
+	if len(inbox) != 1 {
+		t.Fatalf("Expected 1 message in the PM inbox, got %d", len(inbox))
+	}
+
+	var reply db.Message
+	if code := do(t, ts, "POST", "/api/messages/"+itoa(sent.ID)+"/reply", `{"content": "REST"}`, &reply); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if reply.ToWorker != "fe" || reply.ReplyTo != sent.ID || reply.Subject != "Re: API?" {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+
+	// Replying marks the question read
+	do(t, ts, "GET", "/api/messages?worker=pm", "", &inbox)
+	if len(inbox) != 0 {
+		t.Errorf("Expected an empty unread inbox, got %d", len(inbox))
+	}
+
+	var thread []db.Message
+	do(t, ts, "GET", "/api/threads/"+itoa(sent.ThreadID), "", &thread)
+	if len(thread) != 2 {
+		t.Errorf("Expected 2 messages in the thread, got %d", len(thread))
+	}
+
+	var read db.Message
+	if code := do(t, ts, "POST", "/api/messages/"+itoa(reply.ID)+"/read", "", &read); code != http.StatusOK || read.ReadAt == nil {
+		t.Errorf("Expected the reply to be marked read, got %d/%+v", code, read)
+	}
+
+	// Messages are sent as the PM
+	var note db.Message
+	if code := do(t, ts, "POST", "/api/messages", `{"to": "be", "type": "info", "subject": "API", "content": "REST"}`, &note); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if note.FromWorker != "pm" || note.ToWorker != "be" || note.MessageType != "info" {
+		t.Errorf("Unexpected message: %+v", note)
+	}
+
+	var errResp map[string]string
+	if code := do(t, ts, "POST", "/api/messages", `{"from": "fe", "to": "be", "content": "hi"}`, &errResp); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a client-sent from, got %d", code)
+	}
+	if code := do(t, ts, "POST", "/api/messages/"+itoa(note.ID)+"/reply", `{"content": "ok"}`, &errResp); code != http.StatusForbidden {
+		t.Errorf("Expected 403 replying outside the PM inbox, got %d", code)
+	}
+	if code := do(t, ts, "POST", "/api/messages", `{"to": "nobody", "content": "hi"}`, &errResp); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown recipient, got %d", code)
+	}
+	if code := do(t, ts, "GET", "/api/messages", "", &errResp); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without worker, got %d", code)
+	}
+	if code := do(t, ts, "GET", "/api/messages/999", "", &errResp); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown message, got %d", code)
+	}
+}
+
+func TestEventsAndSprints(t *testing.T) {
+	database, ts := setupTestServer(t)
+	database.UpdateWorkerProgress("fe", 10, "")
+	database.UpdateWorkerProgress("be", 20, "")
+
+	var events []db.Event
+	do(t, ts, "GET", "/api/events?worker=fe&type=worker_progress_updated", "", &events)
+	if len(events) != 1 || events[0].Worker != "fe" {
+		t.Errorf("Expected fe's progress event, got %+v", events)
+	}
+
+	do(t, ts, "GET", "/api/events?since=0&type=worker_registered", "", &events)
+	if len(events) != 2 || events[0].ID > events[1].ID {
+		t.Errorf("Expected 2 worker_registered events in ID order, got %+v", events)
+	}
+
+	var sprint db.Sprint
+	if code := do(t, ts, "GET", "/api/sprints/active", "", &sprint); code != http.StatusOK || sprint.ID != "sprint-01" {
+		t.Errorf("Expected active sprint-01, got %d/%s", code, sprint.ID)
+	}
+	var workers []db.Worker
+	do(t, ts, "GET", "/api/sprints/sprint-01/workers", "", &workers)
+	if len(workers) != 2 {
+		t.Errorf("Expected 2 sprint workers, got %d", len(workers))
+	}
+	var errResp map[string]string
+	if code := do(t, ts, "GET", "/api/sprints/nope", "", &errResp); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown sprint, got %d", code)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	database, ts := setupTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/events/stream?worker=fe", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %s", ct)
+	}
+
+	database.UpdateWorkerProgress("be", 30, "")
+	database.UpdateWorkerProgress("fe", 50, "")
+
+	// Read one SSE message
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" && len(fields) > 0 {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok && key != "" {
+			fields[key] = value
+		}
+	}
+
+	if fields["event"] != "worker_progress_updated" {
+		t.Errorf("Expected event worker_progress_updated, got %q", fields["event"])
+	}
+	var e db.Event
+	if err := json.Unmarshal([]byte(fields["data"]), &e); err != nil {
+		t.Fatalf("Invalid event data %q: %v", fields["data"], err)
+	}
+	if e.Worker != "fe" || itoa(e.ID) != fields["id"] {
+		t.Errorf("Expected fe's event with id %s, got %+v", fields["id"], e)
+	}
+}
+
+func itoa(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestDashboard(t *testing.T) {
+	_, ts := setupTestServer(t)
+
+	for _, path := range []string{"/", "/app.js", "/style.css"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, resp.StatusCode)
+		}
+	}
+
+	var types []string
+	do(t, ts, "GET", "/api/event-types", "", &types)
+	found := false
+	for _, typ := range types {
+		if typ == "worker_progress_updated" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected worker_progress_updated in event types, got %v", types)
+	}
+}
+
+func TestRequestChecks(t *testing.T) {
+	_, ts := setupTestServer(t)
+	port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+
+	tests := []struct {
+		name    string
+		method  string
+		host    string
+		headers map[string]string
+		want    int
+	}{
+		{"own address", "GET", "", nil, http.StatusOK},
+		{"localhost", "GET", "localhost:" + port, nil, http.StatusOK},
+		{"rebound host", "GET", "evil.example:" + port, nil, http.StatusForbidden},
+		{"other address", "GET", "10.0.0.1:" + port, nil, http.StatusForbidden},
+		{"json post", "POST", "", map[string]string{"Content-Type": "application/json; charset=utf-8"}, http.StatusOK},
+		{"no content type", "POST", "", nil, http.StatusUnsupportedMediaType},
+		{"form post", "POST", "", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType},
+		{"same origin", "POST", "", map[string]string{"Content-Type": "application/json", "Origin": ts.URL}, http.StatusOK},
+		{"cross origin", "POST", "", map[string]string{"Content-Type": "application/json", "Origin": "http://evil.example"}, http.StatusForbidden},
+		{"cross site", "POST", "", map[string]string{"Content-Type": "application/json", "Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, body := "/api/workers", ""
+			if tt.method == "POST" {
+				path, body = "/api/workers/fe/status", `{"status": "working"}`
+			}
+			req, err := http.NewRequest(tt.method, ts.URL+path, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("NewRequest failed: %v", err)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s failed: %v", tt.method, path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package watch implements rules that detect stalled or struggling workers
+// from observable facts (events, commits, session output, session state).
+package watch
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rule names recorded in worker_stalled events
+const (
+	RuleStalled        = "stalled"
+	RuleErrorStorm     = "error_storm"
+	RulePermissionWait = "permission_wait"
+)
+
+// Rules holds the thresholds for each rule (zero disables a rule)
+type Rules struct {
+	Stall          time.Duration // No activity for this long
+	ErrorThreshold int           // Errors within ErrorWindow that count as a storm
+	ErrorWindow    time.Duration
+	Permission     time.Duration // Waiting for permission for this long
+}
+
+// DefaultRules returns the thresholds used when nothing is configured
+func DefaultRules() Rules {
+	return Rules{
+		Stall:          30 * time.Minute,
+		ErrorThreshold: 5,
+		ErrorWindow:    10 * time.Minute,
+		Permission:     10 * time.Minute,
+	}
+}
+
+// Facts are the observations about a single worker
+type Facts struct {
+	Worker       string
+	Status       string
+	SessionState string
+	LastActivity time.Time            // Latest event, commit or session output (zero if unknown)
+	SessionSince time.Time            // When the session state was last changed
+	RecentErrors int                  // Errors reported within the error window
+	LastAlerts   map[string]time.Time // Last alert time per rule
+}
+
+// Finding is a rule that fired for a worker
+type Finding struct {
+	Worker  string
+	Rule    string
+	Message string
+}
+
+// Evaluate applies the rules to a worker's facts
+// A rule fires at most once per episode: not again until new activity (or a new window) occurs
+func Evaluate(f Facts, r Rules, now time.Time) []Finding {
+	var findings []Finding
+
+	// Stalled: working but nothing observable happened for a while
+	if r.Stall > 0 && f.Status == "working" && !f.LastActivity.IsZero() {
+		idle := now.Sub(f.LastActivity)
+		if idle >= r.Stall && !alertedSince(f, RuleStalled, f.LastActivity) {
+			findings = append(findings, Finding{
+				Worker:  f.Worker,
+				Rule:    RuleStalled,
+				Message: fmt.Sprintf("no events, commits or session output for %d minutes", int(idle.Minutes())),
+			})
+		}
+	}
+
+	// Error storm: many errors in a short window
+	if r.ErrorThreshold > 0 && r.ErrorWindow > 0 && f.RecentErrors >= r.ErrorThreshold {
+		if !alertedSince(f, RuleErrorStorm, now.Add(-r.ErrorWindow)) {
+			findings = append(findings, Finding{
+				Worker:  f.Worker,
+				Rule:    RuleErrorStorm,
+				Message: fmt.Sprintf("%d errors in the last %d minutes", f.RecentErrors, int(r.ErrorWindow.Minutes())),
+			})
+		}
+	}
+
+	// Permission wait: the agent is blocked on a prompt
+	if r.Permission > 0 && f.SessionState == "waiting_permission" && !f.SessionSince.IsZero() {
+		waiting := now.Sub(f.SessionSince)
+		if waiting >= r.Permission && !alertedSince(f, RulePermissionWait, f.SessionSince) {
+			findings = append(findings, Finding{
+				Worker:  f.Worker,
+				Rule:    RulePermissionWait,
+				Message: fmt.Sprintf("waiting for permission for %d minutes", int(waiting.Minutes())),
+			})
+		}
+	}
+
+	return findings
+}
+
+// alertedSince reports whether the rule already fired for the worker after t
+func alertedSince(f Facts, rule string, t time.Time) bool {
+	last, ok := f.LastAlerts[rule]
+	return ok && !last.Before(t)
+}
@@ -0,0 +1,88 @@
+package watch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateStalled(t *testing.T) {
+	now := time.Now()
+	rules := DefaultRules()
+
+	facts := Facts{
+		Worker:       "fe",
+		Status:       "working",
+		LastActivity: now.Add(-45 * time.Minute),
+	}
+	findings := Evaluate(facts, rules, now)
+	if len(findings) != 1 || findings[0].Rule != RuleStalled {
+		t.Fatalf("Expected stalled finding, got %+v", findings)
+	}
+
+	// Already alerted for this episode
+	facts.LastAlerts = map[string]time.Time{RuleStalled: now.Add(-5 * time.Minute)}
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no repeat alert, got %+v", findings)
+	}
+
+	// Recent activity
+	facts.LastAlerts = nil
+	facts.LastActivity = now.Add(-5 * time.Minute)
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no finding for active worker, got %+v", findings)
+	}
+
+	// Only working workers can stall
+	facts.Status = "completed"
+	facts.LastActivity = now.Add(-2 * time.Hour)
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no finding for completed worker, got %+v", findings)
+	}
+}
+
+func TestEvaluateErrorStorm(t *testing.T) {
+	now := time.Now()
+	rules := DefaultRules()
+
+	facts := Facts{Worker: "be", Status: "error", RecentErrors: 5}
+	findings := Evaluate(facts, rules, now)
+	if len(findings) != 1 || findings[0].Rule != RuleErrorStorm {
+		t.Fatalf("Expected error storm finding, got %+v", findings)
+	}
+
+	// Alerted within the window
+	facts.LastAlerts = map[string]time.Time{RuleErrorStorm: now.Add(-time.Minute)}
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no repeat alert, got %+v", findings)
+	}
+
+	// Below threshold
+	facts.LastAlerts = nil
+	facts.RecentErrors = 4
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no finding below threshold, got %+v", findings)
+	}
+}
+
+func TestEvaluatePermissionWait(t *testing.T) {
+	now := time.Now()
+	rules := DefaultRules()
+
+	facts := Facts{
+		Worker:       "fe",
+		Status:       "working",
+		SessionState: "waiting_permission",
+		SessionSince: now.Add(-15 * time.Minute),
+		LastActivity: now.Add(-15 * time.Minute),
+	}
+	findings := Evaluate(facts, rules, now)
+	if len(findings) != 1 || findings[0].Rule != RulePermissionWait {
+		t.Fatalf("Expected permission wait finding, got %+v", findings)
+	}
+
+	// Disabled rule
+	rules.Permission = 0
+	if findings := Evaluate(facts, rules, now); len(findings) != 0 {
+		t.Errorf("Expected no finding with rule disabled, got %+v", findings)
+	}
+}